@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nichecode/pipeline-analyzer/internal/circleci"
+	"github.com/nichecode/pipeline-analyzer/internal/convert"
+	"github.com/nichecode/pipeline-analyzer/internal/discovery"
+	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// runConvert implements the convert command
+func runConvert(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	var (
+		from   = fs.String("from", "", "Source format: circleci or github-actions")
+		to     = fs.String("to", "", "Target format: github-actions or circleci")
+		output = fs.String("output", "", "Directory to write the converted files to (default: the repository)")
+		force  = fs.Bool("force", false, "Overwrite existing files")
+		stdout = fs.Bool("stdout", false, "Print the converted files instead of writing them")
+		report = fs.String("report", "", "Write a markdown conversion report to this file")
+		debug  = fs.Bool("debug", false, "Enable debug logging")
+	)
+	fs.Usage = func() {
+		fmt.Printf("USAGE:\n")
+		fmt.Printf("  pipeline-analyzer convert --from circleci --to github-actions [options] [repository-path]\n")
+		fmt.Printf("  pipeline-analyzer convert --from github-actions --to circleci [options] [repository-path]\n\n")
+		fmt.Printf("  Converts a CI configuration to another system. Constructs without an\n")
+		fmt.Printf("  equivalent are kept with TODO comments that are also listed after the run.\n\n")
+		fmt.Printf("OPTIONS:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	logLevel := shared.LogLevelWarn
+	if *debug {
+		logLevel = shared.LogLevelDebug
+	}
+	if err := shared.InitLogger(logLevel, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+	}
+	defer shared.GetLogger().Close()
+
+	supported := (*from == ir.ToolCircleCI && *to == ir.ToolGitHubActions) ||
+		(*from == ir.ToolGitHubActions && *to == ir.ToolCircleCI)
+	if !supported {
+		fmt.Fprintf(os.Stderr, "❌ Unsupported conversion: --from %q --to %q (supported: circleci ↔ github-actions)\n", *from, *to)
+		os.Exit(1)
+	}
+
+	repoPath := "."
+	if fs.NArg() > 0 {
+		repoPath = fs.Arg(0)
+	}
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid repository path: %v\n", err)
+		os.Exit(1)
+	}
+
+	repo, err := discovery.NewScanner(absPath).ScanRepository()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to scan repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	var configPath string
+	for _, tool := range repo.BuildTools {
+		if tool.Type == *from {
+			configPath = filepath.Join(repo.RootPath, tool.ConfigPath)
+			break
+		}
+	}
+	if configPath == "" {
+		fmt.Fprintf(os.Stderr, "❌ No %s configuration found in %s\n", *from, absPath)
+		os.Exit(1)
+	}
+
+	var result *convert.Result
+	if *from == ir.ToolCircleCI {
+		result, err = convertCircleCI(repo.RootPath, configPath)
+	} else {
+		result, err = convertGitHubActions(repo.RootPath, configPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Conversion failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	// With --stdout the YAML is the only thing on stdout
+	notes := os.Stdout
+	if *stdout {
+		notes = os.Stderr
+		for i, file := range result.Files {
+			if i > 0 {
+				fmt.Println("---")
+			}
+			fmt.Printf("# File: %s\n", file.Path)
+			os.Stdout.Write(file.Content)
+		}
+	} else {
+		outputDir := absPath
+		if *output != "" {
+			if outputDir, err = filepath.Abs(*output); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Invalid output directory: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := writeConvertedFiles(outputDir, result.Files, *force); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		for _, file := range result.Files {
+			fmt.Printf("✅ Wrote %s\n", filepath.Join(outputDir, filepath.FromSlash(file.Path)))
+		}
+	}
+
+	if len(result.Notes) > 0 {
+		fmt.Fprintf(notes, "\n⚠️  %d TODO items need manual review:\n", len(result.Notes))
+		for _, note := range result.Notes {
+			if note.Job != "" {
+				fmt.Fprintf(notes, "  - %s (%s): %s\n", note.File, note.Job, note.Message)
+			} else {
+				fmt.Fprintf(notes, "  - %s: %s\n", note.File, note.Message)
+			}
+		}
+	}
+	if len(result.UnknownActions) > 0 {
+		fmt.Fprintf(notes, "\n❓ %d actions have no equivalent and were replaced by placeholders\n", len(result.UnknownActions))
+	}
+
+	if *report != "" {
+		if err := os.WriteFile(*report, []byte(convert.FormatReport(result)), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(notes, "📝 Report written to %s\n", *report)
+	}
+}
+
+// convertCircleCI converts the CircleCI config at configPath
+func convertCircleCI(root, configPath string) (*convert.Result, error) {
+	config, err := circleci.ParseConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CircleCI config: %w", err)
+	}
+	circleci.ResolveOrbs(config, filepath.Join(root, circleci.OrbCacheDir))
+
+	source, err := filepath.Rel(root, configPath)
+	if err != nil {
+		source = configPath
+	}
+	return convert.CircleCIToGitHubActions(config, filepath.ToSlash(source))
+}
+
+// convertGitHubActions converts the workflows in workflowsDir, inlining local
+// composite actions and reusable workflows first
+func convertGitHubActions(root, workflowsDir string) (*convert.Result, error) {
+	workflows, err := githubactions.NewParser().ParseWorkflowsDirectory(workflowsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub Actions workflows: %w", err)
+	}
+	for _, workflow := range workflows {
+		githubactions.ResolveLocalReferences(workflow, root)
+	}
+	return convert.GitHubActionsToCircleCI(workflows)
+}
+
+// writeConvertedFiles writes every file under dir, refusing to replace
+// existing files unless force is set. Nothing is written when any file exists.
+func writeConvertedFiles(dir string, files []convert.File, force bool) error {
+	if !force {
+		for _, file := range files {
+			target := filepath.Join(dir, filepath.FromSlash(file.Path))
+			if _, err := os.Stat(target); err == nil {
+				return fmt.Errorf("%s already exists (use --force to overwrite or --output to write elsewhere)", target)
+			}
+		}
+	}
+
+	for _, file := range files {
+		target := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", target, err)
+		}
+		if err := os.WriteFile(target, file.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+	return nil
+}
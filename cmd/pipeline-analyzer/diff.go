@@ -0,0 +1,197 @@
+package main
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/discovery"
+	"github.com/nichecode/pipeline-analyzer/internal/report"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// runDiff implements the diff command
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	var (
+		repoPath = fs.String("repo", ".", "Git repository the refs belong to")
+		format   = fs.String("format", "markdown", "Output format: markdown or json")
+		output   = fs.String("output", "", "Write the change report to this file instead of stdout")
+		debug    = fs.Bool("debug", false, "Enable debug logging")
+	)
+	fs.Usage = func() {
+		fmt.Printf("USAGE:\n")
+		fmt.Printf("  pipeline-analyzer diff [options] <refA> <refB>\n")
+		fmt.Printf("  pipeline-analyzer diff [options] <reportA.json> <reportB.json>\n\n")
+		fmt.Printf("  Compares the pipelines of two git refs, or two saved report.json files, and\n")
+		fmt.Printf("  lists added and removed jobs, dependencies, images, actions and commands,\n")
+		fmt.Printf("  new risky commands and the change in the headline metrics. Refs are read\n")
+		fmt.Printf("  with git archive, so the working tree is left untouched.\n\n")
+		fmt.Printf("OPTIONS:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	logLevel := shared.LogLevelWarn
+	if *debug {
+		logLevel = shared.LogLevelDebug
+	}
+	if err := shared.InitLogger(logLevel, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+	}
+	defer shared.GetLogger().Close()
+
+	if *format != "markdown" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unsupported output format: %s (expected markdown or json)\n", *format)
+		os.Exit(1)
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	from, err := loadDiffSide(*repoPath, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	to, err := loadDiffSide(*repoPath, fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	diff := report.Compare(from, to)
+	diff.From, diff.To = fs.Arg(0), fs.Arg(1)
+
+	var content []byte
+	if *format == "json" {
+		content, err = json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to encode diff: %v\n", err)
+			os.Exit(1)
+		}
+		content = append(content, '\n')
+	} else {
+		content = []byte(report.FormatDiff(diff))
+	}
+
+	if *output == "" {
+		os.Stdout.Write(content)
+		return
+	}
+	if err := os.WriteFile(*output, content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "📄 Diff written to %s\n", *output)
+}
+
+// loadDiffSide reads a saved report.json, or analyzes the tree of a git ref
+func loadDiffSide(repoPath, arg string) (*report.Report, error) {
+	if stat, err := os.Stat(arg); err == nil && !stat.IsDir() && strings.HasSuffix(arg, ".json") {
+		return report.ReadFile(arg)
+	}
+
+	dir, err := os.MkdirTemp("", "pipeline-analyzer-diff-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractGitRef(repoPath, arg, dir); err != nil {
+		return nil, err
+	}
+	// Analysis progress goes to stderr so stdout carries only the change report
+	fmt.Fprintf(os.Stderr, "🔍 Analyzing %s\n", arg)
+	return analyzeTree(dir)
+}
+
+// extractGitRef writes the files of a git ref into dir using git archive
+func extractGitRef(repoPath, ref, dir string) error {
+	cmd := exec.Command("git", "-C", repoPath, "archive", "--format=tar", ref)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	archive, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to run git archive: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run git archive: %w", err)
+	}
+
+	extractErr := extractTar(archive, dir)
+	// Drain the archive so git does not block on a full pipe
+	io.Copy(io.Discard, archive)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to read git ref %q: %s", ref, strings.TrimSpace(stderr.String()))
+	}
+	return extractErr
+}
+
+// extractTar unpacks the directories and regular files of a tar stream
+func extractTar(r io.Reader, dir string) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read git archive: %w", err)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return fmt.Errorf("failed to create file: %w", err)
+			}
+			_, err = io.Copy(file, reader)
+			file.Close()
+			if err != nil {
+				return fmt.Errorf("failed to extract file: %w", err)
+			}
+		}
+	}
+}
+
+// analyzeTree runs the analyzers over an extracted tree and returns its report
+func analyzeTree(dir string) (*report.Report, error) {
+	repo, discoveryDir, err := discovery.NewScanner(dir).ScanAndCreateStructure()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan repository: %w", err)
+	}
+
+	analyzer := discovery.NewAnalyzer(repo, discoveryDir)
+	analyzer.SetOutput(os.Stderr)
+	results, err := analyzer.AnalyzeAll()
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	// The overview measures migration coverage, which the diff compares
+	if err := analyzer.GenerateOverview(results); err != nil {
+		return nil, fmt.Errorf("failed to generate overview: %w", err)
+	}
+	if err := analyzer.GenerateCrossToolReport(); err != nil {
+		return nil, fmt.Errorf("failed to generate cross-tool report: %w", err)
+	}
+	return analyzer.WriteJSONReport(results, version)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nichecode/pipeline-analyzer/internal/gotask"
+	"github.com/nichecode/pipeline-analyzer/internal/report"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// runLint implements the lint command
+func runLint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	var (
+		format = fs.String("format", "text", "Output format: text or json")
+		debug  = fs.Bool("debug", false, "Enable debug logging")
+	)
+	fs.Usage = func() {
+		fmt.Printf("USAGE:\n")
+		fmt.Printf("  pipeline-analyzer lint [options] [repository-path | Taskfile]\n\n")
+		fmt.Printf("  Checks a Taskfile and the Taskfiles it includes against the Taskfile v3\n")
+		fmt.Printf("  schema: unknown or misspelled keys, values of the wrong type, missing\n")
+		fmt.Printf("  required keys and includes that cannot be loaded. Each problem is printed\n")
+		fmt.Printf("  as file:line:column with its severity. Exits with status 1 on errors.\n\n")
+		fmt.Printf("OPTIONS:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	logLevel := shared.LogLevelWarn
+	if *debug {
+		logLevel = shared.LogLevelDebug
+	}
+	if err := shared.InitLogger(logLevel, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+	}
+	defer shared.GetLogger().Close()
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unsupported output format: %s (expected text or json)\n", *format)
+		os.Exit(1)
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	target := "."
+	if fs.NArg() == 1 {
+		target = fs.Arg(0)
+	}
+	taskfilePath := target
+	if stat, err := os.Stat(target); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s does not exist\n", target)
+		os.Exit(1)
+	} else if stat.IsDir() {
+		taskfilePath, err = gotask.FindTaskfile(target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ No Taskfile found in %s\n", target)
+			os.Exit(1)
+		}
+	}
+
+	diagnostics, err := gotask.LintProject(taskfilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == "json" {
+		entries := []report.GoTaskDiagnostic{}
+		for _, diagnostic := range diagnostics {
+			entries = append(entries, report.GoTaskDiagnostic{
+				File:     diagnostic.File,
+				Line:     diagnostic.Line,
+				Column:   diagnostic.Column,
+				Severity: diagnostic.Severity,
+				Rule:     diagnostic.Rule,
+				Message:  diagnostic.Message,
+			})
+		}
+		content, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to encode diagnostics: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(content))
+	} else {
+		for _, diagnostic := range diagnostics {
+			fmt.Println(diagnostic.String())
+		}
+	}
+
+	errors, warnings := gotask.CountDiagnostics(diagnostics)
+	if errors+warnings == 0 {
+		fmt.Fprintf(os.Stderr, "✅ No problems found in %s\n", taskfilePath)
+	} else {
+		fmt.Fprintf(os.Stderr, "%d errors, %d warnings\n", errors, warnings)
+	}
+	if errors > 0 {
+		os.Exit(1)
+	}
+}
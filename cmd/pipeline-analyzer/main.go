@@ -3,10 +3,12 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/nichecode/pipeline-analyzer/internal/discovery"
+	"github.com/nichecode/pipeline-analyzer/internal/report"
 	"github.com/nichecode/pipeline-analyzer/internal/shared"
 )
 
@@ -16,11 +18,33 @@ var (
 )
 
 func main() {
+	// Subcommands have their own flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "simulate":
+			runSimulate(os.Args[2:])
+			return
+		case "convert":
+			runConvert(os.Args[2:])
+			return
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "lint":
+			runLint(os.Args[2:])
+			return
+		}
+	}
+
 	var (
 		repoPath    = flag.String("path", ".", "Path to repository root (default: current directory)")
 		showVersion = flag.Bool("version", false, "Show version information")
 		help        = flag.Bool("help", false, "Show help")
 		debug       = flag.Bool("debug", false, "Enable debug logging")
+		format      = flag.String("format", "markdown", "Output format: markdown or json (report.json is always written)")
 	)
 	flag.Parse()
 
@@ -45,6 +69,11 @@ func main() {
 		return
 	}
 
+	if *format != "markdown" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unsupported output format: %s (expected markdown or json)\n", *format)
+		os.Exit(1)
+	}
+
 	// Handle command line args for repo path
 	args := flag.Args()
 	if len(args) > 0 {
@@ -64,15 +93,29 @@ func main() {
 		os.Exit(1)
 	}
 
-	fmt.Printf("pipeline-analyzer %s\n", version)
-	fmt.Printf("🔍 Scanning repository: %s\n", absPath)
+	// In JSON mode stdout carries only the report, so progress goes to stderr
+	var out io.Writer = os.Stdout
+	if *format == "json" {
+		out = os.Stderr
+	}
+
+	fmt.Fprintf(out, "pipeline-analyzer %s\n", version)
+	fmt.Fprintf(out, "🔍 Scanning repository: %s\n", absPath)
 
 	// Run auto-discovery and analysis
-	runAutoDiscovery(absPath)
+	rep := runAutoDiscovery(absPath, out)
+
+	if *format == "json" {
+		if err := report.Encode(os.Stdout, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write JSON report: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
-// runAutoDiscovery performs automatic discovery and analysis of all build tools
-func runAutoDiscovery(repoPath string) {
+// runAutoDiscovery performs automatic discovery and analysis of all build tools,
+// printing progress to out
+func runAutoDiscovery(repoPath string, out io.Writer) *report.Report {
 	// Create scanner for the repository
 	scanner := discovery.NewScanner(repoPath)
 	
@@ -92,16 +135,18 @@ func runAutoDiscovery(repoPath string) {
 
 	// Check if any build tools were found
 	if len(repo.BuildTools) == 0 {
-		fmt.Printf("⚠️  No supported build tools found in repository\n")
-		fmt.Printf("📁 Repository: %s\n", repoPath)
-		fmt.Printf("🔍 Searched for: CircleCI, Go Task, GitHub Actions, npm, Composer, Cargo, Maven, Gradle, Makefile, Docker, Python, Terraform\n")
-		os.Exit(0)
+		fmt.Fprintf(out, "⚠️  No supported build tools found in repository\n")
+		fmt.Fprintf(out, "📁 Repository: %s\n", repoPath)
+		fmt.Fprintf(out, "🔍 Searched for: CircleCI, Go Task, GitHub Actions, npm, Composer, Cargo, Maven, Gradle, Makefile, Docker, Python, Terraform\n")
+		// An empty report keeps --format json output a valid document
+		return report.New(repo.RootPath, repo.GitRepo, version)
 	}
 
-	fmt.Printf("📁 Discovery directory: %s\n\n", discoveryDir)
+	fmt.Fprintf(out, "📁 Discovery directory: %s\n\n", discoveryDir)
 
 	// Create analyzer and run analysis on all discovered tools
 	analyzer := discovery.NewAnalyzer(repo, discoveryDir)
+	analyzer.SetOutput(out)
 	
 	results, err := analyzer.AnalyzeAll()
 	if err != nil {
@@ -109,6 +154,11 @@ func runAutoDiscovery(repoPath string) {
 		os.Exit(1)
 	}
 
+	// Record this run's metrics so the overview can chart migration progress
+	if err := analyzer.RecordHistory(version); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to record history: %v\n", err)
+	}
+
 	// Generate overview
 	if err := analyzer.GenerateOverview(results); err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Failed to generate overview: %v\n", err)
@@ -121,12 +171,27 @@ func runAutoDiscovery(repoPath string) {
 		os.Exit(1)
 	}
 
+	// Generate cross-tool comparison over the shared pipeline model
+	if err := analyzer.GenerateCrossToolReport(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to generate cross-tool report: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Write machine-readable report
+	rep, err := analyzer.WriteJSONReport(results, version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write JSON report: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Print final summary
-	printFinalSummary(repo, discoveryDir, results)
+	printFinalSummary(out, repo, discoveryDir, results)
+
+	return rep
 }
 
-// printFinalSummary prints the final summary of the analysis
-func printFinalSummary(repo *discovery.Repository, discoveryDir string, results []discovery.AnalysisResult) {
+// printFinalSummary prints the final summary of the analysis to out
+func printFinalSummary(out io.Writer, repo *discovery.Repository, discoveryDir string, results []discovery.AnalysisResult) {
 	successCount := 0
 	for _, result := range results {
 		if result.Success {
@@ -134,21 +199,21 @@ func printFinalSummary(repo *discovery.Repository, discoveryDir string, results
 		}
 	}
 
-	fmt.Printf("🎉 Analysis Complete!\n\n")
-	fmt.Printf("📊 Summary:\n")
-	fmt.Printf("   - Repository: %s\n", repo.RootPath)
-	fmt.Printf("   - Build tools found: %d\n", len(repo.BuildTools))
-	fmt.Printf("   - Successfully analyzed: %d\n", successCount)
-	fmt.Printf("   - Failed: %d\n\n", len(results)-successCount)
+	fmt.Fprintf(out, "🎉 Analysis Complete!\n\n")
+	fmt.Fprintf(out, "📊 Summary:\n")
+	fmt.Fprintf(out, "   - Repository: %s\n", repo.RootPath)
+	fmt.Fprintf(out, "   - Build tools found: %d\n", len(repo.BuildTools))
+	fmt.Fprintf(out, "   - Successfully analyzed: %d\n", successCount)
+	fmt.Fprintf(out, "   - Failed: %d\n\n", len(results)-successCount)
 
-	fmt.Printf("📁 Results location: %s\n", discoveryDir)
-	fmt.Printf("🚀 Start here: %s/README.md\n\n", discoveryDir)
+	fmt.Fprintf(out, "📁 Results location: %s\n", discoveryDir)
+	fmt.Fprintf(out, "🚀 Start here: %s/README.md\n\n", discoveryDir)
 
 	if successCount > 0 {
-		fmt.Printf("🔗 Quick links:\n")
+		fmt.Fprintf(out, "🔗 Quick links:\n")
 		for _, result := range results {
 			if result.Success {
-				fmt.Printf("   - %s: %s/README.md\n", result.Tool.Name, result.OutputDir)
+				fmt.Fprintf(out, "   - %s: %s/README.md\n", result.Tool.Name, result.OutputDir)
 			}
 		}
 	}
@@ -163,16 +228,35 @@ func printUsage() {
 	fmt.Printf("  in a standardized .discovery folder structure.\n\n")
 
 	fmt.Printf("USAGE:\n")
-	fmt.Printf("  pipeline-analyzer [repository-path]\n\n")
+	fmt.Printf("  pipeline-analyzer [repository-path]\n")
+	fmt.Printf("  pipeline-analyzer <command> [options] [repository-path]\n\n")
+
+	fmt.Printf("COMMANDS:\n")
+	fmt.Printf("  simulate                            List the workflows and jobs a --branch or --tag push runs\n")
+	fmt.Printf("  convert                             Convert between CircleCI and GitHub Actions configs\n")
+	fmt.Printf("  migrate                             Generate Taskfile.generated.yml from CI run steps\n")
+	fmt.Printf("  diff                                Compare the pipelines of two git refs or report.json files\n")
+	fmt.Printf("  lint                                Check Taskfiles against the Taskfile v3 schema\n\n")
 
 	fmt.Printf("EXAMPLES:\n")
 	fmt.Printf("  pipeline-analyzer                    # Analyze current directory\n")
 	fmt.Printf("  pipeline-analyzer /path/to/repo     # Analyze specific repository\n")
 	fmt.Printf("  pipeline-analyzer ../my-project     # Analyze relative path\n")
-	fmt.Printf("  pipeline-analyzer --debug /repo            # Enable debug logging\n\n")
+	fmt.Printf("  pipeline-analyzer --debug /repo            # Enable debug logging\n")
+	fmt.Printf("  pipeline-analyzer --format json /repo      # Print the JSON report to stdout\n")
+	fmt.Printf("  pipeline-analyzer simulate --branch main --tag v1.2.0 /repo\n")
+	fmt.Printf("  pipeline-analyzer convert --from circleci --to github-actions /repo\n")
+	fmt.Printf("  pipeline-analyzer convert --from github-actions --to circleci --report report.md /repo\n")
+	fmt.Printf("  pipeline-analyzer migrate /repo\n")
+	fmt.Printf("  pipeline-analyzer migrate --dry-run --patch migrate.patch /repo\n")
+	fmt.Printf("  pipeline-analyzer diff main HEAD\n")
+	fmt.Printf("  pipeline-analyzer diff --format json old/report.json new/report.json\n")
+	fmt.Printf("  pipeline-analyzer lint /repo\n")
+	fmt.Printf("  pipeline-analyzer lint --format json Taskfile.yml\n\n")
 	
 	fmt.Printf("OPTIONS:\n")
 	fmt.Printf("  --debug                             Enable debug logging (logs written to .discovery/logs/)\n")
+	fmt.Printf("  --format <markdown|json>            Output format (report.json is always written)\n")
 	fmt.Printf("  --version                           Show version information\n")
 	fmt.Printf("  --help                              Show this help message\n\n")
 
@@ -199,6 +283,9 @@ func printUsage() {
 	fmt.Printf("  📁 .discovery/pipeline-analyzer/\n")
 	fmt.Printf("  ├── README.md                    # Discovery overview\n")
 	fmt.Printf("  ├── index.html                   # HTML navigation\n")
+	fmt.Printf("  ├── report.json                  # Machine-readable report\n")
+	fmt.Printf("  ├── history.jsonl                # Metrics of every run for trend charts\n")
+	fmt.Printf("  ├── cross-tool.md                # Commands and images shared across tools\n")
 	fmt.Printf("  ├── logs/                        # Debug and error logs\n")
 	fmt.Printf("  ├── circleci/                    # CircleCI analysis (if found)\n")
 	fmt.Printf("  ├── gotask/                      # Go Task analysis (if found)\n")
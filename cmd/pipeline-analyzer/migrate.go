@@ -0,0 +1,270 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/circleci"
+	"github.com/nichecode/pipeline-analyzer/internal/convert"
+	"github.com/nichecode/pipeline-analyzer/internal/discovery"
+	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// runMigrate implements the migrate command
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var (
+		output  = fs.String("output", "", "Directory to write "+convert.TaskfileName+" to (default: the repository)")
+		force   = fs.Bool("force", false, "Overwrite an existing "+convert.TaskfileName)
+		stdout  = fs.Bool("stdout", false, "Print the generated Taskfile instead of writing it")
+		report  = fs.String("report", "", "Write a markdown migration report to this file")
+		noMerge = fs.Bool("no-merge", false, "Write "+convert.TaskfileName+" even when the repository has a Taskfile")
+		apply   = fs.Bool("apply", false, "Also rewrite the CI run steps to call the tasks")
+		dryRun  = fs.Bool("dry-run", false, "Print the changes --apply makes as a unified diff without writing files")
+		patch   = fs.String("patch", "", "Write the changes as a patch file (with --apply or --dry-run)")
+		debug   = fs.Bool("debug", false, "Enable debug logging")
+	)
+	fs.Usage = func() {
+		fmt.Printf("USAGE:\n")
+		fmt.Printf("  pipeline-analyzer migrate [options] [repository-path]\n\n")
+		fmt.Printf("  Generates %s with one go-task task per CircleCI and GitHub\n", convert.TaskfileName)
+		fmt.Printf("  Actions job, running the job's shell steps with its env and working directory.\n")
+		fmt.Printf("  When the repository has a Taskfile the tasks are merged into it instead:\n")
+		fmt.Printf("  tasks that already run a job's commands are reused and comments are kept.\n\n")
+		fmt.Printf("  With --apply the run steps of each job are also replaced by a task call in\n")
+		fmt.Printf("  .circleci/config.yml and .github/workflows/*.yml. --dry-run prints the same\n")
+		fmt.Printf("  changes as a unified diff instead of writing them.\n\n")
+		fmt.Printf("OPTIONS:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	logLevel := shared.LogLevelWarn
+	if *debug {
+		logLevel = shared.LogLevelDebug
+	}
+	if err := shared.InitLogger(logLevel, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+	}
+	defer shared.GetLogger().Close()
+
+	switch {
+	case *apply && *dryRun:
+		fmt.Fprintf(os.Stderr, "❌ --apply and --dry-run cannot be combined\n")
+		os.Exit(1)
+	case *stdout && (*apply || *dryRun):
+		fmt.Fprintf(os.Stderr, "❌ --stdout cannot be combined with --apply or --dry-run\n")
+		os.Exit(1)
+	case *patch != "" && !*apply && !*dryRun:
+		fmt.Fprintf(os.Stderr, "❌ --patch requires --apply or --dry-run\n")
+		os.Exit(1)
+	}
+
+	repoPath := "."
+	if fs.NArg() > 0 {
+		repoPath = fs.Arg(0)
+	}
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid repository path: %v\n", err)
+		os.Exit(1)
+	}
+
+	repo, err := discovery.NewScanner(absPath).ScanRepository()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to scan repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	pipelines, err := loadCIPipelines(repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if len(pipelines) == 0 {
+		fmt.Fprintf(os.Stderr, "❌ No CircleCI or GitHub Actions configuration found in %s\n", absPath)
+		os.Exit(1)
+	}
+
+	// Extend an existing Taskfile rather than writing a separate one
+	var taskfilePath string
+	for _, tool := range repo.BuildTools {
+		if tool.Type == ir.ToolGoTask && !*noMerge {
+			taskfilePath = tool.ConfigPath
+			break
+		}
+	}
+
+	var result *convert.Result
+	if taskfilePath != "" {
+		result, err = convert.MergeTaskfile(filepath.Join(repo.RootPath, taskfilePath), filepath.ToSlash(taskfilePath), pipelines)
+	} else {
+		result, err = convert.GenerateTaskfile(pipelines)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Migration failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Point the CI jobs at the tasks
+	var rewritten []convert.File
+	var diff strings.Builder
+	if *apply || *dryRun {
+		taskfile := convert.TaskfileName
+		if taskfilePath != "" {
+			taskfile = filepath.ToSlash(taskfilePath)
+		}
+		rewrite, err := convert.RewriteCI(repo.RootPath, taskfile, result.Tasks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to rewrite CI configuration: %v\n", err)
+			os.Exit(1)
+		}
+		rewritten = rewrite.Files
+		result.Notes = append(result.Notes, rewrite.Notes...)
+		result.Rewrites = rewrite.Rewrites
+
+		// The patch applies to the repository as it is now
+		for _, file := range append(append([]convert.File(nil), result.Files...), rewritten...) {
+			// A missing file reads as nil, which diffs as a new file
+			before, _ := os.ReadFile(filepath.Join(repo.RootPath, filepath.FromSlash(file.Path)))
+			diff.WriteString(convert.UnifiedDiff(file.Path, before, file.Content))
+		}
+	}
+
+	// With --stdout the YAML is the only thing on stdout, with --dry-run the diff
+	notes := os.Stdout
+	switch {
+	case *stdout:
+		notes = os.Stderr
+		for _, file := range result.Files {
+			os.Stdout.Write(file.Content)
+		}
+
+	case *dryRun:
+		if *patch == "" {
+			notes = os.Stderr
+			fmt.Print(diff.String())
+		}
+		if diff.Len() == 0 {
+			fmt.Fprintf(notes, "✅ No changes\n")
+		}
+
+	default:
+		outputDir := absPath
+		if *output != "" {
+			if outputDir, err = filepath.Abs(*output); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Invalid output directory: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		// Merging only adds tasks, so the existing Taskfile may be rewritten
+		if err := writeConvertedFiles(outputDir, result.Files, *force || taskfilePath != ""); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		for _, file := range result.Files {
+			target := filepath.Join(outputDir, filepath.FromSlash(file.Path))
+			fmt.Printf("✅ Wrote %s\n", target)
+			fmt.Printf("   Try it with: task --taskfile %s --list\n", target)
+		}
+		if err := writeConvertedFiles(outputDir, rewritten, true); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		for _, file := range rewritten {
+			fmt.Printf("✅ Rewrote %s\n", filepath.Join(outputDir, filepath.FromSlash(file.Path)))
+		}
+	}
+
+	if *patch != "" {
+		if err := os.WriteFile(*patch, []byte(diff.String()), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write patch: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(notes, "🩹 Patch written to %s (apply with: git apply %s)\n", *patch, *patch)
+	}
+
+	reused := 0
+	for _, task := range result.Tasks {
+		if task.Existing {
+			reused++
+		}
+	}
+	fmt.Fprintf(notes, "\n📋 %d tasks added, %d existing tasks reused\n", len(result.Tasks)-reused, reused)
+	if len(result.Conflicts) > 0 {
+		fmt.Fprintf(notes, "\n⚔️  %d task names were already taken:\n", len(result.Conflicts))
+		for _, conflict := range result.Conflicts {
+			fmt.Fprintf(notes, "  - %s → added as %s\n", conflict.Name, conflict.Renamed)
+		}
+	}
+	if len(result.Rewrites) > 0 {
+		fmt.Fprintf(notes, "\n🔁 %d CI jobs call their task:\n", len(result.Rewrites))
+		for _, rewrite := range result.Rewrites {
+			fmt.Fprintf(notes, "  - %s (%s) → task %s\n", rewrite.File, rewrite.Job, rewrite.Task)
+		}
+	}
+
+	if len(result.Notes) > 0 {
+		fmt.Fprintf(notes, "\n⚠️  %d TODO items need manual review:\n", len(result.Notes))
+		for _, note := range result.Notes {
+			fmt.Fprintf(notes, "  - %s (%s): %s\n", note.File, note.Job, note.Message)
+		}
+	}
+
+	if *report != "" {
+		if err := os.WriteFile(*report, []byte(convert.FormatReport(result)), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(notes, "📝 Report written to %s\n", *report)
+	}
+}
+
+// loadCIPipelines parses and lowers the CircleCI config and GitHub Actions
+// workflows of a repository
+func loadCIPipelines(repo *discovery.Repository) ([]*ir.Pipeline, error) {
+	var pipelines []*ir.Pipeline
+
+	for _, tool := range repo.BuildTools {
+		configPath := filepath.Join(repo.RootPath, tool.ConfigPath)
+		source := filepath.ToSlash(tool.ConfigPath)
+
+		switch tool.Type {
+		case ir.ToolCircleCI:
+			config, err := circleci.ParseConfig(configPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse CircleCI config: %w", err)
+			}
+			circleci.ResolveOrbs(config, filepath.Join(repo.RootPath, circleci.OrbCacheDir))
+			pipelines = append(pipelines, circleci.Lower(circleci.AnalyzeConfig(config), source))
+
+		case ir.ToolGitHubActions:
+			workflows, err := githubactions.NewParser().ParseWorkflowsDirectory(configPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse GitHub Actions workflows: %w", err)
+			}
+			fileNames := make([]string, 0, len(workflows))
+			for fileName := range workflows {
+				fileNames = append(fileNames, fileName)
+			}
+			sort.Strings(fileNames)
+
+			analyzer := githubactions.NewAnalyzer()
+			for _, fileName := range fileNames {
+				result, err := analyzer.AnalyzeWorkflow(filepath.Join(configPath, fileName))
+				if err != nil {
+					return nil, fmt.Errorf("failed to analyze %s: %w", fileName, err)
+				}
+				pipelines = append(pipelines, githubactions.Lower(result, source+"/"+fileName))
+			}
+		}
+	}
+
+	return pipelines, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/discovery"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"github.com/nichecode/pipeline-analyzer/internal/simulate"
+	"gopkg.in/yaml.v3"
+)
+
+// paramFlag collects repeated --param name=value flags
+type paramFlag map[string]interface{}
+
+func (p paramFlag) String() string {
+	var pairs []string
+	for name, value := range p {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (p paramFlag) Set(value string) error {
+	name, raw, found := strings.Cut(value, "=")
+	if !found || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+
+	// Values are typed the way YAML would read them: true, 3, "text"
+	var typed interface{}
+	if err := yaml.Unmarshal([]byte(raw), &typed); err != nil || typed == nil {
+		typed = raw
+	}
+	p[name] = typed
+	return nil
+}
+
+// runSimulate implements the simulate command
+func runSimulate(args []string) {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	var (
+		branch  = fs.String("branch", "", "Branch being pushed")
+		tag     = fs.String("tag", "", "Tag being pushed")
+		changed = fs.String("changed", "", "Comma-separated changed files, for GitHub Actions paths filters")
+		format  = fs.String("format", "text", "Output format: text or json")
+		debug   = fs.Bool("debug", false, "Enable debug logging")
+		params  = paramFlag{}
+	)
+	fs.Var(params, "param", "CircleCI pipeline parameter as name=value (repeatable)")
+	fs.Usage = func() {
+		fmt.Printf("USAGE:\n")
+		fmt.Printf("  pipeline-analyzer simulate [--branch <name>] [--tag <name>] [options] [repository-path]\n\n")
+		fmt.Printf("  Lists the CircleCI and GitHub Actions workflows and jobs that run for a push,\n")
+		fmt.Printf("  in dependency order. Giving both --branch and --tag simulates each push.\n\n")
+		fmt.Printf("OPTIONS:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	logLevel := shared.LogLevelWarn
+	if *debug {
+		logLevel = shared.LogLevelDebug
+	}
+	if err := shared.InitLogger(logLevel, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+	}
+	defer shared.GetLogger().Close()
+
+	if *branch == "" && *tag == "" {
+		fmt.Fprintf(os.Stderr, "❌ simulate needs --branch, --tag or both\n")
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unsupported output format: %s (expected text or json)\n", *format)
+		os.Exit(1)
+	}
+
+	repoPath := "."
+	if fs.NArg() > 0 {
+		repoPath = fs.Arg(0)
+	}
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid repository path: %v\n", err)
+		os.Exit(1)
+	}
+
+	repo, err := discovery.NewScanner(absPath).ScanRepository()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to scan repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	var changedFiles []string
+	for _, file := range strings.Split(*changed, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			changedFiles = append(changedFiles, file)
+		}
+	}
+
+	var refs []simulate.Ref
+	if *branch != "" {
+		refs = append(refs, simulate.Ref{Branch: *branch, ChangedFiles: changedFiles})
+	}
+	if *tag != "" {
+		refs = append(refs, simulate.Ref{Tag: *tag, ChangedFiles: changedFiles})
+	}
+
+	var results []*simulate.Result
+	for _, ref := range refs {
+		result, err := simulate.Repository(repo, ref, params)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Simulation failed: %v\n", err)
+			os.Exit(1)
+		}
+		results = append(results, result)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	for _, result := range results {
+		fmt.Print(simulate.FormatText(result))
+	}
+}
@@ -8,9 +8,13 @@ import (
 )
 
 // AnalyzeConfig performs comprehensive analysis of CircleCI configuration
-func AnalyzeConfig(config *Config) *Analysis {
+func AnalyzeConfig(rawConfig *Config) *Analysis {
+	// Expand << parameters.x >> so analysis reflects what actually runs
+	config := ResolveConfig(rawConfig)
+
 	analysis := &Analysis{
 		Config:           config,
+		RawConfig:        rawConfig,
 		JobUsage:         make(map[string]int),
 		JobDependencies:  make(map[string][]string),
 		CommandPatterns:  make(map[string]PatternCount),
@@ -21,6 +25,17 @@ func AnalyzeConfig(config *Config) *Analysis {
 		TotalWorkflows:   len(config.Workflows),
 		TotalCommands:    len(config.Commands),
 		GeneratedAt:      time.Now(),
+
+		PipelineParameters: PipelineParameterValues(config),
+		JobParameters:      JobParameterValues(rawConfig),
+		JobDefinitions:     JobDefinitions(rawConfig),
+		Conflicts:          InvocationConflicts(rawConfig),
+		JobSteps:           make(map[string][]ExpandedStep),
+	}
+
+	// Inline reusable commands into each job's effective step list
+	for jobName, job := range config.Jobs {
+		analysis.JobSteps[jobName] = ExpandSteps(config, job.Steps)
 	}
 
 	// Analyze reusable commands
@@ -41,12 +56,12 @@ func AnalyzeConfig(config *Config) *Analysis {
 	return analysis
 }
 
-// analyzeJobUsage counts how many times each job is used across workflows
+// analyzeJobUsage counts how many times each invocation name is used across workflows
 func analyzeJobUsage(config *Config, analysis *Analysis) {
 	for _, workflow := range config.Workflows {
 		jobs := ExtractWorkflowJobs(workflow)
 		for _, job := range jobs {
-			analysis.JobUsage[job.Name]++
+			analysis.JobUsage[job.InvocationName()]++
 		}
 	}
 }
@@ -57,7 +72,7 @@ func analyzeJobDependencies(config *Config, analysis *Analysis) {
 		jobs := ExtractWorkflowJobs(workflow)
 		for _, job := range jobs {
 			if len(job.Requires) > 0 {
-				analysis.JobDependencies[job.Name] = job.Requires
+				analysis.JobDependencies[job.InvocationName()] = job.Requires
 			}
 		}
 	}
@@ -92,8 +107,8 @@ func analyzeCommandPatterns(config *Config, analysis *Analysis) {
 		"./":            regexp.MustCompile(`\./[^\s]+`),
 	}
 
-	for jobName, job := range config.Jobs {
-		commands := ExtractCommands(job.Steps)
+	for jobName, steps := range analysis.JobSteps {
+		commands := RunCommands(steps)
 		for _, command := range commands {
 			for patternName, pattern := range patterns {
 				if pattern.MatchString(command) {
@@ -134,7 +149,7 @@ func analyzeExecutorUsage(config *Config, analysis *Analysis) {
 		
 		// Executor references
 		if job.Executor != "" {
-			executorImages := GetExecutorImages(config, job.Executor)
+			executorImages := ExecutorImages(config, job)
 			for _, image := range executorImages {
 				key := job.Executor + " (" + image + ")"
 				analysis.ExecutorUsage[key] = append(analysis.ExecutorUsage[key], jobName)
@@ -152,17 +167,24 @@ func AnalyzeJob(config *Config, jobName string, analysis *Analysis) *JobAnalysis
 
 	jobAnalysis := &JobAnalysis{
 		Name:         jobName,
+		Job:          analysis.JobDefinitions[jobName],
 		Description:  job.Description,
-		Commands:     ExtractCommands(job.Steps),
+		Commands:     RunCommands(analysis.JobSteps[jobName]),
+		Steps:        analysis.JobSteps[jobName],
 		DockerImages: ExtractDockerImages(job),
 		Executor:     job.Executor,
 		UsageCount:   analysis.JobUsage[jobName],
 		Patterns:     make(map[string]int),
+		Parameters:   analysis.JobParameters[jobName],
+	}
+
+	if conflict, exists := analysis.Conflicts[jobName]; exists {
+		jobAnalysis.Conflict = &conflict
 	}
 
 	// Add executor images if applicable
 	if job.Executor != "" {
-		executorImages := GetExecutorImages(config, job.Executor)
+		executorImages := ExecutorImages(config, job)
 		jobAnalysis.DockerImages = append(jobAnalysis.DockerImages, executorImages...)
 	}
 
@@ -191,8 +213,9 @@ func AnalyzeWorkflow(config *Config, workflowName string) *WorkflowAnalysis {
 	}
 
 	return &WorkflowAnalysis{
-		Name: workflowName,
-		Jobs: ExtractWorkflowJobs(workflow),
+		Name:     workflowName,
+		Jobs:     ExtractWorkflowJobs(workflow),
+		DataFlow: AnalyzeDataFlow(config, workflowName),
 	}
 }
 
@@ -279,7 +302,7 @@ func GetCommandFrequency(config *Config) map[string]int {
 	frequency := make(map[string]int)
 	
 	for _, job := range config.Jobs {
-		commands := ExtractCommands(job.Steps)
+		commands := ExtractJobCommands(config, job)
 		for _, command := range commands {
 			// Extract first word (command)
 			words := strings.Fields(command)
@@ -334,8 +357,8 @@ func GetAllCommandNames(config *Config) []string {
 // analyzeReusableCommands analyzes reusable command definitions
 func analyzeReusableCommands(config *Config, analysis *Analysis) {
 	for cmdName, command := range config.Commands {
-		// Extract commands from command steps
-		commands := ExtractCommands(command.Steps)
+		// Extract commands from command steps, inlining nested commands with parameter defaults
+		commands := RunCommands(ExpandSteps(config, []interface{}{cmdName}))
 		
 		// Create command analysis
 		cmdAnalysis := &CommandAnalysis{
@@ -379,16 +402,12 @@ func analyzeReusableCommands(config *Config, analysis *Analysis) {
 		analysis.ReusableCommands[cmdName] = cmdAnalysis
 	}
 	
-	// Count reusable command usage in jobs
-	for _, job := range config.Jobs {
-		for _, stepInterface := range job.Steps {
-			if stepMap, ok := stepInterface.(map[string]interface{}); ok {
-				for stepKey := range stepMap {
-					if _, exists := config.Commands[stepKey]; exists {
-						analysis.CommandUsage[stepKey]++
-						analysis.ReusableCommands[stepKey].UsageCount++
-					}
-				}
+	// Count the jobs that use each reusable command, directly or nested
+	for _, steps := range analysis.JobSteps {
+		for _, cmdName := range invokedCommands(steps) {
+			if cmdAnalysis, exists := analysis.ReusableCommands[cmdName]; exists {
+				analysis.CommandUsage[cmdName]++
+				cmdAnalysis.UsageCount++
 			}
 		}
 	}
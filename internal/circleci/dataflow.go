@@ -0,0 +1,271 @@
+package circleci
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DataKind classifies data passed between jobs
+type DataKind string
+
+// Data kinds
+const (
+	DataWorkspace DataKind = "workspace"
+	DataCache     DataKind = "cache"
+	DataArtifact  DataKind = "artifact"
+)
+
+// DataProduct is data a job writes with persist_to_workspace, save_cache or store_artifacts
+type DataProduct struct {
+	Job   string
+	Kind  DataKind
+	Key   string   // Cache key, or the artifact destination
+	Paths []string // Workspace paths joined with the workspace root, cached or stored paths
+}
+
+// DataConsumer is data a job reads with attach_workspace or restore_cache
+type DataConsumer struct {
+	Job  string
+	Kind DataKind
+	Keys []string // Cache keys tried in order
+	At   string   // Workspace attach directory
+}
+
+// DataFlowEdge links a producing job to a consuming job
+type DataFlowEdge struct {
+	Producer string
+	Consumer string
+	Kind     DataKind
+	Key      string
+	Ordered  bool // The consumer is the producer or requires it, directly or transitively
+}
+
+// DataFlowIssue is a consumer that is not ordered after any of its producers
+type DataFlowIssue struct {
+	Job       string
+	Kind      DataKind
+	Key       string
+	Producers []string
+	Message   string
+}
+
+// DataFlow is the producer/consumer model of a single workflow
+type DataFlow struct {
+	Workflow  string
+	Products  []DataProduct
+	Consumers []DataConsumer
+	Edges     []DataFlowEdge
+	Issues    []DataFlowIssue
+}
+
+// AnalyzeDataFlow extracts workspace, cache and artifact data passing between
+// the jobs of a workflow and checks consumers are ordered after producers
+func AnalyzeDataFlow(config *Config, workflowName string) *DataFlow {
+	workflow, exists := config.Workflows[workflowName]
+	if !exists {
+		return nil
+	}
+
+	flow := &DataFlow{Workflow: workflowName}
+	wfJobs := ExtractWorkflowJobs(workflow)
+
+	requires := make(map[string][]string)
+	for _, wfJob := range wfJobs {
+		requires[wfJob.InvocationName()] = wfJob.Requires
+
+		// The configuration is resolved per invocation, so aliased runs are looked up by alias
+		job, ok := config.Jobs[wfJob.InvocationName()]
+		if !ok {
+			continue
+		}
+		products, consumers := extractDataSteps(wfJob.InvocationName(), ExpandSteps(config, job.Steps))
+		flow.Products = append(flow.Products, products...)
+		flow.Consumers = append(flow.Consumers, consumers...)
+	}
+
+	for _, consumer := range flow.Consumers {
+		upstream := upstreamJobs(requires, consumer.Job)
+
+		var producers, unordered []string
+		for _, product := range flow.Products {
+			if product.Kind != consumer.Kind || !consumer.matches(product) {
+				continue
+			}
+			// restore_cache then save_cache in one job is the normal pattern, but
+			// a workspace only carries what upstream jobs persisted
+			sameJob := product.Job == consumer.Job
+			if sameJob && consumer.Kind == DataWorkspace {
+				continue
+			}
+			ordered := (sameJob && consumer.Kind == DataCache) || upstream[product.Job]
+			flow.Edges = append(flow.Edges, DataFlowEdge{
+				Producer: product.Job,
+				Consumer: consumer.Job,
+				Kind:     product.Kind,
+				Key:      product.Key,
+				Ordered:  ordered,
+			})
+			if ordered {
+				producers = append(producers, product.Job)
+			} else {
+				unordered = append(unordered, product.Job)
+			}
+		}
+
+		switch {
+		case len(producers) > 0:
+			continue
+		case len(unordered) > 0:
+			unordered = uniqueSorted(unordered)
+			message := fmt.Sprintf("%s attaches a workspace persisted by %s but does not require it", consumer.Job, strings.Join(unordered, ", "))
+			if consumer.Kind == DataCache {
+				message = fmt.Sprintf("%s restores a cache saved by %s but does not require it; only caches from earlier pipelines are available", consumer.Job, strings.Join(unordered, ", "))
+			}
+			flow.Issues = append(flow.Issues, DataFlowIssue{
+				Job:       consumer.Job,
+				Kind:      consumer.Kind,
+				Key:       consumer.displayKey(),
+				Producers: unordered,
+				Message:   message,
+			})
+		case consumer.Kind == DataWorkspace:
+			flow.Issues = append(flow.Issues, DataFlowIssue{
+				Job:     consumer.Job,
+				Kind:    consumer.Kind,
+				Key:     consumer.displayKey(),
+				Message: fmt.Sprintf("%s attaches a workspace but no other job in this workflow persists one", consumer.Job),
+			})
+		}
+	}
+
+	return flow
+}
+
+// extractDataSteps collects the data-passing steps of a job's expanded steps
+func extractDataSteps(jobName string, steps []ExpandedStep) ([]DataProduct, []DataConsumer) {
+	var products []DataProduct
+	var consumers []DataConsumer
+
+	for _, step := range steps {
+		switch step.Type {
+		case "persist_to_workspace":
+			root, _ := step.Args["root"].(string)
+			var paths []string
+			for _, p := range stringList(step.Args["paths"]) {
+				paths = append(paths, path.Join(root, p))
+			}
+			products = append(products, DataProduct{Job: jobName, Kind: DataWorkspace, Key: strings.Join(paths, ", "), Paths: paths})
+
+		case "attach_workspace":
+			at, _ := step.Args["at"].(string)
+			consumers = append(consumers, DataConsumer{Job: jobName, Kind: DataWorkspace, At: at})
+
+		case "save_cache":
+			key, _ := step.Args["key"].(string)
+			products = append(products, DataProduct{Job: jobName, Kind: DataCache, Key: key, Paths: stringList(step.Args["paths"])})
+
+		case "restore_cache":
+			var keys []string
+			if key, ok := step.Args["key"].(string); ok {
+				keys = append(keys, key)
+			}
+			keys = append(keys, stringList(step.Args["keys"])...)
+			consumers = append(consumers, DataConsumer{Job: jobName, Kind: DataCache, Keys: keys})
+
+		case "store_artifacts":
+			source, _ := step.Args["path"].(string)
+			destination, _ := step.Args["destination"].(string)
+			if destination == "" {
+				destination = source
+			}
+			products = append(products, DataProduct{Job: jobName, Kind: DataArtifact, Key: destination, Paths: []string{source}})
+		}
+	}
+
+	return products, consumers
+}
+
+// matches reports whether a consumer reads the given product. Workspaces are
+// shared by the whole workflow; restore_cache keys match saved keys by prefix.
+func (c DataConsumer) matches(product DataProduct) bool {
+	switch c.Kind {
+	case DataWorkspace:
+		return true
+	case DataCache:
+		for _, key := range c.Keys {
+			prefix := cacheKeyPrefix(key)
+			if key == product.Key || (prefix != "" && strings.HasPrefix(cacheKeyPrefix(product.Key), prefix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// displayKey describes what a consumer reads
+func (c DataConsumer) displayKey() string {
+	if c.Kind == DataWorkspace {
+		if c.At == "" {
+			return "."
+		}
+		return c.At
+	}
+	return strings.Join(c.Keys, ", ")
+}
+
+// cacheKeyPrefix returns the literal part of a cache key before its first template
+func cacheKeyPrefix(key string) string {
+	if i := strings.Index(key, "{{"); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// upstreamJobs returns every job a job requires, directly or transitively
+func upstreamJobs(requires map[string][]string, job string) map[string]bool {
+	upstream := make(map[string]bool)
+	stack := append([]string(nil), requires[job]...)
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if upstream[current] {
+			continue
+		}
+		upstream[current] = true
+		stack = append(stack, requires[current]...)
+	}
+	return upstream
+}
+
+// stringList converts a YAML string or list value into a string slice
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				items = append(items, str)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// uniqueSorted returns the distinct values in sorted order
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
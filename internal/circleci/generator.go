@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
 )
 
 // GenerateAllJobsIndex generates the all-jobs.md summary
@@ -368,3 +370,140 @@ func GenerateWorkflowsIndex(analysis *Analysis) string {
 
 	return sb.String()
 }
+
+// GenerateOrbsAnalysis generates orbs.md
+func GenerateOrbsAnalysis(analysis *Analysis) string {
+	var sb strings.Builder
+	config := analysis.Config
+
+	sb.WriteString("# Orb Usage\n\n")
+
+	if len(config.Orbs) == 0 {
+		sb.WriteString("No orbs declared in this configuration.\n\n")
+		sb.WriteString("## Navigation\n\n")
+		sb.WriteString("- [← Back to Overview](../README.md)\n")
+		return sb.String()
+	}
+
+	var aliases []string
+	for alias := range config.Orbs {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	sb.WriteString("## Declared Orbs\n\n")
+	sb.WriteString("| Orb | Reference | Source | Jobs | Commands | Executors |\n")
+	sb.WriteString("|-----|-----------|--------|------|----------|-----------|\n")
+
+	var unresolved []*Orb
+	for _, alias := range aliases {
+		orb, ok := config.ResolvedOrbs[alias]
+		if !ok {
+			orb = &Orb{Alias: alias}
+			if ref, isRef := config.Orbs[alias].(string); isRef {
+				orb.Ref = ref
+			}
+		}
+
+		ref := "-"
+		if orb.Ref != "" {
+			ref = fmt.Sprintf("`%s`", orb.Ref)
+		}
+		source := "⚠️ unresolved"
+		if orb.Resolved() {
+			source = fmt.Sprintf("`%s`", orb.Source)
+		} else if orb.Ref != "" {
+			unresolved = append(unresolved, orb)
+		}
+
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %d | %d | %d |\n",
+			alias, ref, source, len(orb.Jobs), len(orb.Commands), len(orb.Executors)))
+	}
+	sb.WriteString("\n")
+
+	usages := AnalyzeOrbUsage(analysis)
+	sb.WriteString("## Jobs Using Orb Elements\n\n")
+	if len(usages) > 0 {
+		sb.WriteString("| Job | Orb Job | Executor | Commands | Unexpanded Steps |\n")
+		sb.WriteString("|-----|---------|----------|----------|------------------|\n")
+		for _, usage := range usages {
+			jobLink := fmt.Sprintf("[%s](../jobs/%s.md)", usage.Job, NormalizeJobName(usage.Job))
+			orbJob := "-"
+			if usage.OrbJob {
+				orbJob = "✅"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+				jobLink, orbJob, codeList([]string{usage.Executor}), codeList(usage.Commands), codeList(usage.Unresolved)))
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("No jobs use orb jobs, commands or executors.\n\n")
+	}
+
+	// Element to job index
+	elementJobs := make(map[string][]string)
+	for _, usage := range usages {
+		var elements []string
+		if usage.OrbJob {
+			elements = append(elements, usage.Job)
+		}
+		if usage.Executor != "" {
+			elements = append(elements, usage.Executor)
+		}
+		elements = append(elements, usage.Commands...)
+		elements = append(elements, usage.Unresolved...)
+		for _, element := range elements {
+			if !shared.ContainsString(elementJobs[element], usage.Job) {
+				elementJobs[element] = append(elementJobs[element], usage.Job)
+			}
+		}
+	}
+	if len(elementJobs) > 0 {
+		var elements []string
+		for element := range elementJobs {
+			elements = append(elements, element)
+		}
+		sort.Strings(elements)
+
+		sb.WriteString("## Orb Elements\n\n")
+		sb.WriteString("| Element | Jobs |\n")
+		sb.WriteString("|---------|------|\n")
+		for _, element := range elements {
+			sb.WriteString(fmt.Sprintf("| `%s` | %d |\n", element, len(elementJobs[element])))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(unresolved) > 0 {
+		sb.WriteString("## Populating the Offline Cache\n\n")
+		sb.WriteString("Orb steps, jobs and executors from unresolved orbs are not expanded. ")
+		sb.WriteString(fmt.Sprintf("Save each orb's source under `%s/` and re-run the analyzer:\n\n", OrbCacheDir))
+		sb.WriteString("```bash\n")
+		sb.WriteString(fmt.Sprintf("mkdir -p %s\n", OrbCacheDir))
+		for _, orb := range unresolved {
+			sb.WriteString(fmt.Sprintf("circleci orb source %s > %s/%s.yml\n", orb.Ref, OrbCacheDir, orbCacheName(orb.Ref)))
+		}
+		sb.WriteString("```\n\n")
+	}
+
+	sb.WriteString("## Navigation\n\n")
+	sb.WriteString("- [← Back to Overview](../README.md)\n")
+	sb.WriteString("- [Commands Analysis](commands.md)\n")
+	sb.WriteString("- [Executors & Images](executors-and-images.md)\n")
+
+	return sb.String()
+}
+
+// codeList formats names as a comma-separated list of code spans
+func codeList(names []string) string {
+	var items []string
+	for _, name := range names {
+		if name != "" {
+			items = append(items, fmt.Sprintf("`%s`", name))
+		}
+	}
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,207 @@
+package circleci
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+)
+
+// Lower converts an analyzed CircleCI configuration into the shared pipeline IR
+func Lower(analysis *Analysis, source string) *ir.Pipeline {
+	config := analysis.Config
+	pipeline := &ir.Pipeline{
+		Tool:     ir.ToolCircleCI,
+		Name:     "CircleCI",
+		Source:   source,
+		Triggers: lowerTriggers(config),
+	}
+
+	for jobName, job := range config.Jobs {
+		irJob := &ir.Job{
+			ID:         jobName,
+			Name:       jobName,
+			Runner:     jobRunner(config, job),
+			WorkingDir: job.WorkingDir,
+			Env:        ir.EnvFromMap(job.Environment),
+		}
+
+		// Primary image first, remaining images are service containers
+		images := ExtractDockerImages(job)
+		if job.Executor != "" {
+			images = append(images, ExecutorImages(config, job)...)
+		}
+		for i, image := range images {
+			role := ir.ImageService
+			if i == 0 {
+				role = ir.ImagePrimary
+			}
+			irJob.Images = append(irJob.Images, ir.Image{Name: image, Role: role})
+		}
+
+		for _, dep := range analysis.JobDependencies[jobName] {
+			irJob.Needs = append(irJob.Needs, ir.Dependency{Target: dep, Kind: ir.DependencyRequires})
+		}
+
+		for _, step := range analysis.JobSteps[jobName] {
+			irJob.Steps = append(irJob.Steps, lowerStep(step))
+		}
+
+		pipeline.Jobs = append(pipeline.Jobs, irJob)
+	}
+
+	pipeline.SortJobs()
+	return pipeline
+}
+
+// lowerStep converts an expanded CircleCI step into an IR step
+func lowerStep(step ExpandedStep) ir.Step {
+	irStep := ir.Step{
+		Name:      step.Name,
+		Kind:      stepKind(step.Type),
+		Origin:    step.Origin(),
+		Condition: step.Condition,
+	}
+
+	if step.Type != "run" {
+		irStep.Uses = step.Type
+		return irStep
+	}
+
+	irStep.Commands = ir.SplitCommands(step.Command)
+	if dir, ok := step.Args["working_directory"].(string); ok {
+		irStep.WorkingDir = dir
+	}
+	if env, ok := step.Args["environment"].(map[string]interface{}); ok {
+		irStep.Env = ir.EnvFromMap(env)
+	}
+	return irStep
+}
+
+// stepKind maps a built-in CircleCI step name to an IR step kind
+func stepKind(name string) ir.StepKind {
+	switch name {
+	case "run":
+		return ir.StepRun
+	case "checkout":
+		return ir.StepCheckout
+	case "save_cache", "restore_cache":
+		return ir.StepCache
+	case "persist_to_workspace", "attach_workspace":
+		return ir.StepWorkspace
+	case "store_artifacts", "store_test_results":
+		return ir.StepArtifact
+	}
+	return ir.StepOther
+}
+
+// jobRunner describes the execution environment of a job
+func jobRunner(config *Config, job Job) string {
+	switch {
+	case len(job.Docker) > 0:
+		return "docker"
+	case job.Machine != nil:
+		return "machine"
+	case job.MacOS != nil:
+		return "macos"
+	case job.Executor != "":
+		if executor, ok := LookupExecutor(config, job.Executor); ok {
+			switch {
+			case len(executor.Docker) > 0:
+				return "docker"
+			case executor.Machine != nil:
+				return "machine"
+			case executor.MacOS != nil:
+				return "macos"
+			}
+		}
+		return fmt.Sprintf("executor:%s", job.Executor)
+	}
+	return ""
+}
+
+// lowerTriggers derives push and schedule triggers from workflow definitions
+func lowerTriggers(config *Config) []ir.Trigger {
+	var triggers []ir.Trigger
+
+	workflowNames := GetAllWorkflowNames(config)
+	sort.Strings(workflowNames)
+
+	for _, workflowName := range workflowNames {
+		workflow := config.Workflows[workflowName]
+		scheduled := false
+
+		for _, triggerInterface := range workflow.Triggers {
+			trigger, ok := triggerInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			schedule, ok := trigger["schedule"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			scheduled = true
+			irTrigger := ir.Trigger{Event: "schedule"}
+			if cron, ok := schedule["cron"].(string); ok {
+				irTrigger.Schedule = cron
+			}
+			if filters, ok := schedule["filters"].(map[string]interface{}); ok {
+				irTrigger.Branches = filterValues(filters, "branches")
+			}
+			triggers = append(triggers, irTrigger)
+		}
+
+		if scheduled {
+			continue
+		}
+
+		// Collect the branch and tag filters of every job in the workflow
+		push := ir.Trigger{Event: "push"}
+		for _, job := range ExtractWorkflowJobs(workflow) {
+			push.Branches = appendUnique(push.Branches, filterValues(job.Filters, "branches")...)
+			push.Tags = appendUnique(push.Tags, filterValues(job.Filters, "tags")...)
+		}
+		triggers = append(triggers, push)
+	}
+
+	return triggers
+}
+
+// filterValues returns the "only" values of a branches or tags filter
+func filterValues(filters map[string]interface{}, kind string) []string {
+	filter, ok := filters[kind].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	switch only := filter["only"].(type) {
+	case string:
+		return []string{only}
+	case []interface{}:
+		var values []string
+		for _, value := range only {
+			if str, ok := value.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// appendUnique appends values that are not already present
+func appendUnique(items []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, item := range items {
+			if item == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			items = append(items, value)
+		}
+	}
+	return items
+}
@@ -0,0 +1,303 @@
+package circleci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// OrbCacheDir is the offline orb cache location relative to the repository root.
+// Orb sources are stored as <namespace>/<name>@<version>.yml or <name>@<version>.yml.
+const OrbCacheDir = ".pipeline-analyzer/orbs"
+
+// Orb sources
+const (
+	OrbSourceInline     = "inline"
+	OrbSourceUnresolved = ""
+)
+
+// Orb is an orb declared in the config, resolved inline or from the offline cache.
+// Element names are qualified with the orb alias (e.g. node/install-packages).
+type Orb struct {
+	Alias     string
+	Ref       string // Registry reference such as circleci/node@5.0.2, empty for inline orbs
+	Source    string // OrbSourceInline, the cache file relative to the repository, or OrbSourceUnresolved
+	Jobs      map[string]Job
+	Commands  map[string]Command
+	Executors map[string]Executor
+}
+
+// Resolved reports whether the orb body is available
+func (o *Orb) Resolved() bool {
+	return o.Source != OrbSourceUnresolved
+}
+
+// orbDefinition is the YAML shape of an orb body
+type orbDefinition struct {
+	Version     string              `yaml:"version"`
+	Description string              `yaml:"description"`
+	Jobs        map[string]Job      `yaml:"jobs"`
+	Commands    map[string]Command  `yaml:"commands"`
+	Executors   map[string]Executor `yaml:"executors"`
+}
+
+// ResolveOrbs resolves the config's orb declarations from inline definitions and
+// the offline cache directory, and adds orb jobs referenced by workflows to the
+// config's jobs under their qualified names
+func ResolveOrbs(config *Config, cacheDir string) {
+	logger := shared.GetLogger()
+	config.ResolvedOrbs = make(map[string]*Orb)
+
+	for alias, declaration := range config.Orbs {
+		orb := &Orb{Alias: alias}
+
+		switch decl := declaration.(type) {
+		case string:
+			orb.Ref = decl
+			if definition, path, err := loadCachedOrb(cacheDir, decl); err == nil {
+				orb.Source = path
+				orb.qualify(definition)
+			} else {
+				logger.Debug("CircleCI", "Orb not found in offline cache", map[string]interface{}{
+					"orb":   decl,
+					"cache": cacheDir,
+					"error": err.Error(),
+				})
+			}
+		case map[string]interface{}:
+			definition, err := decodeOrbDefinition(decl)
+			if err != nil {
+				logger.Warn("CircleCI", "Failed to decode inline orb", map[string]interface{}{
+					"orb":   alias,
+					"error": err.Error(),
+				})
+				continue
+			}
+			orb.Source = OrbSourceInline
+			orb.qualify(definition)
+		}
+
+		config.ResolvedOrbs[alias] = orb
+	}
+
+	// Orb jobs only run when a workflow references them
+	for _, workflow := range config.Workflows {
+		for _, wfJob := range ExtractWorkflowJobs(workflow) {
+			if _, exists := config.Jobs[wfJob.Name]; exists {
+				continue
+			}
+			if job, ok := lookupOrbJob(config, wfJob.Name); ok {
+				if config.Jobs == nil {
+					config.Jobs = make(map[string]Job)
+				}
+				config.Jobs[wfJob.Name] = job
+			}
+		}
+	}
+}
+
+// loadCachedOrb reads an orb body from the offline cache, returning the cache
+// file relative to the repository root
+func loadCachedOrb(cacheDir, ref string) (*orbDefinition, string, error) {
+	var candidates []string
+	for _, ext := range []string{".yml", ".yaml"} {
+		candidates = append(candidates, filepath.Join(cacheDir, filepath.FromSlash(ref)+ext))
+		if slash := strings.LastIndex(ref, "/"); slash >= 0 {
+			candidates = append(candidates, filepath.Join(cacheDir, ref[slash+1:]+ext))
+		}
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var definition orbDefinition
+		if err := yaml.Unmarshal(data, &definition); err != nil {
+			return nil, "", fmt.Errorf("failed to parse orb %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(cacheDir, path)
+		if err != nil {
+			rel = filepath.Base(path)
+		}
+		return &definition, filepath.ToSlash(filepath.Join(OrbCacheDir, rel)), nil
+	}
+
+	return nil, "", fmt.Errorf("orb %s not found in %s", ref, cacheDir)
+}
+
+// decodeOrbDefinition converts an inline orb map into an orb definition
+func decodeOrbDefinition(raw map[string]interface{}) (*orbDefinition, error) {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode inline orb: %w", err)
+	}
+	var definition orbDefinition
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("failed to decode inline orb: %w", err)
+	}
+	return &definition, nil
+}
+
+// qualify copies an orb definition into the orb, prefixing element names and
+// internal references with the orb alias
+func (o *Orb) qualify(definition *orbDefinition) {
+	o.Jobs = make(map[string]Job)
+	o.Commands = make(map[string]Command)
+	o.Executors = make(map[string]Executor)
+
+	for name, executor := range definition.Executors {
+		o.Executors[o.Alias+"/"+name] = executor
+	}
+	for name, command := range definition.Commands {
+		command.Steps = o.qualifySteps(command.Steps, definition)
+		o.Commands[o.Alias+"/"+name] = command
+	}
+	for name, job := range definition.Jobs {
+		if _, local := definition.Executors[job.Executor]; local {
+			job.Executor = o.Alias + "/" + job.Executor
+		}
+		job.Steps = o.qualifySteps(job.Steps, definition)
+		o.Jobs[o.Alias+"/"+name] = job
+	}
+}
+
+// qualifySteps rewrites references to orb-local commands into qualified names,
+// including the steps of when and unless blocks
+func (o *Orb) qualifySteps(steps []interface{}, definition *orbDefinition) []interface{} {
+	result := make([]interface{}, 0, len(steps))
+	for _, stepInterface := range steps {
+		switch step := stepInterface.(type) {
+		case string:
+			if _, local := definition.Commands[step]; local {
+				result = append(result, o.Alias+"/"+step)
+				continue
+			}
+		case map[string]interface{}:
+			qualified := make(map[string]interface{}, len(step))
+			for key, value := range step {
+				if _, local := definition.Commands[key]; local {
+					key = o.Alias + "/" + key
+				}
+				if args, ok := value.(map[string]interface{}); ok && (key == "when" || key == "unless") {
+					if nested, ok := args["steps"].([]interface{}); ok {
+						block := make(map[string]interface{}, len(args))
+						for name, arg := range args {
+							block[name] = arg
+						}
+						block["steps"] = o.qualifySteps(nested, definition)
+						value = block
+					}
+				}
+				qualified[key] = value
+			}
+			result = append(result, qualified)
+			continue
+		}
+		result = append(result, stepInterface)
+	}
+	return result
+}
+
+// orbAlias returns the orb alias of a qualified element name, or ""
+func orbAlias(config *Config, name string) string {
+	slash := strings.Index(name, "/")
+	if slash <= 0 {
+		return ""
+	}
+	if _, declared := config.Orbs[name[:slash]]; declared {
+		return name[:slash]
+	}
+	return ""
+}
+
+// lookupOrbJob finds a job provided by a resolved orb
+func lookupOrbJob(config *Config, name string) (Job, bool) {
+	if orb, ok := config.ResolvedOrbs[orbAlias(config, name)]; ok {
+		job, exists := orb.Jobs[name]
+		return job, exists
+	}
+	return Job{}, false
+}
+
+// LookupCommand finds a reusable command in the config or a resolved orb
+func LookupCommand(config *Config, name string) (Command, bool) {
+	if command, exists := config.Commands[name]; exists {
+		return command, true
+	}
+	if orb, ok := config.ResolvedOrbs[orbAlias(config, name)]; ok {
+		command, exists := orb.Commands[name]
+		return command, exists
+	}
+	return Command{}, false
+}
+
+// LookupExecutor finds an executor in the config or a resolved orb
+func LookupExecutor(config *Config, name string) (Executor, bool) {
+	if executor, exists := config.Executors[name]; exists {
+		return executor, true
+	}
+	if orb, ok := config.ResolvedOrbs[orbAlias(config, name)]; ok {
+		executor, exists := orb.Executors[name]
+		return executor, exists
+	}
+	return Executor{}, false
+}
+
+// OrbUsage lists the orb elements a single job depends on
+type OrbUsage struct {
+	Job        string
+	OrbJob     bool     // The job itself comes from an orb
+	Executor   string   // Orb executor used by the job
+	Commands   []string // Orb commands inlined into the job
+	Unresolved []string // Orb steps that could not be expanded
+}
+
+// AnalyzeOrbUsage returns the orb elements used by each job, sorted by job name
+func AnalyzeOrbUsage(analysis *Analysis) []OrbUsage {
+	config := analysis.Config
+	var usages []OrbUsage
+
+	jobNames := GetAllJobNames(config)
+	sort.Strings(jobNames)
+	for _, jobName := range jobNames {
+		job := config.Jobs[jobName]
+		usage := OrbUsage{Job: jobName, OrbJob: orbAlias(config, jobName) != ""}
+
+		if orbAlias(config, job.Executor) != "" {
+			usage.Executor = job.Executor
+		}
+
+		for _, step := range analysis.JobSteps[jobName] {
+			for _, name := range step.Provenance {
+				if orbAlias(config, name) != "" && !shared.ContainsString(usage.Commands, name) {
+					usage.Commands = append(usage.Commands, name)
+				}
+			}
+			if orbAlias(config, step.Type) != "" && !shared.ContainsString(usage.Unresolved, step.Type) {
+				usage.Unresolved = append(usage.Unresolved, step.Type)
+			}
+		}
+
+		if usage.OrbJob || usage.Executor != "" || len(usage.Commands) > 0 || len(usage.Unresolved) > 0 {
+			sort.Strings(usage.Commands)
+			sort.Strings(usage.Unresolved)
+			usages = append(usages, usage)
+		}
+	}
+
+	return usages
+}
+
+// orbCacheName returns the cache file name of a registry reference without extension
+func orbCacheName(ref string) string {
+	if slash := strings.LastIndex(ref, "/"); slash >= 0 {
+		return ref[slash+1:]
+	}
+	return ref
+}
@@ -0,0 +1,364 @@
+package circleci
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// parameterRefRegex matches << parameters.x >>, << pipeline.parameters.x >> and
+// other << dotted.path >> references
+var parameterRefRegex = regexp.MustCompile(`<<\s*([A-Za-z0-9_.\-]+)\s*>>`)
+
+// workflowJobKeys are invocation keys that configure the workflow rather than
+// pass parameter values to the job
+var workflowJobKeys = map[string]bool{
+	"requires":      true,
+	"context":       true,
+	"filters":       true,
+	"name":          true,
+	"matrix":        true,
+	"type":          true,
+	"pre-steps":     true,
+	"post-steps":    true,
+	"serial-group":  true,
+	"override-with": true,
+}
+
+// ParameterDecl is a declared job, command, executor or pipeline parameter
+type ParameterDecl struct {
+	Name        string
+	Type        string
+	Description string
+	Default     interface{}
+	HasDefault  bool
+}
+
+// ParameterScope holds the values visible to << >> references, keyed by the
+// full reference path such as "parameters.tag" or "pipeline.parameters.deploy"
+type ParameterScope map[string]interface{}
+
+// ParseParameterDecls reads a parameters: block into declarations
+func ParseParameterDecls(raw interface{}) map[string]ParameterDecl {
+	decls := make(map[string]ParameterDecl)
+
+	params, ok := raw.(map[string]interface{})
+	if !ok {
+		return decls
+	}
+
+	for name, definition := range params {
+		decl := ParameterDecl{Name: name, Type: "string"}
+		if def, ok := definition.(map[string]interface{}); ok {
+			if t, ok := def["type"].(string); ok {
+				decl.Type = t
+			}
+			if d, ok := def["description"].(string); ok {
+				decl.Description = d
+			}
+			if value, exists := def["default"]; exists {
+				decl.Default = value
+				decl.HasDefault = true
+			}
+		}
+		decls[name] = decl
+	}
+
+	return decls
+}
+
+// ResolveParameterValues merges declared defaults with the values passed at an
+// invocation site. Parameters with neither stay unresolved.
+func ResolveParameterValues(decls map[string]ParameterDecl, args map[string]interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+
+	for name, decl := range decls {
+		if decl.HasDefault {
+			values[name] = decl.Default
+		}
+	}
+	for name, value := range args {
+		if _, declared := decls[name]; declared {
+			values[name] = value
+		}
+	}
+
+	return values
+}
+
+// NewParameterScope builds a scope from local and pipeline parameter values
+func NewParameterScope(params, pipelineParams map[string]interface{}) ParameterScope {
+	scope := make(ParameterScope)
+	for name, value := range params {
+		scope["parameters."+name] = value
+	}
+	for name, value := range pipelineParams {
+		scope["pipeline.parameters."+name] = value
+	}
+	return scope
+}
+
+// PipelineParameterValues returns the default values of the top-level pipeline parameters
+func PipelineParameterValues(config *Config) map[string]interface{} {
+	return ResolveParameterValues(ParseParameterDecls(config.Parameters), nil)
+}
+
+// InterpolateString replaces known references in a string, leaving unknown ones intact
+func InterpolateString(s string, scope ParameterScope) string {
+	if !strings.Contains(s, "<<") {
+		return s
+	}
+
+	return parameterRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		path := parameterRefRegex.FindStringSubmatch(ref)[1]
+		if value, ok := scope[path]; ok {
+			return formatParameterValue(value)
+		}
+		return ref
+	})
+}
+
+// Interpolate replaces references anywhere inside a YAML value. A string that is
+// exactly one reference takes the referenced value's type, so steps parameters
+// expand into step lists.
+func Interpolate(value interface{}, scope ParameterScope) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := parameterRefRegex.FindStringSubmatch(strings.TrimSpace(v)); match != nil && match[0] == strings.TrimSpace(v) {
+			if resolved, ok := scope[match[1]]; ok {
+				return resolved
+			}
+		}
+		return InterpolateString(v, scope)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[InterpolateString(key, scope)] = Interpolate(item, scope)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			result = append(result, Interpolate(item, scope))
+		}
+		return result
+	}
+	return value
+}
+
+// InterpolateSteps interpolates a step list, splicing in steps parameters
+func InterpolateSteps(steps []interface{}, scope ParameterScope) []interface{} {
+	var result []interface{}
+	for _, step := range steps {
+		switch resolved := Interpolate(step, scope).(type) {
+		case []interface{}:
+			result = append(result, resolved...)
+		default:
+			result = append(result, resolved)
+		}
+	}
+	return result
+}
+
+// formatParameterValue renders a parameter value the way CircleCI substitutes it into text
+func formatParameterValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// InvocationArgs returns the parameter values passed at a workflow job invocation
+func InvocationArgs(jobConfig map[string]interface{}) map[string]interface{} {
+	args := make(map[string]interface{})
+	for key, value := range jobConfig {
+		if !workflowJobKeys[key] {
+			args[key] = value
+		}
+	}
+	return args
+}
+
+// InvocationConflict records an invocation name that several workflows invoke
+// with different jobs or parameter values. Only the first is resolved.
+type InvocationConflict struct {
+	Workflow string   // Workflow whose invocation is resolved
+	Others   []string // Workflows invoking the name differently
+}
+
+// JobInvocations returns the workflow invocations of jobs, one per invocation
+// name, in workflow name order. A name invoked by several workflows keeps its
+// first invocation; see InvocationConflicts for names invoked differently.
+func JobInvocations(config *Config) []WorkflowJob {
+	invocations, _ := collectInvocations(config)
+	return invocations
+}
+
+// InvocationConflicts returns the invocation names that later workflows
+// invoke with a different job or different parameter values than the
+// invocation JobInvocations keeps
+func InvocationConflicts(config *Config) map[string]InvocationConflict {
+	_, conflicts := collectInvocations(config)
+	return conflicts
+}
+
+// collectInvocations walks the workflows in name order, keeping the first
+// invocation of each name and recording later ones that differ from it
+func collectInvocations(config *Config) ([]WorkflowJob, map[string]InvocationConflict) {
+	var invocations []WorkflowJob
+	conflicts := make(map[string]InvocationConflict)
+	kept := make(map[string]int)
+	keptWorkflow := make(map[string]string)
+
+	workflowNames := GetAllWorkflowNames(config)
+	sort.Strings(workflowNames)
+	for _, workflowName := range workflowNames {
+		for _, job := range ExtractWorkflowJobs(config.Workflows[workflowName]) {
+			name := job.InvocationName()
+			index, seen := kept[name]
+			if !seen {
+				kept[name] = len(invocations)
+				keptWorkflow[name] = workflowName
+				invocations = append(invocations, job)
+				continue
+			}
+
+			first := invocations[index]
+			if first.Name == job.Name && reflect.DeepEqual(first.Parameters, job.Parameters) {
+				continue
+			}
+			conflict := conflicts[name]
+			conflict.Workflow = keptWorkflow[name]
+			if !shared.ContainsString(conflict.Others, workflowName) {
+				conflict.Others = append(conflict.Others, workflowName)
+			}
+			conflicts[name] = conflict
+		}
+	}
+
+	return invocations, conflicts
+}
+
+// JobDefinitions maps the invocation names of a configuration to the jobs
+// they run. Jobs no workflow invokes map to themselves.
+func JobDefinitions(config *Config) map[string]string {
+	definitions := make(map[string]string)
+	invoked := make(map[string]bool)
+	for _, invocation := range JobInvocations(config) {
+		if _, exists := config.Jobs[invocation.Name]; exists {
+			definitions[invocation.InvocationName()] = invocation.Name
+			invoked[invocation.Name] = true
+		}
+	}
+	for jobName := range config.Jobs {
+		if !invoked[jobName] {
+			definitions[jobName] = jobName
+		}
+	}
+	return definitions
+}
+
+// ResolveConfig returns a copy of the configuration with job and executor
+// parameters expanded. Each workflow invocation becomes its own job under its
+// invocation name, such as deploy-staging and deploy-prod for one deploy job,
+// with the declared defaults overridden by the values passed there. Jobs no
+// workflow invokes keep their defaults. Reusable command bodies are left
+// untouched because their parameters depend on each invocation.
+func ResolveConfig(config *Config) *Config {
+	resolved := *config
+	resolved.Jobs = make(map[string]Job, len(config.Jobs))
+
+	pipelineParams := PipelineParameterValues(config)
+	values := JobParameterValues(config)
+
+	for invocationName, jobName := range JobDefinitions(config) {
+		job := config.Jobs[jobName]
+		resolved.Jobs[invocationName] = resolveJob(job, NewParameterScope(values[invocationName], pipelineParams))
+	}
+
+	return &resolved
+}
+
+// ResolveJob returns a job from the config or a resolved orb with its
+// parameters expanded for the values passed at a single invocation
+func ResolveJob(config *Config, jobName string, args map[string]interface{}) (Job, bool) {
+	job, exists := config.Jobs[jobName]
+	if !exists {
+		if job, exists = lookupOrbJob(config, jobName); !exists {
+			return Job{}, false
+		}
+	}
+
+	values := ResolveParameterValues(ParseParameterDecls(job.Parameters), args)
+	return resolveJob(job, NewParameterScope(values, PipelineParameterValues(config))), true
+}
+
+// JobParameterValues returns the parameter values ResolveConfig used for each
+// invocation name
+func JobParameterValues(config *Config) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
+	args := make(map[string]map[string]interface{})
+	for _, invocation := range JobInvocations(config) {
+		args[invocation.InvocationName()] = invocation.Parameters
+	}
+
+	for invocationName, jobName := range JobDefinitions(config) {
+		decls := ParseParameterDecls(config.Jobs[jobName].Parameters)
+		if len(decls) == 0 {
+			continue
+		}
+		result[invocationName] = ResolveParameterValues(decls, args[invocationName])
+	}
+
+	return result
+}
+
+// resolveJob interpolates every parameterized field of a job
+func resolveJob(job Job, scope ParameterScope) Job {
+	resolved := job
+	resolved.Steps = InterpolateSteps(job.Steps, scope)
+	resolved.Executor = InterpolateString(job.Executor, scope)
+	resolved.WorkingDir = InterpolateString(job.WorkingDir, scope)
+
+	if job.ExecutorParams != nil {
+		resolved.ExecutorParams, _ = Interpolate(job.ExecutorParams, scope).(map[string]interface{})
+	}
+	if job.Environment != nil {
+		resolved.Environment, _ = Interpolate(job.Environment, scope).(map[string]interface{})
+	}
+
+	resolved.Docker = make([]DockerConfig, len(job.Docker))
+	for i, docker := range job.Docker {
+		docker.Image = InterpolateString(docker.Image, scope)
+		resolved.Docker[i] = docker
+	}
+
+	return resolved
+}
+
+// ExecutorImages returns the Docker images of a job's executor with the
+// executor's parameters resolved from the job's invocation values
+func ExecutorImages(config *Config, job Job) []string {
+	executor, ok := LookupExecutor(config, job.Executor)
+	if !ok {
+		return nil
+	}
+
+	values := ResolveParameterValues(ParseParameterDecls(executor.Parameters), job.ExecutorParams)
+	scope := NewParameterScope(values, PipelineParameterValues(config))
+
+	var images []string
+	for _, docker := range executor.Docker {
+		if docker.Image != "" {
+			images = append(images, InterpolateString(docker.Image, scope))
+		}
+	}
+	return images
+}
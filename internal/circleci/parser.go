@@ -80,6 +80,12 @@ func ExtractWorkflowJobs(workflow Workflow) []WorkflowJob {
 					if filters, ok := config["filters"].(map[string]interface{}); ok {
 						wfJob.Filters = filters
 					}
+
+					if alias, ok := config["name"].(string); ok {
+						wfJob.Alias = alias
+					}
+
+					wfJob.Parameters = InvocationArgs(config)
 				}
 
 				jobs = append(jobs, wfJob)
@@ -90,6 +96,15 @@ func ExtractWorkflowJobs(workflow Workflow) []WorkflowJob {
 	return jobs
 }
 
+// InvocationName returns the name requires: and the UI use for the job: its
+// alias when one is set, otherwise the job name
+func (j WorkflowJob) InvocationName() string {
+	if j.Alias != "" {
+		return j.Alias
+	}
+	return j.Name
+}
+
 // ExtractCommands extracts run commands from job steps
 func ExtractCommands(steps []interface{}) []string {
 	var commands []string
@@ -145,7 +160,7 @@ func ExtractDockerImages(job Job) []string {
 
 // GetExecutorImages gets Docker images from executor configuration
 func GetExecutorImages(config *Config, executorName string) []string {
-	if executor, ok := config.Executors[executorName]; ok {
+	if executor, ok := LookupExecutor(config, executorName); ok {
 		var images []string
 		for _, docker := range executor.Docker {
 			if docker.Image != "" {
@@ -248,3 +263,39 @@ func copyRecursive(original, copy reflect.Value) {
 		copy.Set(original)
 	}
 }
+
+// UnmarshalYAML accepts executor given either as a name or as a map with
+// name and parameter values
+func (j *Job) UnmarshalYAML(value *yaml.Node) error {
+	type plainJob Job
+
+	var executorParams map[string]interface{}
+	node := value
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, val := value.Content[i], value.Content[i+1]
+			if key.Value != "executor" || val.Kind != yaml.MappingNode {
+				continue
+			}
+
+			if err := val.Decode(&executorParams); err != nil {
+				return fmt.Errorf("failed to decode executor: %w", err)
+			}
+			name, _ := executorParams["name"].(string)
+			delete(executorParams, "name")
+
+			// Decode a copy of the job with the executor reduced to its name
+			copied := *value
+			copied.Content = append([]*yaml.Node(nil), value.Content...)
+			copied.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+			node = &copied
+			break
+		}
+	}
+
+	if err := node.Decode((*plainJob)(j)); err != nil {
+		return err
+	}
+	j.ExecutorParams = executorParams
+	return nil
+}
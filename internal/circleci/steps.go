@@ -0,0 +1,291 @@
+package circleci
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// maxCommandDepth limits how deeply reusable commands may nest
+const maxCommandDepth = 16
+
+// ExpandedStep is a job step after reusable commands have been inlined
+type ExpandedStep struct {
+	Type       string                 // Step type: run, checkout, save_cache, ... or an unresolved step name
+	Name       string                 // Display name from the step's name: field
+	Command    string                 // Command text of run steps
+	Args       map[string]interface{} // Step arguments
+	Provenance []string               // Reusable commands the step was inlined from, outermost first
+	Condition  string                 // when/unless conditions that cannot be decided statically; empty when the step always runs
+}
+
+// Origin describes where an expanded step came from
+func (s ExpandedStep) Origin() string {
+	return strings.Join(s.Provenance, " → ")
+}
+
+// IsRun reports whether the step runs a shell command
+func (s ExpandedStep) IsRun() bool {
+	return s.Type == "run" && s.Command != ""
+}
+
+// ExpandSteps recursively inlines reusable command invocations, including
+// nested ones, substituting each invocation's parameter values. when and
+// unless steps are decided the way CircleCI does when it compiles the
+// config: only the branch taken is inlined, and steps under a condition that
+// depends on values only known at run time, such as pipeline.git.branch, are
+// marked with it.
+func ExpandSteps(config *Config, steps []interface{}) []ExpandedStep {
+	return expandSteps(config, steps, PipelineParameterValues(config), nil, "")
+}
+
+// expandSteps expands a step list with the given provenance chain, under the
+// undecided conditions of enclosing when and unless steps
+func expandSteps(config *Config, steps []interface{}, pipelineParams map[string]interface{}, provenance []string, condition string) []ExpandedStep {
+	var expanded []ExpandedStep
+
+	for _, stepInterface := range steps {
+		stepType, args := stepTypeAndArgs(stepInterface)
+		if stepType == "" {
+			continue
+		}
+
+		switch {
+		case stepType == "run":
+			step := ExpandedStep{Type: stepType, Args: args, Provenance: provenance, Condition: condition}
+			step.Command, _ = args["command"].(string)
+			step.Name, _ = args["name"].(string)
+			expanded = append(expanded, step)
+
+		case stepType == "when" || stepType == "unless":
+			// Conditions are evaluated when the config is compiled, after parameters are interpolated
+			nested, ok := args["steps"].([]interface{})
+			if !ok {
+				continue
+			}
+			nestedCondition := condition
+			if value, known := EvaluateCondition(args["condition"]); known {
+				if value != (stepType == "when") {
+					continue
+				}
+			} else {
+				nestedCondition = joinConditions(condition, stepType+" "+FormatCondition(args["condition"]))
+			}
+			expanded = append(expanded, expandSteps(config, nested, pipelineParams, provenance, nestedCondition)...)
+
+		case isReusableCommand(config, stepType) && !shared.ContainsString(provenance, stepType) && len(provenance) < maxCommandDepth:
+			command, _ := LookupCommand(config, stepType)
+			values := ResolveParameterValues(ParseParameterDecls(command.Parameters), args)
+			body := InterpolateSteps(command.Steps, NewParameterScope(values, pipelineParams))
+
+			chain := append(append([]string(nil), provenance...), stepType)
+			expanded = append(expanded, expandSteps(config, body, pipelineParams, chain, condition)...)
+
+		default:
+			step := ExpandedStep{Type: stepType, Args: args, Provenance: provenance, Condition: condition}
+			step.Name, _ = args["name"].(string)
+			expanded = append(expanded, step)
+		}
+	}
+
+	return expanded
+}
+
+// EvaluateCondition evaluates a when or unless condition the way CircleCI
+// does at compile time. Literals follow CircleCI's truthiness: false, null,
+// 0, empty strings and logic statements without arguments are false. known
+// is false when the condition depends on a value that is not interpolated,
+// such as << pipeline.git.branch >>.
+func EvaluateCondition(condition interface{}) (value bool, known bool) {
+	switch v := condition.(type) {
+	case nil:
+		return false, true
+	case bool:
+		return v, true
+	case int:
+		return v != 0, true
+	case float64:
+		return v != 0 && !math.IsNaN(v), true
+	case string:
+		if strings.Contains(v, "<<") {
+			return false, false
+		}
+		return v != "", true
+	case map[string]interface{}:
+		// A logic statement is a mapping with a single operator
+		if len(v) == 1 {
+			for operator, operand := range v {
+				return evaluateLogic(operator, operand)
+			}
+		}
+	}
+	return false, false
+}
+
+// evaluateLogic evaluates an and, or, not, equal or matches statement
+func evaluateLogic(operator string, operand interface{}) (bool, bool) {
+	items, _ := operand.([]interface{})
+
+	switch operator {
+	case "not":
+		value, known := EvaluateCondition(operand)
+		return !value, known
+
+	case "and", "or":
+		if len(items) == 0 {
+			return false, true
+		}
+		// A false operand decides and, a true one decides or
+		decisive := operator == "or"
+		known := true
+		for _, item := range items {
+			value, itemKnown := EvaluateCondition(item)
+			if itemKnown && value == decisive {
+				return decisive, true
+			}
+			known = known && itemKnown
+		}
+		return !decisive, known
+
+	case "equal":
+		if len(items) == 0 {
+			return false, true
+		}
+		var first string
+		for i, item := range items {
+			if text, ok := item.(string); ok && strings.Contains(text, "<<") {
+				return false, false
+			}
+			if _, ok := item.(map[string]interface{}); ok {
+				return false, false
+			}
+			if i == 0 {
+				first = formatParameterValue(item)
+			} else if formatParameterValue(item) != first {
+				return false, true
+			}
+		}
+		return true, true
+
+	case "matches":
+		statement, _ := operand.(map[string]interface{})
+		pattern, _ := statement["pattern"].(string)
+		value, ok := statement["value"].(string)
+		if !ok || pattern == "" || strings.Contains(value, "<<") || strings.Contains(pattern, "<<") {
+			return false, false
+		}
+		// CircleCI patterns must match the whole value
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return false, false
+		}
+		return re.MatchString(value), true
+	}
+
+	return false, false
+}
+
+// FormatCondition renders a when or unless condition on one line
+func FormatCondition(condition interface{}) string {
+	switch v := condition.(type) {
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = FormatCondition(item)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		operators := make([]string, 0, len(v))
+		for operator := range v {
+			operators = append(operators, operator)
+		}
+		sort.Strings(operators)
+
+		var parts []string
+		for _, operator := range operators {
+			if operator == "matches" {
+				statement, _ := v[operator].(map[string]interface{})
+				parts = append(parts, fmt.Sprintf("matches(%v, /%v/)", statement["value"], statement["pattern"]))
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s(%s)", operator, FormatCondition(v[operator])))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return formatParameterValue(condition)
+}
+
+// joinConditions combines the conditions of nested when and unless steps
+func joinConditions(outer, inner string) string {
+	if outer == "" {
+		return inner
+	}
+	return outer + " and " + inner
+}
+
+// stepTypeAndArgs splits a raw step into its type and argument map
+func stepTypeAndArgs(stepInterface interface{}) (string, map[string]interface{}) {
+	switch step := stepInterface.(type) {
+	case string:
+		return step, map[string]interface{}{}
+	case map[string]interface{}:
+		for key, value := range step {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				return key, v
+			case string:
+				// Shorthand run: "cmd"
+				if key == "run" {
+					return key, map[string]interface{}{"command": v}
+				}
+			}
+			return key, map[string]interface{}{}
+		}
+	}
+	return "", nil
+}
+
+// isReusableCommand reports whether a step invokes a command from commands: or a resolved orb
+func isReusableCommand(config *Config, name string) bool {
+	_, exists := LookupCommand(config, name)
+	return exists
+}
+
+// RunCommands returns the command text of every run step
+func RunCommands(steps []ExpandedStep) []string {
+	var commands []string
+	for _, step := range steps {
+		if step.IsRun() {
+			commands = append(commands, step.Command)
+		}
+	}
+	return commands
+}
+
+// ExtractJobCommands returns a job's effective run commands with reusable
+// commands inlined
+func ExtractJobCommands(config *Config, job Job) []string {
+	return RunCommands(ExpandSteps(config, job.Steps))
+}
+
+// invokedCommands returns the reusable commands a step list pulls in, sorted
+func invokedCommands(steps []ExpandedStep) []string {
+	seen := make(map[string]bool)
+	for _, step := range steps {
+		for _, name := range step.Provenance {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -30,6 +30,23 @@ func GenerateMainReadme(analysis *Analysis, configPath string) string {
 	}
 	sb.WriteString("\n")
 
+	// Pipeline parameters section
+	if len(analysis.PipelineParameters) > 0 {
+		sb.WriteString("## ⚙️ Pipeline Parameters\n\n")
+		sb.WriteString("Defaults used to expand `<< pipeline.parameters.x >>`:\n\n")
+		sb.WriteString("| Parameter | Default |\n")
+		sb.WriteString("|-----------|---------|\n")
+		paramNames := make([]string, 0, len(analysis.PipelineParameters))
+		for name := range analysis.PipelineParameters {
+			paramNames = append(paramNames, name)
+		}
+		sort.Strings(paramNames)
+		for _, name := range paramNames {
+			sb.WriteString(fmt.Sprintf("| %s | `%v` |\n", name, analysis.PipelineParameters[name]))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Workflow diagram
 	diagram := generateCircleCIDiagram(analysis)
 	if diagram != "" {
@@ -90,7 +107,8 @@ func GenerateMainReadme(analysis *Analysis, configPath string) string {
 	sb.WriteString("- [⚡ Commands Analysis](summaries/commands.md)\n")
 	sb.WriteString("- [🐳 Docker & Scripts](summaries/docker-and-scripts.md)\n")
 	sb.WriteString("- [⚙️ Executors & Images](summaries/executors-and-images.md)\n")
-	sb.WriteString("- [🔄 Workflows Index](summaries/workflows.md)\n\n")
+	sb.WriteString("- [🔄 Workflows Index](summaries/workflows.md)\n")
+	sb.WriteString("- [🧩 Orb Usage](summaries/orbs.md)\n\n")
 
 	// Next Steps section
 	sb.WriteString("## 🎯 Next Steps\n\n")
@@ -134,6 +152,7 @@ func GenerateMigrationChecklist(analysis *Analysis) string {
 
 	sb.WriteString("## 🎯 Migration Overview\n\n")
 	sb.WriteString("This checklist guides you through converting your CircleCI configuration to a local go-task setup.\n\n")
+	sb.WriteString("Run `pipeline-analyzer migrate` to generate `Taskfile.generated.yml` with one task per job as a starting point.\n\n")
 
 	// Quick Stats
 	sb.WriteString("### 📊 Current State\n\n")
@@ -219,6 +238,15 @@ func GenerateJobMarkdown(jobAnalysis *JobAnalysis) string {
 		sb.WriteString(fmt.Sprintf("**Description:** %s\n\n", jobAnalysis.Description))
 	}
 
+	if jobAnalysis.Job != "" && jobAnalysis.Job != jobAnalysis.Name {
+		sb.WriteString(fmt.Sprintf("**Runs job:** %s, invoked as `%s`\n\n", jobAnalysis.Job, jobAnalysis.Name))
+	}
+
+	if conflict := jobAnalysis.Conflict; conflict != nil {
+		sb.WriteString(fmt.Sprintf("> ⚠️ **Conflicting invocations:** workflow(s) %s invoke `%s` with a different job or different parameter values. This page shows the invocation in workflow %s; rename one with `name:` to analyze both.\n\n",
+			strings.Join(conflict.Others, ", "), jobAnalysis.Name, conflict.Workflow))
+	}
+
 	// Usage info
 	sb.WriteString("## Usage Information\n\n")
 	sb.WriteString(fmt.Sprintf("- **Used in workflows:** %d times\n", jobAnalysis.UsageCount))
@@ -232,6 +260,23 @@ func GenerateJobMarkdown(jobAnalysis *JobAnalysis) string {
 	}
 	sb.WriteString("\n")
 
+	// Parameters
+	if len(jobAnalysis.Parameters) > 0 {
+		sb.WriteString("## ⚙️ Parameters\n\n")
+		sb.WriteString("Values used to expand `<< parameters.x >>` in this analysis (defaults overridden by the values passed at this invocation):\n\n")
+		sb.WriteString("| Parameter | Value |\n")
+		sb.WriteString("|-----------|-------|\n")
+		paramNames := make([]string, 0, len(jobAnalysis.Parameters))
+		for name := range jobAnalysis.Parameters {
+			paramNames = append(paramNames, name)
+		}
+		sort.Strings(paramNames)
+		for _, name := range paramNames {
+			sb.WriteString(fmt.Sprintf("| %s | `%v` |\n", name, jobAnalysis.Parameters[name]))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Docker Images
 	if len(jobAnalysis.DockerImages) > 0 {
 		sb.WriteString("## Docker Images\n\n")
@@ -241,13 +286,41 @@ func GenerateJobMarkdown(jobAnalysis *JobAnalysis) string {
 		sb.WriteString("\n")
 	}
 
+	// Effective steps with reusable commands inlined
+	if len(jobAnalysis.Steps) > 0 {
+		sb.WriteString("## Steps\n\n")
+		sb.WriteString("| # | Step | Name | From Command | Runs |\n")
+		sb.WriteString("|---|------|------|--------------|------|\n")
+		for i, step := range jobAnalysis.Steps {
+			origin := "-"
+			if len(step.Provenance) > 0 {
+				origin = fmt.Sprintf("`%s`", step.Origin())
+			}
+			runs := "always"
+			if step.Condition != "" {
+				runs = fmt.Sprintf("`%s`", step.Condition)
+			}
+			sb.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s |\n", i+1, step.Type, step.Name, origin, runs))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Commands
 	if len(jobAnalysis.Commands) > 0 {
 		sb.WriteString("## Run Commands\n\n")
-		for i, command := range jobAnalysis.Commands {
-			sb.WriteString(fmt.Sprintf("### Command %d\n\n", i+1))
+		i := 0
+		for _, step := range jobAnalysis.Steps {
+			if !step.IsRun() {
+				continue
+			}
+			i++
+			if len(step.Provenance) > 0 {
+				sb.WriteString(fmt.Sprintf("### Command %d (from `%s`)\n\n", i, step.Origin()))
+			} else {
+				sb.WriteString(fmt.Sprintf("### Command %d\n\n", i))
+			}
 			sb.WriteString("```bash\n")
-			sb.WriteString(command)
+			sb.WriteString(step.Command)
 			sb.WriteString("\n```\n\n")
 		}
 	}
@@ -321,8 +394,9 @@ func GenerateWorkflowMarkdown(workflowAnalysis *WorkflowAnalysis) string {
 				context = strings.Join(job.Context, ", ")
 			}
 
-			normalizedName := NormalizeJobName(job.Name)
-			jobLink := fmt.Sprintf("[%s](../jobs/%s.md)", job.Name, normalizedName)
+			// Aliased invocations have their own job pages
+			name := job.InvocationName()
+			jobLink := fmt.Sprintf("[%s](../jobs/%s.md)", name, NormalizeJobName(name))
 			sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n", jobLink, deps, context))
 		}
 	} else {
@@ -340,9 +414,9 @@ func GenerateWorkflowMarkdown(workflowAnalysis *WorkflowAnalysis) string {
 
 	for _, job := range workflowAnalysis.Jobs {
 		if len(job.Requires) == 0 {
-			independentJobs = append(independentJobs, job.Name)
+			independentJobs = append(independentJobs, job.InvocationName())
 		} else {
-			dependentJobs[job.Name] = job.Requires
+			dependentJobs[job.InvocationName()] = job.Requires
 		}
 	}
 
@@ -366,6 +440,10 @@ func GenerateWorkflowMarkdown(workflowAnalysis *WorkflowAnalysis) string {
 
 	sb.WriteString("```\n\n")
 
+	if flow := workflowAnalysis.DataFlow; flow != nil && (len(flow.Products) > 0 || len(flow.Consumers) > 0) {
+		sb.WriteString(generateDataFlowSection(flow))
+	}
+
 	// Navigation
 	sb.WriteString("## Navigation\n\n")
 	sb.WriteString("- [← Back to Workflows](../summaries/workflows.md)\n")
@@ -374,6 +452,119 @@ func GenerateWorkflowMarkdown(workflowAnalysis *WorkflowAnalysis) string {
 	return sb.String()
 }
 
+// generateDataFlowSection renders workspace, cache and artifact passing between jobs
+func generateDataFlowSection(flow *DataFlow) string {
+	var sb strings.Builder
+
+	sb.WriteString("## Data Flow\n\n")
+
+	if len(flow.Issues) > 0 {
+		sb.WriteString("### ⚠️ Unordered Consumers\n\n")
+		for _, issue := range flow.Issues {
+			sb.WriteString(fmt.Sprintf("- **%s** (%s `%s`): %s\n", issue.Job, issue.Kind, issue.Key, issue.Message))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("```mermaid\n")
+	sb.WriteString("flowchart LR\n")
+
+	// One node per job and per distinct piece of data
+	var jobs []string
+	for _, product := range flow.Products {
+		jobs = appendUnique(jobs, product.Job)
+	}
+	for _, consumer := range flow.Consumers {
+		jobs = appendUnique(jobs, consumer.Job)
+	}
+	for _, job := range jobs {
+		sb.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", dataFlowJobID(job), mermaidLabel(job)))
+	}
+
+	dataIDs := make(map[string]string)
+	for _, product := range flow.Products {
+		dataKey := string(product.Kind) + ":" + product.Key
+		id, exists := dataIDs[dataKey]
+		if !exists {
+			id = fmt.Sprintf("D%d", len(dataIDs)+1)
+			dataIDs[dataKey] = id
+			label := mermaidLabel(fmt.Sprintf("%s: %s", product.Kind, product.Key))
+			switch product.Kind {
+			case DataCache:
+				sb.WriteString(fmt.Sprintf("    %s[(\"%s\")]\n", id, label))
+			case DataArtifact:
+				sb.WriteString(fmt.Sprintf("    %s>\"%s\"]\n", id, label))
+			default:
+				sb.WriteString(fmt.Sprintf("    %s([\"%s\"])\n", id, label))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("    %s -->|%s| %s\n", dataFlowJobID(product.Job), dataFlowVerb(product.Kind, true), id))
+	}
+
+	for _, edge := range flow.Edges {
+		id := dataIDs[string(edge.Kind)+":"+edge.Key]
+		if edge.Ordered {
+			sb.WriteString(fmt.Sprintf("    %s -->|%s| %s\n", id, dataFlowVerb(edge.Kind, false), dataFlowJobID(edge.Consumer)))
+		} else {
+			sb.WriteString(fmt.Sprintf("    %s -.->|⚠️ %s, not required| %s\n", id, dataFlowVerb(edge.Kind, false), dataFlowJobID(edge.Consumer)))
+		}
+	}
+
+	sb.WriteString("```\n\n")
+
+	sb.WriteString("| Job | Step | Data |\n")
+	sb.WriteString("|-----|------|------|\n")
+	for _, product := range flow.Products {
+		sb.WriteString(fmt.Sprintf("| %s | %s | `%s` |\n", product.Job, dataFlowStep(product.Kind, true), product.Key))
+	}
+	for _, consumer := range flow.Consumers {
+		sb.WriteString(fmt.Sprintf("| %s | %s | `%s` |\n", consumer.Job, dataFlowStep(consumer.Kind, false), consumer.displayKey()))
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// dataFlowJobID returns the Mermaid node ID of a job in the data-flow graph
+func dataFlowJobID(job string) string {
+	return "J_" + shared.CleanNodeID(job)
+}
+
+// dataFlowVerb labels a data-flow edge
+func dataFlowVerb(kind DataKind, produce bool) string {
+	switch {
+	case kind == DataCache && produce:
+		return "save"
+	case kind == DataCache:
+		return "restore"
+	case kind == DataArtifact:
+		return "store"
+	case produce:
+		return "persist"
+	}
+	return "attach"
+}
+
+// dataFlowStep returns the CircleCI step that produces or consumes a kind of data
+func dataFlowStep(kind DataKind, produce bool) string {
+	switch {
+	case kind == DataCache && produce:
+		return "save_cache"
+	case kind == DataCache:
+		return "restore_cache"
+	case kind == DataArtifact:
+		return "store_artifacts"
+	case produce:
+		return "persist_to_workspace"
+	}
+	return "attach_workspace"
+}
+
+// mermaidLabel escapes text for a quoted Mermaid node label
+func mermaidLabel(text string) string {
+	return strings.ReplaceAll(text, "\"", "#quot;")
+}
+
 // GenerateCommandMarkdown generates markdown for a reusable command
 func GenerateCommandMarkdown(cmdAnalysis *CommandAnalysis) string {
 	var sb strings.Builder
@@ -489,7 +680,7 @@ func generateCircleCIDiagram(analysis *Analysis) string {
 	// Create nodes for selected jobs
 	for _, jobName := range selectedJobs {
 		if job, exists := analysis.Config.Jobs[jobName]; exists {
-			commands := ExtractCommands(job.Steps)
+			commands := ExtractJobCommands(analysis.Config, job)
 			if len(commands) > 5 {
 				commands = commands[:5]
 			}
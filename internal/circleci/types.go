@@ -8,8 +8,11 @@ type Config struct {
 	Jobs      map[string]Job         `yaml:"jobs"`
 	Workflows map[string]Workflow    `yaml:"workflows"`
 	Executors map[string]Executor    `yaml:"executors"`
-	Commands  map[string]Command     `yaml:"commands"`
-	Orbs      map[string]interface{} `yaml:"orbs"`
+	Commands   map[string]Command     `yaml:"commands"`
+	Orbs       map[string]interface{} `yaml:"orbs"`
+	Parameters map[string]interface{} `yaml:"parameters"`
+
+	ResolvedOrbs map[string]*Orb `yaml:"-"` // Populated by ResolveOrbs
 }
 
 // Job represents a CircleCI job
@@ -23,20 +26,31 @@ type Job struct {
 	Environment map[string]interface{} `yaml:"environment"`
 	WorkingDir  string                 `yaml:"working_directory"`
 	Parallelism int                    `yaml:"parallelism"`
+	Parameters  map[string]interface{} `yaml:"parameters"`
+
+	// ExecutorParams holds the values passed when executor is given as
+	// a map such as {name: node, tag: "18.17"}
+	ExecutorParams map[string]interface{} `yaml:"-"`
 }
 
 // Workflow represents a CircleCI workflow
 type Workflow struct {
 	Jobs     []interface{} `yaml:"jobs"`
 	Triggers []interface{} `yaml:"triggers"`
+	When     interface{}   `yaml:"when"`
+	Unless   interface{}   `yaml:"unless"`
 }
 
 // WorkflowJob represents a job within a workflow (can be string or object)
 type WorkflowJob struct {
 	Name     string
+	Alias    string // name: given at the invocation, such as deploy-prod
 	Requires []string
 	Context  []string
 	Filters  map[string]interface{}
+
+	// Parameters holds the parameter values passed at this invocation
+	Parameters map[string]interface{}
 }
 
 // Executor represents a reusable executor
@@ -46,6 +60,7 @@ type Executor struct {
 	MacOS       interface{}            `yaml:"macos"`
 	Environment map[string]interface{} `yaml:"environment"`
 	WorkingDir  string                 `yaml:"working_directory"`
+	Parameters  map[string]interface{} `yaml:"parameters"`
 }
 
 // DockerConfig represents Docker configuration
@@ -80,7 +95,8 @@ type RunStep struct {
 
 // Analysis represents the analysis results
 type Analysis struct {
-	Config           *Config
+	Config           *Config // Configuration with job parameters resolved, one job per invocation name
+	RawConfig        *Config // Configuration as written
 	JobUsage         map[string]int
 	JobDependencies  map[string][]string
 	CommandPatterns  map[string]PatternCount
@@ -91,6 +107,12 @@ type Analysis struct {
 	TotalWorkflows   int
 	TotalCommands    int
 	GeneratedAt      time.Time
+
+	PipelineParameters map[string]interface{}
+	JobParameters      map[string]map[string]interface{}
+	JobDefinitions     map[string]string             // Invocation names to the jobs they run
+	Conflicts          map[string]InvocationConflict // Invocation names other workflows invoke differently
+	JobSteps           map[string][]ExpandedStep     // Effective steps with reusable commands inlined
 }
 
 // PatternCount tracks pattern usage
@@ -102,6 +124,7 @@ type PatternCount struct {
 // JobAnalysis represents analysis for a single job
 type JobAnalysis struct {
 	Name         string
+	Job          string // Job definition it runs; differs from Name for aliased invocations
 	Description  string
 	Commands     []string
 	DockerImages []string
@@ -109,6 +132,9 @@ type JobAnalysis struct {
 	Dependencies []string
 	UsageCount   int
 	Patterns     map[string]int
+	Parameters   map[string]interface{}
+	Steps        []ExpandedStep
+	Conflict     *InvocationConflict // Set when other workflows invoke the name differently
 }
 
 // CommandAnalysis represents analysis for a reusable command
@@ -123,6 +149,7 @@ type CommandAnalysis struct {
 
 // WorkflowAnalysis represents analysis for a single workflow
 type WorkflowAnalysis struct {
-	Name string
-	Jobs []WorkflowJob
+	Name     string
+	Jobs     []WorkflowJob
+	DataFlow *DataFlow
 }
\ No newline at end of file
@@ -156,6 +156,7 @@ func (w *Writer) writeSummaryFiles(analysis *Analysis) error {
 		"docker-and-scripts.md":   GenerateDockerAndScriptsAnalysis(analysis),
 		"executors-and-images.md": GenerateExecutorsAndImagesAnalysis(analysis),
 		"workflows.md":            GenerateWorkflowsIndex(analysis),
+		"orbs.md":                 GenerateOrbsAnalysis(analysis),
 	}
 
 	for filename, content := range summaries {
@@ -0,0 +1,1266 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/circleci"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// Action versions used by generated workflows
+const (
+	checkoutAction         = "actions/checkout@v4"
+	cacheRestoreAction     = "actions/cache/restore@v4"
+	cacheSaveAction        = "actions/cache/save@v4"
+	uploadArtifactAction   = "actions/upload-artifact@v4"
+	downloadArtifactAction = "actions/download-artifact@v4"
+)
+
+// workspaceDir holds workspace archives passed between converted jobs
+const workspaceDir = "/tmp/workspace"
+
+// globSpecialChars are the characters with a special meaning in GitHub
+// Actions branch and tag filters
+const globSpecialChars = `*?+[]!`
+
+// circleEnvironment maps CircleCI built-in environment variables to the
+// GitHub Actions expressions that provide the same value
+var circleEnvironment = map[string]string{
+	"CIRCLE_SHA1":              "${{ github.sha }}",
+	"CIRCLE_BRANCH":            "${{ github.ref_type == 'branch' && github.ref_name || '' }}",
+	"CIRCLE_TAG":               "${{ github.ref_type == 'tag' && github.ref_name || '' }}",
+	"CIRCLE_BUILD_NUM":         "${{ github.run_number }}",
+	"CIRCLE_BUILD_URL":         "${{ github.server_url }}/${{ github.repository }}/actions/runs/${{ github.run_id }}",
+	"CIRCLE_JOB":               "${{ github.job }}",
+	"CIRCLE_WORKFLOW_ID":       "${{ github.run_id }}",
+	"CIRCLE_PROJECT_REPONAME":  "${{ github.event.repository.name }}",
+	"CIRCLE_PROJECT_USERNAME":  "${{ github.repository_owner }}",
+	"CIRCLE_REPOSITORY_URL":    "${{ github.server_url }}/${{ github.repository }}",
+	"CIRCLE_PULL_REQUEST":      "${{ github.event.pull_request.html_url }}",
+	"CIRCLE_WORKING_DIRECTORY": "${{ github.workspace }}",
+	"CIRCLE_NODE_INDEX":        "${{ strategy.job-index }}",
+	"CIRCLE_NODE_TOTAL":        "${{ strategy.job-total }}",
+}
+
+var (
+	circleVariableRegex = regexp.MustCompile(`\$\{?(CIRCLE_[A-Z0-9_]+)`)
+	cacheTemplateRegex  = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+	checksumRegex       = regexp.MustCompile(`^checksum\s+"([^"]+)"$`)
+	pipelineValueRegex  = regexp.MustCompile(`^<<\s*pipeline\.([A-Za-z0-9_.-]+)\s*>>$`)
+	matrixValueRegex    = regexp.MustCompile(`<<\s*matrix\.([A-Za-z0-9_-]+)\s*>>`)
+	circleCLIRegex      = regexp.MustCompile(`\bcircleci(-agent)?\s+(tests|step)\b`)
+)
+
+// circleCIConverter carries the state of one CircleCI to GitHub Actions conversion
+type circleCIConverter struct {
+	notes
+	config     *circleci.Config
+	source     string
+	inputs     map[string]bool     // Pipeline parameters referenced by the current workflow
+	cachePaths map[string][]string // save_cache key prefix → saved paths
+}
+
+// invocation is a job entry of a workflow with its raw settings
+type invocation struct {
+	circleci.WorkflowJob
+	Alias     string // name: given at the invocation, or the job name
+	Approval  bool
+	Matrix    map[string]interface{}
+	PreSteps  []interface{}
+	PostSteps []interface{}
+}
+
+// CircleCIToGitHubActions converts each CircleCI workflow into a GitHub
+// Actions workflow file under .github/workflows. source is the config path
+// quoted in the generated headers.
+func CircleCIToGitHubActions(config *circleci.Config, source string) (*Result, error) {
+	workflowNames := circleci.GetAllWorkflowNames(config)
+	if len(workflowNames) == 0 {
+		return nil, fmt.Errorf("no workflows to convert in %s", source)
+	}
+	sort.Strings(workflowNames)
+
+	c := &circleCIConverter{
+		config:     config,
+		source:     source,
+		notes:      notes{result: &Result{From: ir.ToolCircleCI, To: ir.ToolGitHubActions}},
+		cachePaths: savedCachePaths(config),
+	}
+
+	fileNames := make(map[string]bool)
+	for _, workflowName := range workflowNames {
+		c.file = ".github/workflows/" + identifier(workflowName, fileNames) + ".yml"
+		c.job = ""
+		c.inputs = make(map[string]bool)
+
+		root := c.convertWorkflow(workflowName, config.Workflows[workflowName])
+		content, err := encodeDocument(root, []string{
+			fmt.Sprintf("Converted from %s (workflow %q) by pipeline-analyzer convert.", source, workflowName),
+			"Review every TODO comment before relying on this workflow.",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate workflow %s: %w", workflowName, err)
+		}
+		c.result.Files = append(c.result.Files, File{Path: c.file, Content: content})
+
+		shared.GetLogger().Debug("convert", "Converted CircleCI workflow", map[string]interface{}{
+			"workflow": workflowName,
+			"file":     c.file,
+		})
+	}
+
+	return c.result, nil
+}
+
+// convertWorkflow builds a GitHub Actions workflow from one CircleCI workflow
+func (c *circleCIConverter) convertWorkflow(name string, workflow circleci.Workflow) *yaml.Node {
+	root := newMapping()
+	set(root, "name", newScalar(name))
+	on := newMapping()
+	onKey := set(root, "on", on)
+
+	invocations := workflowInvocations(workflow)
+
+	// Workflow-level when/unless becomes part of every job's if:
+	var conditions []string
+	if workflow.When != nil {
+		if expr, ok := c.condition(workflow.When); ok {
+			conditions = append(conditions, expr)
+		} else {
+			c.todo(onKey, "the workflow's when: %s condition could not be converted; add it to each job's if:", circleci.FormatCondition(workflow.When))
+		}
+	}
+	if workflow.Unless != nil {
+		if expr, ok := c.condition(workflow.Unless); ok {
+			conditions = append(conditions, "!("+expr+")")
+		} else {
+			c.todo(onKey, "the workflow's unless: %s condition could not be converted; add its negation to each job's if:", circleci.FormatCondition(workflow.Unless))
+		}
+	}
+
+	scheduled := c.convertSchedules(on, workflow)
+	var tagTriggers bool
+	var branchTriggers []string
+	if !scheduled {
+		branchTriggers, tagTriggers = c.convertPushFilters(on, onKey, invocations)
+	}
+
+	ids := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, inv := range invocations {
+		// Matrix aliases such as build-<< matrix.version >> name a single job
+		if matrixValueRegex.MatchString(inv.Alias) {
+			ids[inv.Alias] = identifier(inv.Name, taken)
+			continue
+		}
+		ids[inv.Alias] = identifier(inv.Alias, taken)
+	}
+
+	jobs := newMapping()
+	for _, inv := range invocations {
+		c.job = inv.Alias
+		key := newScalar(ids[inv.Alias])
+		jobConditions := append([]string(nil), conditions...)
+		jobConditions = append(jobConditions, c.invocationConditions(key, inv)...)
+		if !scheduled {
+			if expr := c.refCondition(key, inv.Filters, branchTriggers, tagTriggers); expr != "" {
+				jobConditions = append(jobConditions, expr)
+			}
+		}
+		jobs.Content = append(jobs.Content, key, c.convertJob(key, inv, ids, jobConditions))
+	}
+	c.job = ""
+
+	c.addDispatchInputs(on)
+	set(root, "jobs", jobs)
+	return root
+}
+
+// workflowInvocations pairs ExtractWorkflowJobs entries with the raw
+// invocation settings it does not expose
+func workflowInvocations(workflow circleci.Workflow) []invocation {
+	wfJobs := circleci.ExtractWorkflowJobs(workflow)
+	invocations := make([]invocation, 0, len(wfJobs))
+
+	for i, wfJob := range wfJobs {
+		inv := invocation{WorkflowJob: wfJob, Alias: wfJob.Name}
+		if i < len(workflow.Jobs) {
+			if entry, ok := workflow.Jobs[i].(map[string]interface{}); ok {
+				if settings, ok := entry[wfJob.Name].(map[string]interface{}); ok {
+					if alias, ok := settings["name"].(string); ok && alias != "" {
+						inv.Alias = alias
+					}
+					inv.Approval = settings["type"] == "approval"
+					inv.Matrix, _ = settings["matrix"].(map[string]interface{})
+					inv.PreSteps, _ = settings["pre-steps"].([]interface{})
+					inv.PostSteps, _ = settings["post-steps"].([]interface{})
+				}
+			}
+		}
+		invocations = append(invocations, inv)
+	}
+
+	return invocations
+}
+
+// invocationConditions converts when: and unless: set on a workflow job
+// invocation, such as when: << pipeline.parameters.deploy >>, into job if:
+// terms. Keys the job declares as parameters are left to the parameters.
+func (c *circleCIConverter) invocationConditions(key *yaml.Node, inv invocation) []string {
+	var conditions []string
+	for _, keyword := range invocationConditionKeys(c.config, inv) {
+		value := inv.Parameters[keyword]
+		expr, ok := c.condition(value)
+		if !ok {
+			c.todo(key, "the invocation's %s: %s condition could not be converted; add it to this job's if:", keyword, circleci.FormatCondition(value))
+			continue
+		}
+		if keyword == "unless" {
+			expr = "!(" + expr + ")"
+		}
+		conditions = append(conditions, expr)
+	}
+	return conditions
+}
+
+// invocationConditionKeys returns the when and unless keys of an invocation
+// that are conditions rather than parameters of the job
+func invocationConditionKeys(config *circleci.Config, inv invocation) []string {
+	decls := circleci.ParseParameterDecls(config.Jobs[inv.Name].Parameters)
+	var keys []string
+	for _, keyword := range []string{"when", "unless"} {
+		if _, set := inv.Parameters[keyword]; set {
+			if _, declared := decls[keyword]; !declared {
+				keys = append(keys, keyword)
+			}
+		}
+	}
+	return keys
+}
+
+// convertSchedules adds on.schedule for scheduled triggers and reports
+// whether the workflow is scheduled
+func (c *circleCIConverter) convertSchedules(on *yaml.Node, workflow circleci.Workflow) bool {
+	schedules := newSequence()
+	var branches []string
+
+	for _, triggerInterface := range workflow.Triggers {
+		trigger, ok := triggerInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		schedule, ok := trigger["schedule"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entry := newMapping()
+		cron, _ := schedule["cron"].(string)
+		set(entry, "cron", newScalar(cron))
+		schedules.Content = append(schedules.Content, entry)
+
+		if filters, ok := schedule["filters"].(map[string]interface{}); ok {
+			if branchFilter, ok := filters["branches"].(map[string]interface{}); ok {
+				branches = append(branches, filterPatterns(branchFilter["only"])...)
+			}
+		}
+	}
+
+	if len(schedules.Content) == 0 {
+		return false
+	}
+
+	key := set(on, "schedule", schedules)
+	if len(branches) > 0 {
+		c.todo(key, "scheduled workflows run on the default branch; CircleCI scheduled this one on %s", strings.Join(branches, ", "))
+	}
+	set(on, "workflow_dispatch", newMapping())
+	return true
+}
+
+// convertPushFilters builds on.push from the union of the job filters and
+// returns the branch globs and whether tags trigger the workflow
+func (c *circleCIConverter) convertPushFilters(on, onKey *yaml.Node, invocations []invocation) ([]string, bool) {
+	allBranches := false
+	var branches, tags []string
+
+	for _, inv := range invocations {
+		branchFilter, _ := inv.Filters["branches"].(map[string]interface{})
+		only := filterPatterns(branchFilter["only"])
+		if len(only) == 0 {
+			allBranches = true
+		}
+		for _, pattern := range only {
+			glob, ok := filterGlob(pattern)
+			if !ok {
+				c.todo(onKey, "branch filter %s is a regular expression; the trigger runs for every branch and jobs must check the branch themselves", pattern)
+				glob = "**"
+			}
+			if glob == "**" {
+				allBranches = true
+			}
+			branches = appendUnique(branches, glob)
+		}
+
+		if tagFilter, ok := inv.Filters["tags"].(map[string]interface{}); ok {
+			only := filterPatterns(tagFilter["only"])
+			if len(only) == 0 {
+				only = []string{"/.*/"}
+			}
+			for _, pattern := range only {
+				glob, ok := filterGlob(pattern)
+				if !ok {
+					c.todo(onKey, "tag filter %s is a regular expression; the trigger runs for every tag", pattern)
+					glob = "**"
+				}
+				tags = appendUnique(tags, glob)
+			}
+		}
+	}
+
+	if allBranches {
+		branches = []string{"**"}
+	}
+	if shared.ContainsString(tags, "**") {
+		tags = []string{"**"}
+	}
+
+	push := newMapping()
+	if len(branches) > 0 && (len(tags) > 0 || !allBranches) {
+		set(push, "branches", newStrings(branches))
+	}
+	if len(tags) > 0 {
+		set(push, "tags", newStrings(tags))
+	}
+	set(on, "push", push)
+	set(on, "workflow_dispatch", newMapping())
+	return branches, len(tags) > 0
+}
+
+// refCondition builds the part of a job's if: that reproduces its branch and
+// tag filters, or "" when the workflow trigger already matches them
+func (c *circleCIConverter) refCondition(key *yaml.Node, filters map[string]interface{}, triggerBranches []string, tagTriggers bool) string {
+	branchFilter, _ := filters["branches"].(map[string]interface{})
+	branchExpr := c.filterCondition(key, branchFilter, "refs/heads/", "branch")
+
+	// Skip conditions that only repeat the workflow trigger
+	only := filterPatterns(branchFilter["only"])
+	var globs []string
+	for _, pattern := range only {
+		if glob, ok := filterGlob(pattern); ok {
+			globs = append(globs, glob)
+		}
+	}
+	if len(globs) == len(only) && sameStrings(globs, triggerBranches) && len(filterPatterns(branchFilter["ignore"])) == 0 {
+		branchExpr = ""
+	}
+
+	if !tagTriggers {
+		return branchExpr
+	}
+
+	branchPart := joinConditions("&&", "startsWith(github.ref, 'refs/heads/')", branchExpr)
+	tagFilter, hasTags := filters["tags"].(map[string]interface{})
+	if !hasTags {
+		return branchPart
+	}
+	tagPart := joinConditions("&&", "startsWith(github.ref, 'refs/tags/')", c.filterCondition(key, tagFilter, "refs/tags/", "tag"))
+	if branchExpr == "false" {
+		return tagPart
+	}
+	return joinConditions("||", branchPart, tagPart)
+}
+
+// filterCondition converts an only/ignore filter into an expression over
+// github.ref. An empty result matches every ref.
+func (c *circleCIConverter) filterCondition(key *yaml.Node, filter map[string]interface{}, prefix, kind string) string {
+	var onlyTerms []string
+	for _, pattern := range filterPatterns(filter["only"]) {
+		term, all, ok := refTerm(pattern, prefix)
+		if !ok {
+			c.todo(key, "%s filter %s cannot be expressed in if:; the job runs for every %s", kind, pattern, kind)
+			return ""
+		}
+		if all {
+			onlyTerms = nil
+			break
+		}
+		onlyTerms = append(onlyTerms, term)
+	}
+
+	var ignoreTerms []string
+	for _, pattern := range filterPatterns(filter["ignore"]) {
+		term, all, ok := refTerm(pattern, prefix)
+		switch {
+		case !ok:
+			c.todo(key, "%s ignore filter %s cannot be expressed in if: and is not applied", kind, pattern)
+			continue
+		case all:
+			return "false"
+		}
+		ignoreTerms = append(ignoreTerms, "!"+term)
+	}
+
+	return joinConditions("&&", joinConditions("||", onlyTerms...), joinConditions("&&", ignoreTerms...))
+}
+
+// refTerm converts one filter pattern into a github.ref test. all is set when
+// the pattern matches every ref.
+func refTerm(pattern, prefix string) (term string, all bool, ok bool) {
+	glob, ok := filterGlob(pattern)
+	if !ok {
+		return "", false, false
+	}
+	if glob == "**" {
+		return "", true, true
+	}
+
+	if name, literal := globLiteral(glob); literal {
+		return fmt.Sprintf("github.ref == '%s%s'", prefix, name), false, true
+	}
+	if stem, literal := globLiteral(strings.TrimSuffix(glob, "**")); literal && strings.HasSuffix(glob, "**") {
+		return fmt.Sprintf("startsWith(github.ref, '%s%s')", prefix, stem), false, true
+	}
+	return "", false, false
+}
+
+// filterGlob converts a CircleCI filter pattern to a GitHub Actions glob.
+// A pattern that is not a /regex/ is an exact name, so glob characters in
+// it are escaped. Regular expressions beyond escaped literals and .* cannot
+// be converted.
+func filterGlob(pattern string) (string, bool) {
+	if len(pattern) < 2 || !strings.HasPrefix(pattern, "/") || !strings.HasSuffix(pattern, "/") {
+		var glob strings.Builder
+		for i := 0; i < len(pattern); i++ {
+			writeGlobLiteral(&glob, pattern[i])
+		}
+		return glob.String(), true
+	}
+
+	expr := strings.TrimSuffix(strings.TrimPrefix(pattern[1:len(pattern)-1], "^"), "$")
+	var glob strings.Builder
+	for i := 0; i < len(expr); i++ {
+		ch := expr[i]
+		switch {
+		case ch == '\\' && i+1 < len(expr) && strings.IndexByte("/.-_", expr[i+1]) >= 0:
+			writeGlobLiteral(&glob, expr[i+1])
+			i++
+		case ch == '.' && i+1 < len(expr) && (expr[i+1] == '*' || expr[i+1] == '+'):
+			glob.WriteString("**")
+			i++
+		case strings.IndexByte(`\.+?*()[]{}|^$`, ch) >= 0:
+			return pattern, false
+		default:
+			writeGlobLiteral(&glob, ch)
+		}
+	}
+	return glob.String(), true
+}
+
+// writeGlobLiteral writes a character that matches itself, escaping the
+// characters GitHub Actions filter globs treat as special
+func writeGlobLiteral(glob *strings.Builder, ch byte) {
+	if strings.IndexByte(globSpecialChars, ch) >= 0 {
+		glob.WriteByte('\\')
+	}
+	glob.WriteByte(ch)
+}
+
+// globLiteral returns the name a glob matches when it has no unescaped
+// special characters
+func globLiteral(glob string) (string, bool) {
+	var name strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; {
+		case ch == '\\' && i+1 < len(glob):
+			name.WriteByte(glob[i+1])
+			i++
+		case strings.IndexByte(globSpecialChars, ch) >= 0:
+			return "", false
+		default:
+			name.WriteByte(ch)
+		}
+	}
+	return name.String(), true
+}
+
+// condition converts a CircleCI logic statement into an expression
+func (c *circleCIConverter) condition(condition interface{}) (string, bool) {
+	switch v := condition.(type) {
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case string:
+		match := pipelineValueRegex.FindStringSubmatch(v)
+		if match == nil {
+			return quoteLiteral(v), true
+		}
+		switch {
+		case strings.HasPrefix(match[1], "parameters."):
+			return c.inputReference(strings.TrimPrefix(match[1], "parameters.")), true
+		case match[1] == "git.branch":
+			return "(github.ref_type == 'branch' && github.ref_name || '')", true
+		case match[1] == "git.tag":
+			return "(github.ref_type == 'tag' && github.ref_name || '')", true
+		}
+		return "", false
+	case map[string]interface{}:
+		if nested, ok := v["condition"]; ok && len(v) == 1 {
+			return c.condition(nested)
+		}
+		if operand, ok := v["not"]; ok {
+			expr, ok := c.condition(operand)
+			return "!(" + expr + ")", ok
+		}
+		for _, op := range []string{"and", "or", "equal"} {
+			operands, ok := v[op].([]interface{})
+			if !ok {
+				continue
+			}
+			var exprs []string
+			for _, operand := range operands {
+				expr, ok := c.condition(operand)
+				if !ok {
+					return "", false
+				}
+				exprs = append(exprs, expr)
+			}
+			switch op {
+			case "and":
+				return joinConditions("&&", exprs...), len(exprs) > 0
+			case "or":
+				return joinConditions("||", exprs...), len(exprs) > 0
+			}
+			var pairs []string
+			for _, expr := range exprs[1:] {
+				pairs = append(pairs, exprs[0]+" == "+expr)
+			}
+			return joinConditions("&&", pairs...), len(pairs) > 0
+		}
+	}
+	return "", false
+}
+
+// inputReference reads a pipeline parameter from the workflow_dispatch
+// inputs, falling back to its default on other events
+func (c *circleCIConverter) inputReference(name string) string {
+	c.inputs[name] = true
+	decl := circleci.ParseParameterDecls(c.config.Parameters)[name]
+
+	switch value := decl.Default.(type) {
+	case bool:
+		if value {
+			return fmt.Sprintf("(github.event_name != 'workflow_dispatch' || inputs.%s)", name)
+		}
+	case string:
+		if value != "" {
+			return fmt.Sprintf("(inputs.%s || %s)", name, quoteLiteral(value))
+		}
+	case int, float64:
+		return fmt.Sprintf("(inputs.%s || %v)", name, value)
+	}
+	return "inputs." + name
+}
+
+// addDispatchInputs declares the pipeline parameters conditions refer to as
+// workflow_dispatch inputs
+func (c *circleCIConverter) addDispatchInputs(on *yaml.Node) {
+	if len(c.inputs) == 0 {
+		return
+	}
+	dispatch := lookup(on, "workflow_dispatch")
+	inputs := newMapping()
+	set(dispatch, "inputs", inputs)
+
+	decls := circleci.ParseParameterDecls(c.config.Parameters)
+	raw := c.config.Parameters
+	for _, name := range sortedKeys(c.inputs) {
+		decl := decls[name]
+		input := newMapping()
+		if decl.Description != "" {
+			set(input, "description", newScalar(decl.Description))
+		}
+
+		switch decl.Type {
+		case "boolean":
+			set(input, "type", newScalar("boolean"))
+		case "integer":
+			set(input, "type", newScalar("number"))
+		case "enum":
+			set(input, "type", newScalar("choice"))
+			if definition, ok := raw[name].(map[string]interface{}); ok {
+				if options, ok := definition["enum"].([]interface{}); ok {
+					set(input, "options", newValue(options))
+				}
+			}
+		default:
+			set(input, "type", newScalar("string"))
+		}
+
+		if decl.HasDefault {
+			set(input, "default", newValue(decl.Default))
+		} else {
+			set(input, "required", newValue(true))
+		}
+		set(inputs, name, input)
+	}
+}
+
+// convertJob builds a GitHub Actions job from a workflow invocation
+func (c *circleCIConverter) convertJob(key *yaml.Node, inv invocation, ids map[string]string, conditions []string) *yaml.Node {
+	node := newMapping()
+	if ids[inv.Alias] != inv.Alias {
+		set(node, "name", newScalar(matrixValueRegex.ReplaceAllString(inv.Alias, "$${{ matrix.$1 }}")))
+	}
+
+	var needs []string
+	for _, required := range inv.Requires {
+		if id, ok := ids[required]; ok {
+			needs = append(needs, id)
+		} else {
+			c.todo(key, "requires %s, which is not a job of this workflow", required)
+		}
+	}
+	if len(needs) > 0 {
+		set(node, "needs", newStrings(needs))
+	}
+	if condition := joinConditions("&&", conditions...); condition != "" {
+		set(node, "if", newScalar(condition))
+	}
+
+	if inv.Approval {
+		set(node, "runs-on", newScalar("ubuntu-latest"))
+		environment := set(node, "environment", newScalar(inv.Alias))
+		c.todo(environment, "add required reviewers to the %s environment to reproduce the approval gate", inv.Alias)
+		step := newMapping()
+		set(step, "run", newScalar(fmt.Sprintf("echo \"%s approved\"", inv.Alias)))
+		set(node, "steps", newSequence(step))
+		return node
+	}
+
+	// Matrix parameters are passed to the job as matrix expressions
+	args := make(map[string]interface{})
+	for name, value := range inv.Parameters {
+		args[name] = value
+	}
+	matrix := newMapping()
+	if params, ok := inv.Matrix["parameters"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(params) {
+			set(matrix, name, newValue(params[name]))
+			args[name] = fmt.Sprintf("${{ matrix.%s }}", name)
+		}
+		if exclude, ok := inv.Matrix["exclude"]; ok {
+			set(matrix, "exclude", newValue(exclude))
+		}
+	}
+
+	job, ok := circleci.ResolveJob(c.config, inv.Name, args)
+	if !ok {
+		set(node, "runs-on", newScalar("ubuntu-latest"))
+		step := newMapping()
+		set(step, "run", newScalar(fmt.Sprintf("echo \"TODO: convert %s\"", inv.Name)))
+		c.todo(set(node, "steps", newSequence(step)), "job %s is not defined in the config or a resolved orb; its steps could not be converted", inv.Name)
+		return node
+	}
+
+	decls := circleci.ParseParameterDecls(job.Parameters)
+	conditionKeys := invocationConditionKeys(c.config, inv)
+	var undeclared []string
+	for _, name := range sortedKeys(inv.Parameters) {
+		if _, declared := decls[name]; !declared && !shared.ContainsString(conditionKeys, name) {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		c.todo(key, "invocation keys %s are not parameters of job %s and were not converted", strings.Join(undeclared, ", "), inv.Name)
+	}
+
+	env := make(map[string]interface{})
+	workingDir := c.convertRunner(node, job, env)
+
+	if len(inv.Context) > 0 {
+		environment := set(node, "environment", newScalar(inv.Context[0]))
+		c.todo(environment, "copy the variables of CircleCI context %s into this environment's secrets and map them in env:", strings.Join(inv.Context, ", "))
+		if len(inv.Context) > 1 {
+			c.todo(environment, "a job can only use one environment; merge contexts %s", strings.Join(inv.Context, ", "))
+		}
+	}
+
+	if job.Parallelism > 1 {
+		nodes := make([]interface{}, job.Parallelism)
+		for i := range nodes {
+			nodes[i] = i
+		}
+		set(matrix, "node", newValue(nodes))
+	}
+	parallel := len(matrix.Content) > 0
+	if parallel {
+		strategy := newMapping()
+		set(strategy, "fail-fast", newValue(false))
+		matrixKey := set(strategy, "matrix", matrix)
+		if job.Parallelism > 1 {
+			c.todo(matrixKey, "parallelism: %d runs %d copies of the job; split the work with CIRCLE_NODE_INDEX and CIRCLE_NODE_TOTAL because circleci tests split is not available", job.Parallelism, job.Parallelism)
+		}
+		set(node, "strategy", strategy)
+	}
+
+	for name, value := range job.Environment {
+		env[name] = value
+	}
+
+	var raw []interface{}
+	raw = append(raw, inv.PreSteps...)
+	raw = append(raw, job.Steps...)
+	raw = append(raw, inv.PostSteps...)
+	steps := circleci.ExpandSteps(c.config, raw)
+
+	envNode := newMapping()
+	envKey := newScalar("env")
+	c.addCircleVariables(envKey, env, steps, job.Parallelism > 1)
+	if len(env) > 0 {
+		envNode = newValueMap(env)
+		node.Content = append(node.Content, envKey, envNode)
+	}
+
+	if workingDir != "" {
+		run := newMapping()
+		set(run, "working-directory", newScalar(workingDir))
+		defaults := newMapping()
+		set(defaults, "run", run)
+		set(node, "defaults", defaults)
+	}
+
+	stepsKey := newScalar("steps")
+	node.Content = append(node.Content, stepsKey, c.convertSteps(stepsKey, ids[inv.Alias], steps, parallel, lookup(node, "container") != nil))
+	return node
+}
+
+// convertRunner sets runs-on, container and services from the job's
+// execution environment, collects executor environment variables into env
+// and returns the working directory for run steps
+func (c *circleCIConverter) convertRunner(node *yaml.Node, job circleci.Job, env map[string]interface{}) string {
+	docker := job.Docker
+	machine, macos := job.Machine, job.MacOS
+	workingDir := job.WorkingDir
+
+	if len(docker) == 0 && machine == nil && macos == nil && job.Executor != "" {
+		executor, ok := circleci.LookupExecutor(c.config, job.Executor)
+		if !ok {
+			key := set(node, "runs-on", newScalar("ubuntu-latest"))
+			c.todo(key, "executor %s is not defined in the config or a resolved orb", job.Executor)
+			return ""
+		}
+
+		values := circleci.ResolveParameterValues(circleci.ParseParameterDecls(executor.Parameters), job.ExecutorParams)
+		scope := circleci.NewParameterScope(values, circleci.PipelineParameterValues(c.config))
+		for _, image := range executor.Docker {
+			image.Image = circleci.InterpolateString(image.Image, scope)
+			docker = append(docker, image)
+		}
+		machine, macos = executor.Machine, executor.MacOS
+		for name, value := range executor.Environment {
+			env[name] = value
+		}
+		if workingDir == "" {
+			workingDir = executor.WorkingDir
+		}
+	}
+
+	var key *yaml.Node
+	switch {
+	case len(docker) > 0:
+		key = set(node, "runs-on", newScalar("ubuntu-latest"))
+		c.convertContainers(node, docker)
+	case machine != nil:
+		key = set(node, "runs-on", newScalar("ubuntu-latest"))
+		if settings, ok := machine.(map[string]interface{}); ok {
+			if image, ok := settings["image"].(string); ok {
+				c.todo(key, "machine image %s was mapped to ubuntu-latest", image)
+			}
+		}
+	case macos != nil:
+		key = set(node, "runs-on", newScalar("macos-latest"))
+		if settings, ok := macos.(map[string]interface{}); ok {
+			if xcode, ok := settings["xcode"]; ok {
+				c.todo(key, "select Xcode %v, for example with maxim-lobanov/setup-xcode", xcode)
+			}
+		}
+	default:
+		key = set(node, "runs-on", newScalar("ubuntu-latest"))
+		c.todo(key, "the job does not declare an executor")
+	}
+
+	// ~/project is the checkout directory on both systems
+	switch {
+	case workingDir == "" || workingDir == "~/project" || workingDir == ".":
+		return ""
+	case strings.HasPrefix(workingDir, "~/project/"):
+		return strings.TrimPrefix(workingDir, "~/project/")
+	}
+	c.todo(key, "working_directory %s is outside the checkout; adjust the paths of run steps", workingDir)
+	return ""
+}
+
+// convertContainers maps the primary image to container: and the others to services:
+func (c *circleCIConverter) convertContainers(node *yaml.Node, docker []circleci.DockerConfig) {
+	primary := docker[0]
+	container := newMapping()
+	set(container, "image", newScalar(primary.Image))
+	if len(primary.Environment) > 0 {
+		set(container, "env", newValueMap(primary.Environment))
+	}
+	if primary.User != "" {
+		set(container, "options", newScalar("--user "+primary.User))
+	}
+	key := set(node, "container", container)
+	if primary.Auth != nil {
+		c.todo(key, "pass the registry credentials from auth: as container.credentials using secrets")
+	}
+	if len(primary.Entrypoint) > 0 || primary.Command != nil {
+		c.todo(key, "the primary image's entrypoint/command overrides are not supported for job containers")
+	}
+
+	if len(docker) == 1 {
+		return
+	}
+
+	services := newMapping()
+	taken := make(map[string]bool)
+	for _, image := range docker[1:] {
+		service := newMapping()
+		set(service, "image", newScalar(image.Image))
+		if len(image.Environment) > 0 {
+			set(service, "env", newValueMap(image.Environment))
+		}
+		serviceKey := set(services, identifier(serviceName(image), taken), service)
+		if image.Auth != nil {
+			c.todo(serviceKey, "pass the registry credentials from auth: as credentials using secrets")
+		}
+		if len(image.Entrypoint) > 0 || image.Command != nil {
+			c.todo(serviceKey, "service entrypoint/command overrides need an options: --entrypoint flag or a custom image")
+		}
+	}
+	key = set(node, "services", services)
+	c.todo(key, "services are reached by their service name instead of localhost from a job container; update hosts such as localhost:5432")
+}
+
+// serviceName derives a service key from an image reference
+func serviceName(image circleci.DockerConfig) string {
+	if image.Name != "" {
+		return image.Name
+	}
+	name := image.Image
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+	name = path.Base(name)
+	if colon := strings.Index(name, ":"); colon >= 0 {
+		name = name[:colon]
+	}
+	return name
+}
+
+// addCircleVariables maps the CircleCI built-in variables the steps use to
+// their GitHub Actions equivalents
+func (c *circleCIConverter) addCircleVariables(key *yaml.Node, env map[string]interface{}, steps []circleci.ExpandedStep, parallel bool) {
+	used := make(map[string]bool)
+	for _, step := range steps {
+		for _, match := range circleVariableRegex.FindAllStringSubmatch(step.Command, -1) {
+			used[match[1]] = true
+		}
+	}
+	if parallel {
+		used["CIRCLE_NODE_INDEX"] = true
+		used["CIRCLE_NODE_TOTAL"] = true
+	}
+
+	var unknown []string
+	for _, name := range sortedKeys(used) {
+		if _, declared := env[name]; declared {
+			continue
+		}
+		if expr, ok := circleEnvironment[name]; ok {
+			env[name] = expr
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		c.todo(key, "no GitHub Actions equivalent for %s", strings.Join(unknown, ", "))
+	}
+}
+
+// convertSteps converts a job's expanded steps
+func (c *circleCIConverter) convertSteps(key *yaml.Node, jobID string, steps []circleci.ExpandedStep, parallel, container bool) *yaml.Node {
+	seq := newSequence()
+	add := func(step *yaml.Node) *yaml.Node {
+		seq.Content = append(seq.Content, step)
+		return step
+	}
+
+	suffix := ""
+	if parallel {
+		suffix = "-${{ strategy.job-index }}"
+	}
+	artifactNames := make(map[string]bool)
+
+	for _, step := range steps {
+		first := len(seq.Content)
+		switch step.Type {
+		case "checkout":
+			node := newMapping()
+			set(node, "uses", newScalar(checkoutAction))
+			add(node)
+
+		case "run":
+			add(c.convertRun(step))
+
+		case "save_cache":
+			node := newMapping()
+			setName(node, step.Name, "")
+			set(node, "uses", newScalar(cacheSaveAction))
+			with := newMapping()
+			cacheKey, _ := step.Args["key"].(string)
+			set(with, "key", newScalar(c.cacheKey(node, cacheKey)))
+			set(with, "path", newScalar(strings.Join(stringList(step.Args["paths"]), "\n")))
+			set(node, "with", with)
+			add(node)
+
+		case "restore_cache":
+			node := newMapping()
+			setName(node, step.Name, "")
+			set(node, "uses", newScalar(cacheRestoreAction))
+			keys := stringList(step.Args["keys"])
+			if cacheKey, ok := step.Args["key"].(string); ok {
+				keys = append([]string{cacheKey}, keys...)
+			}
+			with := newMapping()
+			paths := c.restorePaths(keys)
+			pathKey := set(with, "path", newScalar(strings.Join(paths, "\n")))
+			if len(paths) == 0 {
+				c.todo(pathKey, "list the cached paths; no save_cache step with a matching key was found")
+			}
+			if len(keys) > 0 {
+				set(with, "key", newScalar(c.cacheKey(node, keys[0])))
+			}
+			if len(keys) > 1 {
+				var restoreKeys []string
+				for _, restoreKey := range keys[1:] {
+					restoreKeys = append(restoreKeys, c.cacheKey(node, restoreKey))
+				}
+				set(with, "restore-keys", newScalar(strings.Join(restoreKeys, "\n")))
+			}
+			set(node, "with", with)
+			add(node)
+
+		case "persist_to_workspace":
+			root, _ := step.Args["root"].(string)
+			if root == "" {
+				root = "."
+			}
+			archive := fmt.Sprintf("%s/%s%s.tgz", workspaceDir, jobID, suffix)
+			tar := newMapping()
+			set(tar, "name", newScalar("Persist to workspace"))
+			set(tar, "run", newScalar(fmt.Sprintf("mkdir -p %s\ntar -czf %s -C %s %s", workspaceDir, archive, root, strings.Join(stringList(step.Args["paths"]), " "))))
+			add(tar)
+			upload := newMapping()
+			set(upload, "uses", newScalar(uploadArtifactAction))
+			with := newMapping()
+			set(with, "name", newScalar("workspace-"+jobID+suffix))
+			set(with, "path", newScalar(archive))
+			set(upload, "with", with)
+			add(upload)
+
+		case "attach_workspace":
+			at, _ := step.Args["at"].(string)
+			if at == "" {
+				at = "."
+			}
+			download := newMapping()
+			set(download, "name", newScalar("Attach workspace"))
+			set(download, "uses", newScalar(downloadArtifactAction))
+			with := newMapping()
+			set(with, "pattern", newScalar("workspace-*"))
+			set(with, "path", newScalar(workspaceDir))
+			set(with, "merge-multiple", newValue(true))
+			set(download, "with", with)
+			add(download)
+			extract := newMapping()
+			set(extract, "run", newScalar(fmt.Sprintf("mkdir -p %s\nfor archive in %s/*.tgz; do tar -xzf \"$archive\" -C %s; done", at, workspaceDir, at)))
+			add(extract)
+
+		case "store_artifacts", "store_test_results":
+			artifactPath, _ := step.Args["path"].(string)
+			name, _ := step.Args["destination"].(string)
+			if name == "" {
+				name = path.Base(artifactPath)
+			}
+			if step.Type == "store_test_results" {
+				name = "test-results"
+			}
+			node := newMapping()
+			setName(node, step.Name, "")
+			set(node, "if", newScalar("always()"))
+			set(node, "uses", newScalar(uploadArtifactAction))
+			with := newMapping()
+			set(with, "name", newScalar(artifactName(jobID, name, artifactNames)+suffix))
+			set(with, "path", newScalar(artifactPath))
+			set(node, "with", with)
+			if step.Type == "store_test_results" {
+				c.todo(node, "publish the results in %s with a test reporter action to get test summaries", artifactPath)
+			}
+			add(node)
+
+		case "setup_remote_docker":
+			if container {
+				c.todo(key, "setup_remote_docker: Docker is not available inside a job container; run this job directly on the runner or mount /var/run/docker.sock")
+			}
+
+		case "add_ssh_keys":
+			node := add(placeholderStep(step))
+			c.todo(node, "load deploy keys with an SSH agent action such as webfactory/ssh-agent and a secret")
+
+		default:
+			node := add(placeholderStep(step))
+			kind := "step"
+			if strings.Contains(step.Type, "/") {
+				kind = "orb step"
+			}
+			if len(step.Args) > 0 {
+				c.todo(node, "%s %s has no GitHub Actions equivalent (arguments: %s)", kind, step.Type, describeArgs(step.Args))
+			} else {
+				c.todo(node, "%s %s has no GitHub Actions equivalent", kind, step.Type)
+			}
+		}
+
+		// Steps under when/unless conditions decided only at run time keep a reminder
+		if step.Condition != "" && first < len(seq.Content) {
+			c.todo(seq.Content[first], "runs only %s in CircleCI; add an if: condition", step.Condition)
+		}
+	}
+
+	return seq
+}
+
+// convertRun converts a run step
+func (c *circleCIConverter) convertRun(step circleci.ExpandedStep) *yaml.Node {
+	node := newMapping()
+	setName(node, step.Name, "")
+
+	switch step.Args["when"] {
+	case "always":
+		set(node, "if", newScalar("always()"))
+	case "on_fail":
+		set(node, "if", newScalar("failure()"))
+	}
+	if workingDir, ok := step.Args["working_directory"].(string); ok && workingDir != "" {
+		set(node, "working-directory", newScalar(workingDir))
+	}
+	if shell, ok := step.Args["shell"].(string); ok && shell != "" {
+		set(node, "shell", newScalar(shell))
+	}
+	if env, ok := step.Args["environment"].(map[string]interface{}); ok && len(env) > 0 {
+		set(node, "env", newValueMap(env))
+	}
+	set(node, "run", newScalar(trimLines(step.Command)))
+
+	if background, _ := step.Args["background"].(bool); background {
+		c.todo(node, "background steps are not supported; start the process with & or nohup")
+	}
+	if timeout, ok := step.Args["no_output_timeout"]; ok {
+		c.todo(node, "no_output_timeout: %v has no equivalent; timeout-minutes limits the total step time", timeout)
+	}
+	if circleCLIRegex.MatchString(step.Command) {
+		c.todo(node, "the circleci CLI is not available on GitHub Actions runners")
+	}
+	return node
+}
+
+// cacheKey converts a CircleCI cache key template into an expression
+func (c *circleCIConverter) cacheKey(node *yaml.Node, key string) string {
+	return cacheTemplateRegex.ReplaceAllStringFunc(key, func(template string) string {
+		expr := cacheTemplateRegex.FindStringSubmatch(template)[1]
+		if match := checksumRegex.FindStringSubmatch(expr); match != nil {
+			return fmt.Sprintf("${{ hashFiles('%s') }}", match[1])
+		}
+		switch {
+		case expr == "arch":
+			return "${{ runner.os }}-${{ runner.arch }}"
+		case expr == ".Branch":
+			return "${{ github.ref_name }}"
+		case expr == ".Revision":
+			return "${{ github.sha }}"
+		case expr == ".BuildNum":
+			return "${{ github.run_number }}"
+		case expr == "epoch":
+			return "${{ github.run_id }}"
+		case strings.HasPrefix(expr, ".Environment."):
+			return fmt.Sprintf("${{ env.%s }}", strings.TrimPrefix(expr, ".Environment."))
+		}
+		c.todo(node, "cache key template %s has no equivalent", template)
+		return template
+	})
+}
+
+// savedCachePaths indexes the paths of every save_cache step by key prefix
+func savedCachePaths(config *circleci.Config) map[string][]string {
+	paths := make(map[string][]string)
+	for _, jobName := range sortedKeys(config.Jobs) {
+		for _, step := range circleci.ExpandSteps(config, config.Jobs[jobName].Steps) {
+			if step.Type != "save_cache" {
+				continue
+			}
+			key, _ := step.Args["key"].(string)
+			prefix := cacheKeyPrefix(key)
+			paths[prefix] = appendUnique(paths[prefix], stringList(step.Args["paths"])...)
+		}
+	}
+	return paths
+}
+
+// restorePaths finds the paths saved under the keys a restore_cache step looks up
+func (c *circleCIConverter) restorePaths(keys []string) []string {
+	for _, key := range keys {
+		prefix := cacheKeyPrefix(key)
+		for _, savedPrefix := range sortedKeys(c.cachePaths) {
+			if strings.HasPrefix(savedPrefix, prefix) || strings.HasPrefix(prefix, savedPrefix) {
+				return c.cachePaths[savedPrefix]
+			}
+		}
+	}
+	return nil
+}
+
+// cacheKeyPrefix returns the literal part of a cache key before its first template
+func cacheKeyPrefix(key string) string {
+	if index := strings.Index(key, "{{"); index >= 0 {
+		return key[:index]
+	}
+	return key
+}
+
+// artifactName builds an artifact name unique within the workflow run
+func artifactName(jobID, name string, taken map[string]bool) string {
+	name = strings.Trim(identifierRegex.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		name = "artifacts"
+	}
+	return identifier(jobID+"-"+name, taken)
+}
+
+// placeholderStep keeps an unconvertible step visible in the job
+func placeholderStep(step circleci.ExpandedStep) *yaml.Node {
+	node := newMapping()
+	setName(node, step.Name, step.Type)
+	set(node, "run", newScalar(fmt.Sprintf("echo \"TODO: convert %s\"", step.Type)))
+	return node
+}
+
+// setName sets a step's name, falling back to fallback when it has none
+func setName(node *yaml.Node, name, fallback string) {
+	if name == "" {
+		name = fallback
+	}
+	if name != "" {
+		set(node, "name", newScalar(name))
+	}
+}
+
+// describeArgs renders step arguments for a TODO comment
+func describeArgs(args map[string]interface{}) string {
+	var pairs []string
+	for _, name := range sortedKeys(args) {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, args[name]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// filterPatterns reads a filter value given as a string or list
+func filterPatterns(value interface{}) []string {
+	return stringList(value)
+}
+
+// stringList reads a string or list of strings
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// joinConditions joins non-empty expressions with an operator, wrapping
+// expressions that contain a lower-precedence operator
+func joinConditions(op string, exprs ...string) string {
+	var parts []string
+	for _, expr := range exprs {
+		if expr == "" {
+			continue
+		}
+		if op == "&&" && strings.Contains(expr, "||") && !(strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")")) {
+			expr = "(" + expr + ")"
+		}
+		parts = append(parts, expr)
+	}
+	if len(parts) > 1 && op == "||" {
+		for i, part := range parts {
+			if strings.Contains(part, "&&") && !(strings.HasPrefix(part, "(") && strings.HasSuffix(part, ")")) {
+				parts[i] = "(" + part + ")"
+			}
+		}
+	}
+	return strings.Join(parts, " "+op+" ")
+}
+
+// quoteLiteral renders a string as an expression literal
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// appendUnique appends values that are not already present
+func appendUnique(items []string, values ...string) []string {
+	for _, value := range values {
+		if !shared.ContainsString(items, value) {
+			items = append(items, value)
+		}
+	}
+	return items
+}
+
+// sameStrings reports whether two lists hold the same values in any order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, value := range a {
+		if !shared.ContainsString(b, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a map, sorted
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
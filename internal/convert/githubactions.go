@@ -0,0 +1,1056 @@
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/circleci"
+	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// circleConfigPath is where the converted CircleCI config is written
+const circleConfigPath = ".circleci/config.yml"
+
+// Execution environments used by converted jobs
+const (
+	machineImage = "ubuntu-2204:current"
+	windowsImage = "windows-server-2022-gui:current"
+	xcodeVersion = "15.4.0"
+	baseImage    = "cimg/base:stable"
+)
+
+// nativeOrbs are the orbs that provide native equivalents of setup actions
+var nativeOrbs = map[string]string{
+	"node": "circleci/node@5.2.0",
+	"go":   "circleci/go@1.11.0",
+}
+
+// githubVariables maps github.* expressions to CircleCI built-in variables
+var githubVariables = map[string]string{
+	"github.sha":              "${CIRCLE_SHA1}",
+	"github.ref_name":         "${CIRCLE_BRANCH:-$CIRCLE_TAG}",
+	"github.run_number":       "${CIRCLE_BUILD_NUM}",
+	"github.run_id":           "${CIRCLE_WORKFLOW_ID}",
+	"github.job":              "${CIRCLE_JOB}",
+	"github.repository":       "${CIRCLE_PROJECT_USERNAME}/${CIRCLE_PROJECT_REPONAME}",
+	"github.repository_owner": "${CIRCLE_PROJECT_USERNAME}",
+	"github.workspace":        "${CIRCLE_WORKING_DIRECTORY}",
+	"github.actor":            "${CIRCLE_USERNAME}",
+	"strategy.job-index":      "${CIRCLE_NODE_INDEX}",
+	"strategy.job-total":      "${CIRCLE_NODE_TOTAL}",
+}
+
+// cacheKeyVariables maps expressions in cache keys to CircleCI key templates
+var cacheKeyVariables = map[string]string{
+	"runner.os":       "{{ arch }}",
+	"runner.arch":     "{{ arch }}",
+	"github.sha":      "{{ .Revision }}",
+	"github.ref_name": "{{ .Branch }}",
+	"github.run_id":   "{{ epoch }}",
+}
+
+var (
+	expressionRegex   = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+	hashFilesRegex    = regexp.MustCompile(`^hashFiles\(\s*'([^'*?]+)'\s*\)$`)
+	refTermRegex      = regexp.MustCompile(`^github\.ref\s*==\s*'refs/(heads|tags)/([^']+)'$`)
+	refPrefixRegex    = regexp.MustCompile(`^startsWith\(\s*github\.ref\s*,\s*'refs/(heads|tags)/([^']*)'\s*\)$`)
+	refNameRegex      = regexp.MustCompile(`^github\.ref_name\s*==\s*'([^']+)'$`)
+	envReferenceRegex = regexp.MustCompile(`^\$\{\{\s*env\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}$`)
+)
+
+// gitHubActionsConverter carries the state of a GitHub Actions to CircleCI conversion
+type gitHubActionsConverter struct {
+	notes
+	parser        *githubactions.Parser
+	orbs          map[string]string
+	executors     *yaml.Node
+	executorNames map[string]string // Encoded executor → name
+	jobs          *yaml.Node
+	jobNames      map[string]bool
+	unknown       map[string][]string // Action reference → "workflow / job" users
+	params        map[string]bool     // Matrix dimensions of the job being converted
+	env           map[string]string   // Workflow and job env of the job being converted
+	downloaded    map[string]bool     // Artifact names downloaded in the workflow, "*" for all
+}
+
+// GitHubActionsToCircleCI converts GitHub Actions workflows, keyed by file
+// name, into a single CircleCI 2.1 config. Resolve local references first so
+// composite actions and reusable workflows are inlined.
+func GitHubActionsToCircleCI(workflows map[string]*githubactions.Workflow) (*Result, error) {
+	if len(workflows) == 0 {
+		return nil, fmt.Errorf("no GitHub Actions workflows to convert")
+	}
+
+	c := &gitHubActionsConverter{
+		notes:         notes{result: &Result{From: ir.ToolGitHubActions, To: ir.ToolCircleCI}, file: circleConfigPath},
+		parser:        githubactions.NewParser(),
+		orbs:          make(map[string]string),
+		executors:     newMapping(),
+		executorNames: make(map[string]string),
+		jobs:          newMapping(),
+		jobNames:      make(map[string]bool),
+		unknown:       make(map[string][]string),
+	}
+
+	workflowsNode := newMapping()
+	workflowNames := make(map[string]bool)
+	for _, fileName := range sortedKeys(workflows) {
+		workflow := workflows[fileName]
+		name := identifier(strings.TrimSuffix(fileName, filepath.Ext(fileName)), workflowNames)
+		c.convertWorkflow(workflowsNode, name, workflow, workflowNames)
+
+		shared.GetLogger().Debug("convert", "Converted GitHub Actions workflow", map[string]interface{}{
+			"file":     fileName,
+			"workflow": name,
+		})
+	}
+
+	root := newMapping()
+	set(root, "version", newScalar("2.1"))
+	if len(c.orbs) > 0 {
+		orbs := newMapping()
+		for _, alias := range sortedKeys(c.orbs) {
+			set(orbs, alias, newScalar(c.orbs[alias]))
+		}
+		set(root, "orbs", orbs)
+	}
+	if len(c.executors.Content) > 0 {
+		set(root, "executors", c.executors)
+	}
+	set(root, "jobs", c.jobs)
+	set(root, "workflows", workflowsNode)
+
+	content, err := encodeDocument(root, []string{
+		"Converted from .github/workflows by pipeline-analyzer convert.",
+		"Review every TODO comment before relying on this config.",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CircleCI config: %w", err)
+	}
+	c.result.Files = append(c.result.Files, File{Path: circleConfigPath, Content: content})
+
+	for _, uses := range sortedKeys(c.unknown) {
+		c.result.UnknownActions = append(c.result.UnknownActions, UnknownAction{Uses: uses, UsedBy: c.unknown[uses]})
+	}
+	return c.result, nil
+}
+
+// convertWorkflow adds the CircleCI workflows and jobs of one GitHub Actions workflow
+func (c *gitHubActionsConverter) convertWorkflow(workflowsNode *yaml.Node, name string, workflow *githubactions.Workflow, workflowNames map[string]bool) {
+	c.job = ""
+	events := triggerEvents(workflow.On)
+
+	if _, called := events["workflow_call"]; called && len(events) == 1 {
+		// Not rendered, so the note is only listed in the report
+		c.todo(newScalar(name), "%s only runs as a reusable workflow; its jobs are inlined into the callers", name)
+		return
+	}
+
+	workflowKey := newScalar(name)
+	push, hasPush := events["push"]
+	filters := c.pushFilters(workflowKey, push)
+
+	var ignored []string
+	for _, event := range sortedKeys(events) {
+		switch event {
+		case "push", "schedule":
+		case "pull_request":
+			// CircleCI has no pull request trigger; the project settings decide
+			// whether the branch builds of a pull request run
+			if hasPush {
+				c.todo(workflowKey, "pull_request triggers are dropped; CircleCI builds pull requests through the project settings, as branch builds of the pushed commits")
+			} else {
+				c.todo(workflowKey, "pull_request triggers become branch builds; enable \"Only build pull requests\" in the project settings to match")
+			}
+		default:
+			ignored = append(ignored, event)
+		}
+	}
+	if len(ignored) > 0 {
+		c.todo(workflowKey, "events %s have no CircleCI trigger; use the API or scheduled pipelines", strings.Join(ignored, ", "))
+	}
+
+	c.downloaded = downloadedArtifacts(workflow)
+	invocations := newSequence()
+	jobIDs := orderJobIDs(c.parser, workflow)
+	names := make(map[string]string)
+	for _, jobID := range jobIDs {
+		jobName := jobID
+		if c.jobNames[jobName] {
+			jobName = name + "-" + jobID
+		}
+		names[jobID] = identifier(jobName, c.jobNames)
+	}
+
+	for _, jobID := range jobIDs {
+		c.job = jobID
+		invocation := c.convertJob(name, jobID, names, workflow, filters)
+		invocations.Content = append(invocations.Content, invocation)
+	}
+	c.job = ""
+
+	// A schedule gets its own workflow unless it is the only trigger
+	_, scheduled := events["schedule"]
+	triggered := !scheduled || len(events) > 1
+	if triggered {
+		definition := newMapping()
+		set(definition, "jobs", invocations)
+		workflowsNode.Content = append(workflowsNode.Content, workflowKey, definition)
+	}
+
+	if scheduled {
+		triggers := newSequence()
+		if schedules, ok := events["schedule"].([]interface{}); ok {
+			for _, entry := range schedules {
+				cron, _ := entry.(map[string]interface{})["cron"].(string)
+				trigger := newMapping()
+				schedule := newMapping()
+				set(schedule, "cron", newScalar(cron))
+				branches := newMapping()
+				set(branches, "only", newScalar("main"))
+				filterNode := newMapping()
+				set(filterNode, "branches", branches)
+				filterKey := set(schedule, "filters", filterNode)
+				c.todo(filterKey, "GitHub runs schedules on the default branch; check it is main")
+				set(trigger, "schedule", schedule)
+				triggers.Content = append(triggers.Content, trigger)
+			}
+		}
+
+		scheduledKey := workflowKey
+		if triggered {
+			scheduledKey = newScalar(identifier(name+"-scheduled", workflowNames))
+		}
+		definition := newMapping()
+		set(definition, "triggers", triggers)
+		set(definition, "jobs", stripFilters(invocations))
+		workflowsNode.Content = append(workflowsNode.Content, scheduledKey, definition)
+	}
+}
+
+// triggerEvents normalizes an on: value into a map of event settings
+func triggerEvents(on interface{}) map[string]interface{} {
+	events := make(map[string]interface{})
+	switch value := on.(type) {
+	case string:
+		events[value] = nil
+	case []interface{}:
+		for _, event := range value {
+			if name, ok := event.(string); ok {
+				events[name] = nil
+			}
+		}
+	case map[string]interface{}:
+		for name, settings := range value {
+			events[name] = settings
+		}
+	}
+	return events
+}
+
+// orderJobIDs lists job IDs with every job after the jobs it needs
+func orderJobIDs(parser *githubactions.Parser, workflow *githubactions.Workflow) []string {
+	var ordered []string
+	placed := make(map[string]bool)
+	remaining := sortedKeys(workflow.Jobs)
+
+	for len(remaining) > 0 {
+		var next []string
+		progressed := false
+		for _, jobID := range remaining {
+			ready := true
+			for _, need := range parser.GetJobDependencies(workflow.Jobs[jobID]) {
+				if _, exists := workflow.Jobs[need]; exists && !placed[need] {
+					ready = false
+				}
+			}
+			if ready {
+				ordered = append(ordered, jobID)
+				placed[jobID] = true
+				progressed = true
+			} else {
+				next = append(next, jobID)
+			}
+		}
+		if !progressed {
+			// Dependency cycle; keep the remaining jobs in name order
+			return append(ordered, next...)
+		}
+		remaining = next
+	}
+
+	return ordered
+}
+
+// pushFilters converts on.push branch and tag filters into job filters
+func (c *gitHubActionsConverter) pushFilters(key *yaml.Node, push interface{}) map[string]interface{} {
+	settings, ok := push.(map[string]interface{})
+	if !ok || len(settings) == 0 {
+		return nil
+	}
+
+	filters := make(map[string]interface{})
+	branches := make(map[string]interface{})
+	tags := make(map[string]interface{})
+	add := func(filter map[string]interface{}, kind, field string) {
+		patterns := stringList(settings[field])
+		if len(patterns) == 0 {
+			return
+		}
+		var values []interface{}
+		for _, pattern := range patterns {
+			if strings.HasPrefix(pattern, "!") {
+				c.todo(key, "negated %s pattern %s is not supported by CircleCI filters", field, pattern)
+				continue
+			}
+			values = append(values, globFilter(pattern))
+		}
+		if len(values) > 0 {
+			filter[kind] = values
+		}
+	}
+	add(branches, "only", "branches")
+	add(branches, "ignore", "branches-ignore")
+	add(tags, "only", "tags")
+	add(tags, "ignore", "tags-ignore")
+
+	// Only tag filters means branch pushes do not trigger
+	if len(branches) == 0 && len(tags) > 0 {
+		branches["ignore"] = []interface{}{"/.*/"}
+	}
+	if len(tags) > 0 && tags["only"] == nil {
+		tags["only"] = []interface{}{"/.*/"}
+	}
+	if len(branches) > 0 {
+		filters["branches"] = branches
+	}
+	if len(tags) > 0 {
+		filters["tags"] = tags
+	}
+
+	if settings["paths"] != nil || settings["paths-ignore"] != nil {
+		c.todo(key, "on.push paths filters need the path-filtering orb and dynamic config; every push runs the workflow")
+	}
+	return filters
+}
+
+// globFilter converts a GitHub filter glob into a CircleCI filter value
+func globFilter(pattern string) string {
+	if !strings.ContainsAny(pattern, "*?+[") {
+		return pattern
+	}
+
+	var sb strings.Builder
+	sb.WriteString("/")
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case ch == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			sb.WriteString(".*")
+			i++
+		case ch == '*':
+			sb.WriteString("[^/]*")
+		case ch == '?' || ch == '+':
+			sb.WriteByte(ch)
+		case ch == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			sb.WriteString(pattern[i : i+end+1])
+			i += end
+		case ch == '/':
+			sb.WriteString(`\/`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("/")
+	return sb.String()
+}
+
+// downloadedArtifacts lists the artifact names download-artifact steps fetch
+func downloadedArtifacts(workflow *githubactions.Workflow) map[string]bool {
+	downloaded := make(map[string]bool)
+	for _, job := range workflow.Jobs {
+		steps, _ := githubactions.ExpandJobSteps(workflow, job)
+		for _, step := range steps {
+			if actionName(step.Uses) != "actions/download-artifact" {
+				continue
+			}
+			name, _ := step.With["name"].(string)
+			if name == "" {
+				name = "*"
+			}
+			downloaded[name] = true
+		}
+	}
+	return downloaded
+}
+
+// convertJob adds a CircleCI job for a GitHub Actions job and returns its
+// workflow invocation
+func (c *gitHubActionsConverter) convertJob(workflowName, jobID string, names map[string]string, workflow *githubactions.Workflow, filters map[string]interface{}) *yaml.Node {
+	job := workflow.Jobs[jobID]
+	name := names[jobID]
+	user := workflowName + " / " + jobID
+
+	key := newScalar(name)
+	node := newMapping()
+	c.jobs.Content = append(c.jobs.Content, key, node)
+	if job.Name != "" && job.Name != jobID {
+		set(node, "description", newScalar(job.Name))
+	}
+
+	settings := newMapping()
+	settingsKey := newScalar(name)
+
+	// Matrix dimensions become job parameters passed by a workflow matrix
+	c.params = make(map[string]bool)
+	matrix := c.convertMatrix(settingsKey, job.Strategy.Matrix)
+	if len(c.params) > 0 {
+		params := newMapping()
+		for _, param := range sortedKeys(c.params) {
+			definition := newMapping()
+			set(definition, "type", newScalar("string"))
+			set(params, param, definition)
+		}
+		set(node, "parameters", params)
+	}
+
+	if job.Uses != "" && !githubactions.IsLocalReference(job.Uses) {
+		c.executor(node, job)
+		step := newMapping()
+		set(step, "run", newScalar(fmt.Sprintf("echo \"TODO: replace reusable workflow %s\"", job.Uses)))
+		stepsKey := set(node, "steps", newSequence(step))
+		c.todo(stepsKey, "reusable workflow %s cannot be converted; inline its jobs", job.Uses)
+		c.unknown[job.Uses] = append(c.unknown[job.Uses], user)
+	} else {
+		c.executor(node, job)
+
+		c.env = make(map[string]string)
+		for envName, value := range workflow.Env {
+			c.env[envName] = value
+		}
+		for envName, value := range job.Env {
+			c.env[envName] = value
+		}
+		if environment := c.environment(key, c.env); environment != nil {
+			set(node, "environment", environment)
+		}
+
+		steps, _ := githubactions.ExpandJobSteps(workflow, job)
+		stepsKey := newScalar("steps")
+		node.Content = append(node.Content, stepsKey, c.convertSteps(stepsKey, steps, user))
+	}
+
+	if len(job.Outputs) > 0 {
+		c.todo(key, "job outputs %s have no equivalent; pass values through the workspace", strings.Join(sortedKeys(job.Outputs), ", "))
+	}
+	if job.TimeoutMinutes > 0 {
+		c.todo(key, "timeout-minutes: %d has no job-level equivalent; use no_output_timeout on long steps", job.TimeoutMinutes)
+	}
+
+	// Workflow invocation
+	var requires []string
+	for _, need := range c.parser.GetJobDependencies(job) {
+		if required, ok := names[need]; ok {
+			requires = append(requires, required)
+		}
+	}
+	if len(requires) > 0 {
+		set(settings, "requires", newStrings(requires))
+	}
+
+	jobFilters := filters
+	if job.If != "" {
+		if refFilters, ok := conditionFilters(job.If); ok {
+			jobFilters = refFilters
+		} else {
+			c.todo(settingsKey, "if: %s was not converted; the job always runs", job.If)
+		}
+	}
+	if len(jobFilters) > 0 {
+		set(settings, "filters", newValue(jobFilters))
+	}
+	if matrix != nil {
+		set(settings, "matrix", matrix)
+	}
+
+	if len(settings.Content) == 0 && settingsKey.HeadComment == "" {
+		return newScalar(name)
+	}
+	// Comments on a sequence item read better above the item than on its key
+	invocation := newMapping()
+	invocation.HeadComment, settingsKey.HeadComment = settingsKey.HeadComment, ""
+	invocation.Content = append(invocation.Content, settingsKey, settings)
+	return invocation
+}
+
+// convertMatrix converts strategy.matrix into a CircleCI workflow matrix and
+// records its dimensions as job parameters
+func (c *gitHubActionsConverter) convertMatrix(key *yaml.Node, matrix interface{}) *yaml.Node {
+	if matrix == nil {
+		return nil
+	}
+	definition, ok := matrix.(map[string]interface{})
+	if !ok {
+		c.todo(key, "the matrix is built from an expression; list its values in a CircleCI matrix")
+		return nil
+	}
+
+	params := newMapping()
+	for _, dimension := range sortedKeys(definition) {
+		values, isList := definition[dimension].([]interface{})
+		switch {
+		case dimension == "include":
+			c.todo(key, "matrix include entries cannot be expressed; add separate invocations for them")
+			continue
+		case dimension == "exclude":
+			continue
+		case !isList:
+			c.todo(key, "matrix dimension %s is built from an expression; list its values", dimension)
+			continue
+		}
+
+		var strs []string
+		for _, value := range values {
+			if _, scalar := value.(map[string]interface{}); scalar {
+				c.todo(key, "matrix dimension %s holds objects; CircleCI matrix values must be scalars", dimension)
+				strs = nil
+				break
+			}
+			strs = append(strs, fmt.Sprintf("%v", value))
+		}
+		if strs != nil {
+			c.params[dimension] = true
+			set(params, dimension, newStrings(strs))
+		}
+	}
+	if len(params.Content) == 0 {
+		return nil
+	}
+
+	node := newMapping()
+	set(node, "parameters", params)
+	if exclude, ok := definition["exclude"].([]interface{}); ok {
+		excludes := newSequence()
+		for _, entry := range exclude {
+			values, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			combination := newMapping()
+			for _, dimension := range sortedKeys(values) {
+				set(combination, dimension, newScalar(fmt.Sprintf("%v", values[dimension])))
+			}
+			excludes.Content = append(excludes.Content, combination)
+		}
+		set(node, "exclude", excludes)
+	}
+	return node
+}
+
+// conditionFilters turns a job if: that only tests github.ref into filters
+func conditionFilters(condition string) (map[string]interface{}, bool) {
+	expr := githubactions.ConditionExpression(condition)
+	if strings.Contains(expr, "&&") {
+		return nil, false
+	}
+
+	var branches, tags []interface{}
+	for _, term := range strings.Split(expr, "||") {
+		term = strings.TrimSpace(term)
+		if strings.HasPrefix(term, "(") && strings.HasSuffix(term, ")") {
+			term = strings.TrimSpace(term[1 : len(term)-1])
+		}
+		if match := refTermRegex.FindStringSubmatch(term); match != nil {
+			if match[1] == "heads" {
+				branches = append(branches, match[2])
+			} else {
+				tags = append(tags, match[2])
+			}
+			continue
+		}
+		if match := refPrefixRegex.FindStringSubmatch(term); match != nil {
+			value := "/" + strings.ReplaceAll(regexp.QuoteMeta(match[2]), "/", `\/`) + ".*/"
+			if match[1] == "heads" {
+				branches = append(branches, value)
+			} else {
+				tags = append(tags, value)
+			}
+			continue
+		}
+		if match := refNameRegex.FindStringSubmatch(term); match != nil {
+			branches = append(branches, match[1])
+			continue
+		}
+		return nil, false
+	}
+
+	filters := make(map[string]interface{})
+	if len(branches) > 0 {
+		filters["branches"] = map[string]interface{}{"only": branches}
+	} else {
+		filters["branches"] = map[string]interface{}{"ignore": []interface{}{"/.*/"}}
+	}
+	if len(tags) > 0 {
+		filters["tags"] = map[string]interface{}{"only": tags}
+	}
+	return filters, true
+}
+
+// stripFilters copies workflow invocations without branch filters, which
+// scheduled workflows take from their trigger
+func stripFilters(invocations *yaml.Node) *yaml.Node {
+	stripped := newSequence()
+	for _, invocation := range invocations.Content {
+		if invocation.Kind != yaml.MappingNode {
+			stripped.Content = append(stripped.Content, invocation)
+			continue
+		}
+		settings := newMapping()
+		original := invocation.Content[1]
+		for i := 0; i+1 < len(original.Content); i += 2 {
+			if original.Content[i].Value != "filters" {
+				settings.Content = append(settings.Content, original.Content[i], original.Content[i+1])
+			}
+		}
+		if len(settings.Content) == 0 {
+			stripped.Content = append(stripped.Content, newScalar(invocation.Content[0].Value))
+			continue
+		}
+		copied := newMapping()
+		copied.Content = append(copied.Content, newScalar(invocation.Content[0].Value), settings)
+		stripped.Content = append(stripped.Content, copied)
+	}
+	return stripped
+}
+
+// executor adds the job's executor key, naming a shared executor that matches
+// the job's runner and containers and is defined on first use
+func (c *gitHubActionsConverter) executor(jobNode *yaml.Node, job githubactions.Job) {
+	runner := c.parser.GetRunnerType(job)
+	definition := newMapping()
+	var name string
+	var todos []string
+
+	image, containerEnv := jobContainer(job.Container)
+	switch {
+	case image != "" || len(job.Services) > 0:
+		docker := newSequence()
+		primary := newMapping()
+		if image == "" {
+			image = baseImage
+			todos = append(todos, baseImage+" lacks the tools preinstalled on GitHub-hosted runners; pick an image for the job")
+		} else if len(job.Services) > 0 {
+			todos = append(todos, "services are reached on localhost instead of their service names; update hosts in the job")
+		}
+		set(primary, "image", newScalar(c.translate(primary, image)))
+		if len(containerEnv) > 0 {
+			set(primary, "environment", newValue(containerEnv))
+		}
+		docker.Content = append(docker.Content, primary)
+
+		for _, alias := range sortedKeys(job.Services) {
+			service := job.Services[alias]
+			secondary := newMapping()
+			set(secondary, "image", newScalar(c.translate(secondary, service.Image)))
+			set(secondary, "name", newScalar(alias))
+			if len(service.Env) > 0 {
+				env := make(map[string]interface{})
+				for envName, value := range service.Env {
+					env[envName] = c.translate(secondary, value)
+				}
+				set(secondary, "environment", newValueMap(env))
+			}
+			docker.Content = append(docker.Content, secondary)
+		}
+		set(definition, "docker", docker)
+		name = serviceName(circleci.DockerConfig{Image: image})
+
+	case strings.Contains(runner, "macos"):
+		macos := newMapping()
+		set(macos, "xcode", newScalar(xcodeVersion))
+		set(definition, "macos", macos)
+		name = "macos"
+		todos = append(todos, "choose the Xcode version matching "+runner)
+
+	case strings.Contains(runner, "windows"):
+		machine := newMapping()
+		set(machine, "image", newScalar(windowsImage))
+		set(machine, "shell", newScalar("powershell.exe -ExecutionPolicy Bypass"))
+		set(definition, "machine", machine)
+		set(definition, "resource_class", newScalar("windows.medium"))
+		name = "windows"
+
+	default:
+		machine := newMapping()
+		set(machine, "image", newScalar(machineImage))
+		set(definition, "machine", machine)
+		name = "ubuntu"
+		if runner != "unknown" && !strings.HasPrefix(runner, "ubuntu") {
+			todos = append(todos, "runs-on "+runner+" was mapped to a Linux machine executor")
+		}
+	}
+
+	encoded, _ := yaml.Marshal(definition)
+	if existing, ok := c.executorNames[string(encoded)]; ok {
+		name = existing
+	} else {
+		taken := make(map[string]bool)
+		for _, existing := range c.executorNames {
+			taken[existing] = true
+		}
+		name = identifier(name, taken)
+		c.executorNames[string(encoded)] = name
+		set(c.executors, name, definition)
+	}
+
+	key := set(jobNode, "executor", newScalar(name))
+	for _, todo := range todos {
+		c.todo(key, "%s", todo)
+	}
+}
+
+// jobContainer reads the image and environment of a job container
+func jobContainer(container interface{}) (string, map[string]interface{}) {
+	switch value := container.(type) {
+	case string:
+		return value, nil
+	case map[string]interface{}:
+		image, _ := value["image"].(string)
+		env, _ := value["env"].(map[string]interface{})
+		return image, env
+	}
+	return "", nil
+}
+
+// environment converts env values for a CircleCI environment: map. CircleCI
+// does not interpolate these values, so secrets already provided as project
+// variables are dropped and other expressions are flagged.
+func (c *gitHubActionsConverter) environment(key *yaml.Node, env map[string]string) *yaml.Node {
+	values := make(map[string]interface{})
+	var provided []string
+	for _, envName := range sortedKeys(env) {
+		value := env[envName]
+		if expressions := githubactions.ExtractExpressions(value); len(expressions) == 1 && strings.TrimSpace(value) == "${{ "+expressions[0]+" }}" {
+			if expressions[0] == "secrets."+envName {
+				provided = append(provided, envName)
+				continue
+			}
+		}
+		translated := c.translate(key, value)
+		if strings.Contains(translated, "${") {
+			c.todo(key, "%s is computed from an expression; export it in a run step because environment values are not interpolated", envName)
+		}
+		values[envName] = translated
+	}
+
+	if len(provided) > 0 {
+		c.todo(key, "set %s as project environment variables or in a context", strings.Join(provided, ", "))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return newValueMap(values)
+}
+
+// translate rewrites ${{ }} expressions as CircleCI parameters or
+// environment variables, flagging the ones without an equivalent
+func (c *gitHubActionsConverter) translate(node *yaml.Node, text string) string {
+	return expressionRegex.ReplaceAllStringFunc(text, func(match string) string {
+		expr := expressionRegex.FindStringSubmatch(match)[1]
+		switch {
+		case strings.HasPrefix(expr, "matrix.") && c.params[strings.TrimPrefix(expr, "matrix.")]:
+			return "<< parameters." + strings.TrimPrefix(expr, "matrix.") + " >>"
+		case expr == "secrets.GITHUB_TOKEN" || expr == "github.token":
+			c.todo(node, "CircleCI does not provide GITHUB_TOKEN; store a token in a context")
+			return "${GITHUB_TOKEN}"
+		case strings.HasPrefix(expr, "secrets."):
+			return "${" + strings.TrimPrefix(expr, "secrets.") + "}"
+		case strings.HasPrefix(expr, "env."):
+			return "${" + strings.TrimPrefix(expr, "env.") + "}"
+		}
+		if variable, ok := githubVariables[expr]; ok {
+			return variable
+		}
+		c.todo(node, "expression %s has no CircleCI equivalent", match)
+		return match
+	})
+}
+
+// convertSteps converts a job's effective steps
+func (c *gitHubActionsConverter) convertSteps(key *yaml.Node, steps []githubactions.ExpandedStep, user string) *yaml.Node {
+	seq := newSequence()
+	var post []*yaml.Node
+
+	for _, expanded := range steps {
+		step := expanded.Step
+		if step.Uses != "" {
+			converted, deferred := c.convertAction(step, user)
+			seq.Content = append(seq.Content, converted...)
+			post = append(post, deferred...)
+			continue
+		}
+		if step.Run != "" {
+			seq.Content = append(seq.Content, c.convertRun(step))
+		}
+	}
+
+	seq.Content = append(seq.Content, post...)
+	if len(seq.Content) == 0 {
+		step := newMapping()
+		set(step, "run", newScalar("echo \"No steps\""))
+		seq.Content = append(seq.Content, step)
+	}
+	return seq
+}
+
+// convertRun converts a run step
+func (c *gitHubActionsConverter) convertRun(step githubactions.Step) *yaml.Node {
+	run := newMapping()
+	wrapper := newMapping()
+	set(wrapper, "run", run)
+
+	if step.Name != "" {
+		set(run, "name", newScalar(c.translate(wrapper, step.Name)))
+	}
+	if step.WorkingDirectory != "" {
+		set(run, "working_directory", newScalar(c.translate(wrapper, step.WorkingDirectory)))
+	}
+	if step.Shell != "" {
+		set(run, "shell", newScalar(step.Shell))
+	}
+	if environment := c.environment(wrapper, step.Env); environment != nil {
+		set(run, "environment", environment)
+	}
+	c.stepCondition(wrapper, run, step)
+	set(run, "command", newScalar(trimLines(c.translate(wrapper, step.Run))))
+
+	if step.ContinueOnError {
+		c.todo(wrapper, "continue-on-error has no equivalent; append || true to the command if failures are acceptable")
+	}
+	if step.TimeoutMinutes > 0 {
+		set(run, "no_output_timeout", newScalar(fmt.Sprintf("%dm", step.TimeoutMinutes)))
+	}
+	if strings.Contains(step.Run, "GITHUB_OUTPUT") || strings.Contains(step.Run, "GITHUB_ENV") || strings.Contains(step.Run, "GITHUB_STEP_SUMMARY") {
+		c.todo(wrapper, "$GITHUB_OUTPUT, $GITHUB_ENV and $GITHUB_STEP_SUMMARY do not exist; share values through $BASH_ENV")
+	}
+	return wrapper
+}
+
+// stepCondition maps status-function conditions to when:
+func (c *gitHubActionsConverter) stepCondition(wrapper, run *yaml.Node, step githubactions.Step) {
+	switch strings.ReplaceAll(githubactions.ConditionExpression(step.If), " ", "") {
+	case "", "success()":
+	case "always()", "!cancelled()":
+		set(run, "when", newScalar("always"))
+	case "failure()":
+		set(run, "when", newScalar("on_fail"))
+	default:
+		c.todo(wrapper, "if: %s was not converted; the step runs when earlier steps succeed", step.If)
+	}
+}
+
+// actionName returns the lowercase owner/repo[/path] of a uses: reference
+func actionName(uses string) string {
+	name, _, _ := strings.Cut(uses, "@")
+	return strings.ToLower(name)
+}
+
+// convertAction maps an action to native steps. Steps GitHub runs as post
+// steps, such as saving a cache, are returned separately.
+func (c *gitHubActionsConverter) convertAction(step githubactions.Step, user string) (converted, post []*yaml.Node) {
+	// Orb parameters are not shell-expanded, so literal env values are inlined
+	with := func(input string) string {
+		value, ok := step.With[input]
+		if !ok || value == nil {
+			return ""
+		}
+		text := fmt.Sprintf("%v", value)
+		if match := envReferenceRegex.FindStringSubmatch(text); match != nil {
+			if literal, ok := c.env[match[1]]; ok && !strings.Contains(literal, "${{") {
+				return literal
+			}
+		}
+		return c.translate(newMapping(), text)
+	}
+	raw := func(input string) string {
+		value, _ := step.With[input].(string)
+		return value
+	}
+
+	switch actionName(step.Uses) {
+	case "actions/checkout":
+		converted = append(converted, newScalar("checkout"))
+		if submodules := with("submodules"); submodules != "" && submodules != "false" {
+			converted = append(converted, c.runStep("Check out submodules", "git submodule update --init --recursive"))
+		}
+		if with("repository") != "" || with("path") != "" {
+			c.todo(converted[0], "checkout of another repository or path must be done with git commands")
+		}
+
+	case "actions/setup-node":
+		c.orbs["node"] = nativeOrbs["node"]
+		install := newMapping()
+		if version := with("node-version"); version != "" {
+			set(install, "node-version", newScalar(version))
+		}
+		node := newMapping()
+		set(node, "node/install", install)
+		if with("node-version-file") != "" {
+			c.todo(node, "node-version-file is not supported; pass node-version")
+		}
+		if with("cache") != "" {
+			c.todo(node, "replace the dependency install step with node/install-packages to cache dependencies")
+		}
+		converted = append(converted, node)
+
+	case "actions/setup-go":
+		c.orbs["go"] = nativeOrbs["go"]
+		install := newMapping()
+		if version := with("go-version"); version != "" {
+			set(install, "version", newScalar(version))
+		}
+		node := newMapping()
+		set(node, "go/install", install)
+		converted = append(converted, node)
+
+	case "actions/cache", "actions/cache/restore", "actions/cache/save":
+		cacheNode := newMapping()
+		key := c.cacheKeyTemplate(cacheNode, raw("key"))
+		paths := splitLines(with("path"))
+
+		if actionName(step.Uses) != "actions/cache/save" {
+			restore := newMapping()
+			if step.Name != "" {
+				set(restore, "name", newScalar(step.Name))
+			}
+			keys := []string{key}
+			for _, restoreKey := range splitLines(raw("restore-keys")) {
+				keys = append(keys, c.cacheKeyTemplate(cacheNode, restoreKey))
+			}
+			set(restore, "keys", newStrings(keys))
+			set(cacheNode, "restore_cache", restore)
+			converted = append(converted, cacheNode)
+		}
+		if actionName(step.Uses) != "actions/cache/restore" {
+			save := newMapping()
+			set(save, "key", newScalar(key))
+			set(save, "paths", newStrings(paths))
+			saveNode := newMapping()
+			set(saveNode, "save_cache", save)
+			if actionName(step.Uses) == "actions/cache/save" {
+				saveNode.HeadComment = cacheNode.HeadComment
+				converted = append(converted, saveNode)
+			} else {
+				post = append(post, saveNode)
+			}
+		}
+
+	case "actions/upload-artifact":
+		paths := splitLines(with("path"))
+		name := with("name")
+		for _, artifactPath := range paths {
+			store := newMapping()
+			set(store, "path", newScalar(artifactPath))
+			if len(paths) == 1 && name != "" {
+				set(store, "destination", newScalar(name))
+			}
+			node := newMapping()
+			set(node, "store_artifacts", store)
+			converted = append(converted, node)
+		}
+		if c.downloaded["*"] || c.downloaded[name] {
+			persist := newMapping()
+			set(persist, "root", newScalar("."))
+			set(persist, "paths", newStrings(paths))
+			node := newMapping()
+			set(node, "persist_to_workspace", persist)
+			converted = append(converted, node)
+		}
+
+	case "actions/download-artifact":
+		at := with("path")
+		if at == "" {
+			at = "."
+		}
+		attach := newMapping()
+		set(attach, "at", newScalar(at))
+		node := newMapping()
+		set(node, "attach_workspace", attach)
+		converted = append(converted, node)
+
+	default:
+		placeholder := c.runStep(step.Name, fmt.Sprintf("echo \"TODO: replace %s\"", step.Uses))
+		if step.Name == "" {
+			placeholder = c.runStep(step.Uses, fmt.Sprintf("echo \"TODO: replace %s\"", step.Uses))
+		}
+		if len(step.With) > 0 {
+			c.todo(placeholder, "action %s has no CircleCI equivalent (with: %s)", step.Uses, describeArgs(step.With))
+		} else {
+			c.todo(placeholder, "action %s has no CircleCI equivalent", step.Uses)
+		}
+		if !shared.ContainsString(c.unknown[step.Uses], user) {
+			c.unknown[step.Uses] = append(c.unknown[step.Uses], user)
+		}
+		converted = append(converted, placeholder)
+	}
+
+	return converted, post
+}
+
+// runStep builds a named run step
+func (c *gitHubActionsConverter) runStep(name, command string) *yaml.Node {
+	run := newMapping()
+	if name != "" {
+		set(run, "name", newScalar(name))
+	}
+	set(run, "command", newScalar(command))
+	node := newMapping()
+	set(node, "run", run)
+	return node
+}
+
+// cacheKeyTemplate converts an actions/cache key into a CircleCI key template
+func (c *gitHubActionsConverter) cacheKeyTemplate(node *yaml.Node, key string) string {
+	key = strings.ReplaceAll(key, "${{ runner.os }}-${{ runner.arch }}", "${{ runner.os }}")
+	return expressionRegex.ReplaceAllStringFunc(key, func(match string) string {
+		expr := expressionRegex.FindStringSubmatch(match)[1]
+		if file := hashFilesRegex.FindStringSubmatch(expr); file != nil {
+			return fmt.Sprintf("{{ checksum %q }}", file[1])
+		}
+		if template, ok := cacheKeyVariables[expr]; ok {
+			return template
+		}
+		switch {
+		case strings.HasPrefix(expr, "matrix.") && c.params[strings.TrimPrefix(expr, "matrix.")]:
+			return "<< parameters." + strings.TrimPrefix(expr, "matrix.") + " >>"
+		case strings.HasPrefix(expr, "env."):
+			return "{{ .Environment." + strings.TrimPrefix(expr, "env.") + " }}"
+		case strings.HasPrefix(expr, "hashFiles("):
+			c.todo(node, "%s hashes several files or a glob; checksum a single lock file instead", match)
+			return match
+		}
+		c.todo(node, "cache key expression %s has no CircleCI equivalent", match)
+		return match
+	})
+}
+
+// splitLines splits a multi-line input into trimmed non-empty lines
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
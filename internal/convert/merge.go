@@ -0,0 +1,296 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/gotask"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"gopkg.in/yaml.v3"
+)
+
+// Conflict is a generated task whose name is already used by a task with
+// different commands. The generated task is added under Renamed.
+type Conflict struct {
+	Name    string    `json:"name"`
+	Renamed string    `json:"renamed"`
+	Jobs    []TaskJob `json:"jobs"`
+}
+
+// MergeTaskfile adds the tasks planned from the CI pipelines to an existing
+// Taskfile. Tasks that already run a job's commands are reused, and only the
+// missing tasks are appended. The yaml.v3 node tree locates the end of the
+// tasks mapping and the new tasks are inserted there, so the rest of the file
+// keeps its comments, ordering and formatting. source is the Taskfile path
+// relative to the repository root.
+func MergeTaskfile(taskfilePath, source string, pipelines []*ir.Pipeline) (*Result, error) {
+	taskfile, err := gotask.ParseTaskfile(taskfilePath)
+	if err != nil {
+		return nil, err
+	}
+	// Tasks of included Taskfiles can be reused under their full names
+	project := gotask.LoadProject(taskfile, taskfilePath)
+	data, err := os.ReadFile(taskfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read taskfile: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse taskfile: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("taskfile %s is not a YAML mapping", source)
+	}
+	root := doc.Content[0]
+	if tasksNode := lookup(root, "tasks"); tasksNode != nil && tasksNode.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("tasks in %s is not a mapping", source)
+	}
+
+	g := newTaskfileGenerator()
+	g.file = source
+	g.plan(pipelines)
+	if len(g.tasks) == 0 {
+		return nil, fmt.Errorf("no CI jobs with run steps to generate tasks from (jobs that already call task are skipped)")
+	}
+
+	// Generated dirs are relative to the repository root, task dirs to the Taskfile
+	base := path.Dir(source)
+	for _, task := range g.tasks {
+		task.Dir = relativeDir(base, task.Dir)
+	}
+
+	taken := make(map[string]bool)
+	for name := range project.Tasks {
+		taken[name] = true
+	}
+	for name := range g.names {
+		taken[name] = true
+	}
+	renamed := make(map[string]string)
+	var added []*Task
+	for _, task := range g.tasks {
+		original := task.Name
+		if name := equivalentTask(project, task); name != "" {
+			task.Name = name
+			task.Existing = true
+		} else if _, exists := project.Tasks[task.Name]; exists {
+			task.Name = uniqueTaskName(task.Name+"-ci", taken)
+			g.result.Conflicts = append(g.result.Conflicts, Conflict{Name: original, Renamed: task.Name, Jobs: task.Jobs})
+		}
+		renamed[original] = task.Name
+		if !task.Existing {
+			added = append(added, task)
+		}
+	}
+
+	newTasks := newMapping()
+	for _, task := range added {
+		for i, dep := range task.Deps {
+			if name, ok := renamed[dep]; ok {
+				task.Deps[i] = name
+			}
+		}
+		key := set(newTasks, task.Name, taskNode(task))
+		if task == added[0] {
+			addComment(key, "Added by pipeline-analyzer migrate from the CI configuration")
+		}
+		for _, message := range task.Todos {
+			addComment(key, "TODO: "+message)
+			g.result.Notes = append(g.result.Notes, Note{File: source, Job: task.Name, Message: message})
+		}
+	}
+	for _, conflict := range g.result.Conflicts {
+		g.result.Notes = append(g.result.Notes, Note{
+			File:    source,
+			Job:     conflict.Renamed,
+			Message: fmt.Sprintf("task %s already exists with different commands; the CI version was added as %s", conflict.Name, conflict.Renamed),
+		})
+	}
+
+	content := data
+	if len(newTasks.Content) > 0 {
+		if content, err = insertTasks(data, &doc, newTasks); err != nil {
+			return nil, err
+		}
+	}
+
+	g.result.Files = append(g.result.Files, File{Path: source, Content: content})
+	g.result.Tasks = g.tasks
+	return g.result, nil
+}
+
+// equivalentTask returns the full name of an existing task, in the Taskfile
+// or the ones it includes, that CI can call and that runs the same commands
+// in the same directory, or ""
+func equivalentTask(project *gotask.Project, task *Task) string {
+	var want []string
+	for _, cmd := range task.Cmds {
+		want = append(want, commandLines(escapeTemplate(cmd))...)
+	}
+
+	for _, name := range project.TaskNames() {
+		projectTask := project.Tasks[name]
+		if projectTask.Internal {
+			continue
+		}
+		existing := projectTask.Task
+		dir := existing.Dir
+		if projectTask.Dir != "" && !path.IsAbs(dir) {
+			dir = path.Join(filepath.ToSlash(projectTask.Dir), dir)
+		}
+		if relativeDir(".", dir) != task.Dir {
+			continue
+		}
+
+		var have []string
+		if existing.Cmd != "" {
+			have = commandLines(existing.Cmd)
+		}
+		for _, cmd := range existing.Cmds {
+			switch value := cmd.(type) {
+			case string:
+				have = append(have, commandLines(value)...)
+			case map[string]interface{}:
+				if text, ok := value["cmd"].(string); ok {
+					have = append(have, commandLines(text)...)
+				} else {
+					have = append(have, fmt.Sprintf("task: %v", value["task"]))
+				}
+			}
+		}
+		if len(have) > 0 && sameStrings(have, want) {
+			return name
+		}
+	}
+	return ""
+}
+
+// commandLines splits a script into normalized command lines
+func commandLines(script string) []string {
+	var lines []string
+	for _, command := range ir.SplitCommands(script) {
+		lines = append(lines, command.Raw)
+	}
+	return lines
+}
+
+// relativeDir makes a repository-relative dir relative to base
+func relativeDir(base, dir string) string {
+	if path.IsAbs(dir) {
+		return dir
+	}
+	dir = path.Clean(dir)
+	base = path.Clean(base)
+
+	// Walk up from base until dir is below it
+	up := ""
+	for base != "." && dir != base && !strings.HasPrefix(dir, base+"/") {
+		up += "../"
+		base = path.Dir(base)
+	}
+	if base != "." {
+		dir = strings.TrimPrefix(strings.TrimPrefix(dir, base), "/")
+	}
+
+	result := path.Clean(up + dir)
+	if result == "." {
+		return ""
+	}
+	return result
+}
+
+// uniqueTaskName returns name, numbered when it is already taken
+func uniqueTaskName(name string, taken map[string]bool) string {
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// insertTasks adds task entries at the end of the tasks mapping. A block
+// mapping is extended in place; otherwise the node tree is re-encoded.
+func insertTasks(data []byte, doc *yaml.Node, newTasks *yaml.Node) ([]byte, error) {
+	root := doc.Content[0]
+	var tasksKey, tasksNode, nextKey *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "tasks" {
+			tasksKey, tasksNode = root.Content[i], root.Content[i+1]
+			if i+2 < len(root.Content) {
+				nextKey = root.Content[i+2]
+			}
+		}
+	}
+
+	if tasksNode != nil && (tasksNode.Style&yaml.FlowStyle != 0 || len(tasksNode.Content) == 0) {
+		tasksNode.Style = 0
+		tasksNode.Content = append(tasksNode.Content, newTasks.Content...)
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode taskfile: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode taskfile: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	// Match the indentation of the existing tasks
+	indent := 2
+	if tasksNode != nil {
+		indent = tasksNode.Content[0].Column - 1
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(newTasks); err != nil {
+		return nil, fmt.Errorf("failed to encode tasks: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode tasks: %w", err)
+	}
+	var block []string
+	if tasksKey == nil {
+		block = append(block, "tasks:")
+	}
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		if line != "" {
+			line = strings.Repeat(" ", indent) + line
+		}
+		block = append(block, line)
+	}
+
+	// Insert before the next top-level key and its comments, or at the end
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	at := len(lines)
+	if tasksKey != nil && nextKey != nil {
+		at = nextKey.Line - 1
+		if nextKey.HeadComment != "" {
+			at -= strings.Count(nextKey.HeadComment, "\n") + 1
+		}
+	}
+	for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+
+	var out []string
+	out = append(out, lines[:at]...)
+	out = append(out, "")
+	out = append(out, block...)
+	if at < len(lines) {
+		out = append(out, "")
+		for at < len(lines) && strings.TrimSpace(lines[at]) == "" {
+			at++
+		}
+		out = append(out, lines[at:]...)
+	}
+	return []byte(strings.Join(out, "\n") + "\n"), nil
+}
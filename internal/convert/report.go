@@ -0,0 +1,73 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatReport renders a conversion report listing the generated files, the
+// actions that have no equivalent and every TODO left in the output
+func FormatReport(result *Result) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Conversion Report\n\n")
+	fmt.Fprintf(&sb, "Converted **%s** to **%s**.\n\n", result.From, result.To)
+
+	sb.WriteString("## 📄 Generated Files\n\n")
+	for _, file := range result.Files {
+		fmt.Fprintf(&sb, "- `%s`\n", file.Path)
+	}
+	sb.WriteString("\n")
+
+	if len(result.UnknownActions) > 0 {
+		sb.WriteString("## ❓ Unknown Actions\n\n")
+		sb.WriteString("These actions have no native equivalent and were replaced by placeholder steps.\n\n")
+		sb.WriteString("| Action | Used By |\n")
+		sb.WriteString("|--------|---------|\n")
+		for _, action := range result.UnknownActions {
+			fmt.Fprintf(&sb, "| `%s` | %s |\n", action.Uses, strings.Join(action.UsedBy, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(result.Conflicts) > 0 {
+		sb.WriteString("## ⚔️ Task Name Conflicts\n\n")
+		sb.WriteString("| Task | Added As | Jobs |\n")
+		sb.WriteString("|------|----------|------|\n")
+		for _, conflict := range result.Conflicts {
+			var jobs []string
+			for _, job := range conflict.Jobs {
+				jobs = append(jobs, fmt.Sprintf("%s (%s)", job.Job, job.Source))
+			}
+			fmt.Fprintf(&sb, "| `%s` | `%s` | %s |\n", conflict.Name, conflict.Renamed, strings.Join(jobs, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(result.Rewrites) > 0 {
+		sb.WriteString("## 🔁 Rewritten CI Jobs\n\n")
+		sb.WriteString("| File | Job | Task |\n")
+		sb.WriteString("|------|-----|------|\n")
+		for _, rewrite := range result.Rewrites {
+			fmt.Fprintf(&sb, "| `%s` | %s | `%s` |\n", rewrite.File, rewrite.Job, rewrite.Task)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## 📝 TODO Items\n\n")
+	if len(result.Notes) == 0 {
+		sb.WriteString("Everything was converted automatically.\n")
+		return sb.String()
+	}
+	sb.WriteString("| File | Job | Item |\n")
+	sb.WriteString("|------|-----|------|\n")
+	for _, note := range result.Notes {
+		job := note.Job
+		if job == "" {
+			job = "-"
+		}
+		fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", note.File, job, strings.ReplaceAll(note.Message, "|", "\\|"))
+	}
+
+	return sb.String()
+}
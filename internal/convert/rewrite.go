@@ -0,0 +1,446 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// Steps that only publish what earlier steps produced. They may sit between
+// run steps and are moved after the task call.
+var (
+	circleOutputSteps   = []string{"save_cache", "store_artifacts", "store_test_results", "persist_to_workspace"}
+	githubOutputActions = []string{"actions/upload-artifact", "actions/cache/save"}
+)
+
+// Run step settings that cannot be carried over to a single task call
+var (
+	circleRunOptions = []string{"when", "background", "no_output_timeout"}
+	githubRunOptions = []string{"if", "id", "continue-on-error", "timeout-minutes"}
+)
+
+// Rewrite is a CI job whose run steps were replaced by a task call
+type Rewrite struct {
+	File string `json:"file"`
+	Job  string `json:"job"`
+	Task string `json:"task"`
+}
+
+// ciRewriter carries the state of rewriting one CI configuration file
+type ciRewriter struct {
+	tool     string
+	taskfile string // Relative to the repository root
+	lines    []string
+	workflow *yaml.Node // Root mapping of the file
+}
+
+// ciStep is a step of a CI job as written in the file
+type ciStep struct {
+	node     *yaml.Node
+	kind     string     // "run", a CircleCI step type or an action without its version
+	settings *yaml.Node // Run step settings, nil for shorthand run steps
+	script   string
+}
+
+// lineEdit replaces lines [start, end) of a file
+type lineEdit struct {
+	start, end int
+	lines      []string
+}
+
+// RewriteCI replaces the run steps of every CI job a task was planned from
+// with a call to that task. Files are edited at the lines the yaml.v3 node
+// tree locates, so comments and formatting outside the replaced steps are
+// kept. Jobs that cannot be rewritten safely are left alone with a note.
+// taskfile is the path of the Taskfile relative to the repository root.
+func RewriteCI(root, taskfile string, tasks []*Task) (*Result, error) {
+	result := &Result{To: ir.ToolGoTask}
+
+	// Source file → job ID → task
+	jobTasks := make(map[string]map[string]*Task)
+	tools := make(map[string]string)
+	for _, task := range tasks {
+		for _, job := range task.Jobs {
+			if jobTasks[job.Source] == nil {
+				jobTasks[job.Source] = make(map[string]*Task)
+			}
+			jobTasks[job.Source][job.Job] = task
+			tools[job.Source] = job.Tool
+		}
+	}
+
+	for _, source := range sortedKeys(jobTasks) {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(source)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a YAML mapping", source)
+		}
+
+		r := &ciRewriter{
+			tool:     tools[source],
+			taskfile: taskfile,
+			lines:    strings.Split(string(data), "\n"),
+			workflow: doc.Content[0],
+		}
+		jobs := lookup(r.workflow, "jobs")
+
+		var edits []lineEdit
+		for _, jobID := range sortedKeys(jobTasks[source]) {
+			task := jobTasks[source][jobID]
+			edit, err := r.rewriteJob(jobs, jobID, task)
+			if err != nil {
+				result.Notes = append(result.Notes, Note{File: source, Job: jobID, Message: "not rewritten: " + err.Error()})
+				continue
+			}
+			edits = append(edits, *edit)
+			result.Rewrites = append(result.Rewrites, Rewrite{File: source, Job: jobID, Task: task.Name})
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		// Later edits first so earlier line numbers stay valid
+		sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+		lines := r.lines
+		for _, edit := range edits {
+			lines = append(lines[:edit.start], append(edit.lines, lines[edit.end:]...)...)
+		}
+		content := []byte(strings.Join(lines, "\n"))
+		if err := yaml.Unmarshal(content, &yaml.Node{}); err != nil {
+			return nil, fmt.Errorf("rewritten %s is not valid YAML: %w", source, err)
+		}
+
+		result.Files = append(result.Files, File{Path: source, Content: content})
+		shared.GetLogger().Debug("convert", "Rewrote CI jobs to call tasks", map[string]interface{}{
+			"file": source,
+			"jobs": len(edits),
+		})
+	}
+
+	return result, nil
+}
+
+// rewriteJob replaces the run steps of a job with a task call. The error
+// explains why a job is left unchanged.
+func (r *ciRewriter) rewriteJob(jobs *yaml.Node, jobID string, task *Task) (*lineEdit, error) {
+	if len(task.Todos) > 0 {
+		return nil, fmt.Errorf("task %s has TODO items; resolve them and run migrate again", task.Name)
+	}
+	job := lookup(jobs, jobID)
+	if job == nil || job.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the job is not defined in this file")
+	}
+	if r.tool == ir.ToolCircleCI && lookup(job, "parameters") != nil {
+		return nil, fmt.Errorf("the job takes parameters")
+	}
+	stepsNode := lookup(job, "steps")
+	if stepsNode == nil || stepsNode.Kind != yaml.SequenceNode || stepsNode.Style&yaml.FlowStyle != 0 {
+		return nil, fmt.Errorf("the job has no block sequence of steps")
+	}
+
+	jobDir, err := r.jobDir(job)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []ciStep
+	first, last, runs := -1, -1, 0
+	installed := false
+	for i, node := range stepsNode.Content {
+		step := r.parseStep(node)
+		steps = append(steps, step)
+		if step.kind == "run" {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			runs++
+			if strings.Contains(step.script, ir.GoTaskInstallScript) {
+				installed = true
+			}
+		}
+		if step.kind == "arduino/setup-task" {
+			installed = true
+		}
+	}
+	if runs != len(task.Cmds) {
+		return nil, fmt.Errorf("the job has %d run steps in the file but %d in task %s; steps from reusable commands or actions must be rewritten by hand", runs, len(task.Cmds), task.Name)
+	}
+
+	// Outputs between the run steps move after the task call, anything else
+	// between them would change what the commands see
+	env := make(map[string]string)
+	var moved []ciStep
+	for _, step := range steps[first : last+1] {
+		if step.kind == "run" {
+			if err := r.checkRunStep(step, env); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !r.isOutput(step) {
+			return nil, fmt.Errorf("%s runs between the run steps", step.kind)
+		}
+		moved = append(moved, step)
+	}
+
+	start, _, dash, ok := r.stepSpan(stepsNode.Content[first])
+	if !ok {
+		return nil, fmt.Errorf("the steps are not written one per list item")
+	}
+	_, end, _, _ := r.stepSpan(stepsNode.Content[last])
+
+	var newSteps []*yaml.Node
+	if !installed {
+		newSteps = append(newSteps, r.installStep())
+	}
+	newSteps = append(newSteps, r.taskStep(r.invocation(task.Name, jobDir), env))
+	block, err := encodeSteps(newSteps, dash)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range moved {
+		from, to, _, ok := r.stepSpan(step.node)
+		if !ok {
+			return nil, fmt.Errorf("the steps are not written one per list item")
+		}
+		block = append(block, r.lines[from:to]...)
+	}
+
+	return &lineEdit{start: start, end: end, lines: block}, nil
+}
+
+// parseStep classifies a step as written in the file
+func (r *ciRewriter) parseStep(node *yaml.Node) ciStep {
+	step := ciStep{node: node}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch {
+	case node.Kind == yaml.ScalarNode:
+		step.kind = node.Value
+
+	case r.tool == ir.ToolCircleCI && node.Kind == yaml.MappingNode && len(node.Content) == 2:
+		step.kind = node.Content[0].Value
+		value := node.Content[1]
+		if step.kind == "run" {
+			if value.Kind == yaml.MappingNode {
+				step.settings = value
+				if command := lookup(value, "command"); command != nil {
+					step.script = command.Value
+				}
+			} else {
+				step.script = value.Value
+			}
+		}
+
+	case r.tool == ir.ToolGitHubActions && node.Kind == yaml.MappingNode:
+		if run := lookup(node, "run"); run != nil {
+			step.kind = "run"
+			step.settings = node
+			step.script = run.Value
+		} else if uses := lookup(node, "uses"); uses != nil {
+			step.kind = strings.SplitN(uses.Value, "@", 2)[0]
+		}
+	}
+
+	if step.kind == "" {
+		step.kind = "an unrecognized step"
+	}
+	return step
+}
+
+// checkRunStep reports settings of a run step that a task call would lose,
+// and collects the step env the task still needs from CI
+func (r *ciRewriter) checkRunStep(step ciStep, env map[string]string) error {
+	options := circleRunOptions
+	if r.tool == ir.ToolGitHubActions {
+		options = githubRunOptions
+	}
+	for _, option := range options {
+		if lookup(step.settings, option) != nil {
+			return fmt.Errorf("a run step sets %s", option)
+		}
+	}
+	if shell := lookup(step.settings, "shell"); shell != nil && !posixShell(shell.Value) {
+		return fmt.Errorf("a run step uses the %s shell", shell.Value)
+	}
+	if r.tool == ir.ToolCircleCI && strings.Contains(step.script, "<<") {
+		return fmt.Errorf("a run step uses pipeline parameters")
+	}
+	if r.tool != ir.ToolGitHubActions {
+		return nil
+	}
+
+	// Secrets are read from the environment by the task, CI still provides them
+	carry := make(map[string]string)
+	for _, match := range secretExpressionRegex.FindAllStringSubmatch(step.script, -1) {
+		if strings.Contains(match[0], "secrets.") {
+			carry[match[1]] = "${{ secrets." + match[1] + " }}"
+		}
+	}
+	if stepEnv := lookup(step.settings, "env"); stepEnv != nil && stepEnv.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(stepEnv.Content); i += 2 {
+			if value := stepEnv.Content[i+1].Value; strings.Contains(value, "${{") {
+				carry[stepEnv.Content[i].Value] = value
+			}
+		}
+	}
+	for name, value := range carry {
+		if existing, ok := env[name]; ok && existing != value {
+			return fmt.Errorf("run steps set %s to different values", name)
+		}
+		env[name] = value
+	}
+	return nil
+}
+
+// jobDir returns the directory run steps of a job start in, relative to the
+// repository root
+func (r *ciRewriter) jobDir(job *yaml.Node) (string, error) {
+	var dir string
+	if r.tool == ir.ToolCircleCI {
+		if node := lookup(job, "working_directory"); node != nil {
+			dir = taskDir("", node.Value)
+		}
+	} else {
+		// Job defaults override workflow defaults
+		for _, defaults := range []*yaml.Node{lookup(r.workflow, "defaults"), lookup(job, "defaults")} {
+			run := lookup(defaults, "run")
+			if run == nil {
+				continue
+			}
+			if shell := lookup(run, "shell"); shell != nil && !posixShell(shell.Value) {
+				return "", fmt.Errorf("run steps default to the %s shell", shell.Value)
+			}
+			if node := lookup(run, "working-directory"); node != nil {
+				dir = taskDir("", node.Value)
+			}
+		}
+	}
+
+	if path.IsAbs(dir) || strings.HasPrefix(dir, "~") || strings.HasPrefix(dir, "..") || strings.Contains(dir, "${{") {
+		return "", fmt.Errorf("run steps start outside the checkout (%s)", dir)
+	}
+	return dir, nil
+}
+
+// isOutput reports whether a step only publishes results of earlier steps
+func (r *ciRewriter) isOutput(step ciStep) bool {
+	if r.tool == ir.ToolCircleCI {
+		return shared.ContainsString(circleOutputSteps, step.kind)
+	}
+	return shared.ContainsString(githubOutputActions, step.kind)
+}
+
+// stepSpan returns the lines [start, end) of a sequence item and the column
+// of its dash. Comments indented below the dash belong to the item.
+func (r *ciRewriter) stepSpan(node *yaml.Node) (int, int, int, bool) {
+	start := node.Line - 1
+	if start < 0 || start >= len(r.lines) {
+		return 0, 0, 0, false
+	}
+	line := r.lines[start]
+	column := min(node.Column-1, len(line))
+	dash := strings.LastIndex(line[:column], "-")
+	if dash < 0 || strings.TrimSpace(line[:dash]) != "" {
+		return 0, 0, 0, false
+	}
+
+	end := start + 1
+	for end < len(r.lines) {
+		text := r.lines[end]
+		trimmed := strings.TrimSpace(text)
+		if trimmed != "" && len(text)-len(strings.TrimLeft(text, " \t")) <= dash {
+			break
+		}
+		end++
+	}
+	for end > start+1 && strings.TrimSpace(r.lines[end-1]) == "" {
+		end--
+	}
+	return start, end, dash, true
+}
+
+// invocation returns the command running a task from a job directory
+func (r *ciRewriter) invocation(name, jobDir string) string {
+	if jobDir == "" && (r.taskfile == "Taskfile.yml" || r.taskfile == "Taskfile.yaml") {
+		return "task " + quoteShell(name)
+	}
+	return fmt.Sprintf("task --taskfile %s %s", quoteShell(relativeDir(jobDir, r.taskfile)), quoteShell(name))
+}
+
+// installStep returns a step installing go-task
+func (r *ciRewriter) installStep() *yaml.Node {
+	step := newMapping()
+	if r.tool == ir.ToolCircleCI {
+		run := newMapping()
+		set(run, "name", newScalar("Install go-task"))
+		set(run, "command", newScalar(strings.Join([]string{
+			`sh -c "$(curl --location https://` + ir.GoTaskInstallScript + `)" -- -d -b ~/.local/bin`,
+			`echo 'export PATH="$HOME/.local/bin:$PATH"' >> "$BASH_ENV"`,
+		}, "\n")))
+		set(step, "run", run)
+		return step
+	}
+
+	set(step, "name", newScalar("Install go-task"))
+	set(step, "uses", newScalar("arduino/setup-task@v2"))
+	with := newMapping()
+	set(with, "version", newScalar("3.x"))
+	set(step, "with", with)
+	return step
+}
+
+// taskStep returns a run step calling a task
+func (r *ciRewriter) taskStep(command string, env map[string]string) *yaml.Node {
+	step := newMapping()
+	set(step, "run", newScalar(command))
+	if len(env) > 0 {
+		values := newMapping()
+		for _, name := range sortedKeys(env) {
+			set(values, name, newScalar(env[name]))
+		}
+		set(step, "env", values)
+	}
+	return step
+}
+
+// encodeSteps renders steps as sequence items with their dash at a column
+func encodeSteps(steps []*yaml.Node, dash int) ([]string, error) {
+	var sb strings.Builder
+	encoder := yaml.NewEncoder(&sb)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(newSequence(steps...)); err != nil {
+		return nil, fmt.Errorf("failed to encode steps: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode steps: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(sb.String(), "\n"), "\n") {
+		lines = append(lines, strings.Repeat(" ", dash)+line)
+	}
+	return lines, nil
+}
+
+// posixShell reports whether a CI shell setting runs scripts like go-task does
+func posixShell(shell string) bool {
+	program := strings.Fields(shell)
+	return len(program) > 0 && (program[0] == "bash" || program[0] == "sh" || strings.HasSuffix(program[0], "/bash") || strings.HasSuffix(program[0], "/sh"))
+}
@@ -0,0 +1,411 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// TaskfileName is the file generated tasks are written to
+const TaskfileName = "Taskfile.generated.yml"
+
+// localExpressions maps github.* expressions to shell commands giving the same
+// value in a local checkout
+var localExpressions = map[string]string{
+	"github.sha":       "$(git rev-parse HEAD)",
+	"github.ref_name":  "$(git rev-parse --abbrev-ref HEAD)",
+	"github.workspace": "$(git rev-parse --show-toplevel)",
+}
+
+var (
+	secretExpressionRegex = regexp.MustCompile(`\$\{\{\s*(?:env|secrets)\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	taskNameRegex         = regexp.MustCompile(`[^A-Za-z0-9_:-]+`)
+)
+
+// Task is a go-task task generated from the run steps of a CI job. Values are
+// plain shell text; template escaping is applied when the task is rendered.
+type Task struct {
+	Name  string
+	Desc  string
+	Dir   string // Relative to the repository root, empty for the root
+	Env   []ir.Env
+	Deps  []string
+	Cmds  []string  // One shell script per run step
+	Jobs  []TaskJob // CI jobs whose run steps the task replaces
+	Todos []string  // Manual fixes needed before the task runs outside CI
+
+	Existing bool // Reuses a task already in the Taskfile
+}
+
+// TaskJob identifies a CI job a generated task was derived from
+type TaskJob struct {
+	Tool   string `json:"tool"`
+	Source string `json:"source"`
+	Job    string `json:"job"`
+}
+
+// taskfileGenerator carries the state of a Taskfile generation
+type taskfileGenerator struct {
+	notes
+	tasks []*Task
+	names map[string]bool
+}
+
+// PlanTasks derives one task per CI job with run steps. Jobs whose steps are
+// identical share a task, and job dependencies become deps.
+func PlanTasks(pipelines []*ir.Pipeline) []*Task {
+	g := newTaskfileGenerator()
+	g.plan(pipelines)
+	return g.tasks
+}
+
+// GenerateTaskfile renders the tasks planned from the CI pipelines as a
+// Taskfile that go-task can read directly
+func GenerateTaskfile(pipelines []*ir.Pipeline) (*Result, error) {
+	g := newTaskfileGenerator()
+	g.plan(pipelines)
+	if len(g.tasks) == 0 {
+		return nil, fmt.Errorf("no CI jobs with run steps to generate tasks from (jobs that already call task are skipped)")
+	}
+
+	root := newMapping()
+	set(root, "version", newScalar("3"))
+	tasks := newMapping()
+	for _, task := range g.tasks {
+		key := set(tasks, task.Name, taskNode(task))
+		for _, message := range task.Todos {
+			addComment(key, "TODO: "+message)
+			g.result.Notes = append(g.result.Notes, Note{File: TaskfileName, Job: task.Name, Message: message})
+		}
+	}
+	set(root, "tasks", tasks)
+
+	var sources []string
+	for _, pipeline := range pipelines {
+		if pipeline.Tool != ir.ToolGoTask {
+			sources = appendUnique(sources, pipeline.Source)
+		}
+	}
+	content, err := encodeDocument(root, []string{
+		"Generated by pipeline-analyzer migrate from " + strings.Join(sources, ", ") + ".",
+		"Each task runs the shell steps of one CI job; review every TODO comment.",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Taskfile: %w", err)
+	}
+
+	g.result.Files = append(g.result.Files, File{Path: TaskfileName, Content: content})
+	g.result.Tasks = g.tasks
+	return g.result, nil
+}
+
+// newTaskfileGenerator creates a generator with an empty result
+func newTaskfileGenerator() *taskfileGenerator {
+	return &taskfileGenerator{
+		notes: notes{result: &Result{To: ir.ToolGoTask}, file: TaskfileName},
+		names: make(map[string]bool),
+	}
+}
+
+// taskNode renders a task as a Taskfile mapping
+func taskNode(task *Task) *yaml.Node {
+	node := newMapping()
+	set(node, "desc", newScalar(task.Desc))
+	if task.Dir != "" {
+		set(node, "dir", newScalar(task.Dir))
+	}
+	if len(task.Env) > 0 {
+		env := newMapping()
+		for _, variable := range task.Env {
+			set(env, variable.Name, newScalar(escapeTemplate(variable.Value)))
+		}
+		set(node, "env", env)
+	}
+	if len(task.Deps) > 0 {
+		set(node, "deps", newStrings(task.Deps))
+	}
+	cmds := newSequence()
+	for _, cmd := range task.Cmds {
+		cmds.Content = append(cmds.Content, newScalar(escapeTemplate(cmd)))
+	}
+	set(node, "cmds", cmds)
+	return node
+}
+
+// plan builds the tasks of every CI pipeline
+func (g *taskfileGenerator) plan(pipelines []*ir.Pipeline) {
+	var tools []string
+	for _, pipeline := range pipelines {
+		if pipeline.Tool == ir.ToolGoTask {
+			continue
+		}
+		tools = appendUnique(tools, pipeline.Tool)
+
+		// Job ID → task, missing for jobs without run steps
+		jobTasks := make(map[string]*Task)
+		for _, job := range pipeline.Jobs {
+			if task := g.planJob(pipeline, job); task != nil {
+				jobTasks[job.ID] = task
+			}
+		}
+
+		for _, job := range pipeline.Jobs {
+			task := jobTasks[job.ID]
+			if task == nil {
+				continue
+			}
+			for _, dep := range taskDeps(pipeline, job, jobTasks, make(map[string]bool)) {
+				if dep != task.Name {
+					task.Deps = appendUnique(task.Deps, dep)
+				}
+			}
+		}
+
+		shared.GetLogger().Debug("convert", "Planned tasks for pipeline", map[string]interface{}{
+			"tool":   pipeline.Tool,
+			"source": pipeline.Source,
+			"jobs":   len(pipeline.Jobs),
+		})
+	}
+	g.result.From = strings.Join(tools, ", ")
+}
+
+// planJob turns the run steps of a job into a task, reusing an existing task
+// when another job runs exactly the same steps
+func (g *taskfileGenerator) planJob(pipeline *ir.Pipeline, job *ir.Job) *Task {
+	var runs []ir.Step
+	for _, step := range job.Steps {
+		if step.Kind == ir.StepRun && len(step.Commands) > 0 && !callsTask(step) {
+			runs = append(runs, step)
+		}
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+
+	task := &Task{Jobs: []TaskJob{{Tool: pipeline.Tool, Source: pipeline.Source, Job: job.ID}}}
+	todo := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		if !shared.ContainsString(task.Todos, message) {
+			task.Todos = append(task.Todos, message)
+		}
+	}
+
+	// A directory shared by every step becomes the task dir, others cd first
+	dirs := make([]string, len(runs))
+	for i, step := range runs {
+		dirs[i] = taskDir(job.WorkingDir, step.WorkingDir)
+	}
+	task.Dir = dirs[0]
+	for _, dir := range dirs[1:] {
+		if dir != task.Dir {
+			task.Dir = ""
+			break
+		}
+	}
+
+	// Pipeline and job env become task env, step env is exported by the step
+	env := make(map[string]string)
+	for _, variables := range [][]ir.Env{pipeline.Env, job.Env} {
+		for _, variable := range variables {
+			value, ok := g.envValue(variable, todo)
+			if !ok {
+				continue
+			}
+			if _, exists := env[variable.Name]; !exists {
+				task.Env = append(task.Env, ir.Env{Name: variable.Name})
+			}
+			env[variable.Name] = value
+		}
+	}
+	for i := range task.Env {
+		task.Env[i].Value = env[task.Env[i].Name]
+	}
+
+	for i, step := range runs {
+		var lines []string
+		for _, variable := range step.Env {
+			if value, ok := g.envValue(variable, todo); ok && env[variable.Name] != value {
+				lines = append(lines, fmt.Sprintf("export %s=%s", variable.Name, quoteShell(value)))
+			}
+		}
+		if dirs[i] != task.Dir && dirs[i] != "" {
+			lines = append(lines, "cd "+quoteShell(dirs[i]))
+		}
+		for _, command := range step.Commands {
+			lines = append(lines, command.Raw)
+		}
+		if step.Condition != "" {
+			todo("step %d only runs in CI %s; guard it or drop it", i+1, step.Condition)
+		}
+		task.Cmds = append(task.Cmds, g.taskCommand(strings.Join(lines, "\n"), todo))
+	}
+
+	// Jobs running the same steps share one task
+	for _, existing := range g.tasks {
+		if sameTask(existing, task) {
+			existing.Jobs = append(existing.Jobs, task.Jobs...)
+			return existing
+		}
+	}
+
+	task.Name = g.taskName(pipeline, job.ID)
+	task.Desc = fmt.Sprintf("Run the %s job %s (%s)", toolLabel(pipeline.Tool), job.ID, pipeline.Source)
+	g.tasks = append(g.tasks, task)
+	return task
+}
+
+// callsTask reports whether a run step already runs go-task or installs it,
+// as in jobs rewritten by migrate --apply
+func callsTask(step ir.Step) bool {
+	for _, command := range step.Commands {
+		if strings.Contains(command.Raw, ir.GoTaskInstallScript) {
+			return true
+		}
+		if command.Program != "task" {
+			return false
+		}
+	}
+	return true
+}
+
+// envValue converts an environment value, dropping values that only CI can
+// compute. References to secrets and other variables are expected to be set
+// in the shell running task.
+func (g *taskfileGenerator) envValue(variable ir.Env, todo func(string, ...interface{})) (string, bool) {
+	value := variable.Value
+	if match := secretExpressionRegex.FindStringSubmatch(strings.TrimSpace(value)); match != nil && match[0] == strings.TrimSpace(value) {
+		if match[1] != variable.Name {
+			todo("set %s from %s before running the task", variable.Name, match[1])
+		}
+		return "", false
+	}
+	if strings.Contains(value, "${{") || strings.Contains(value, "<<") {
+		todo("%s is computed by CI (%s); set it before running the task", variable.Name, value)
+		return "", false
+	}
+	return value, true
+}
+
+// taskCommand rewrites CI expressions in a script for go-task
+func (g *taskfileGenerator) taskCommand(script string, todo func(string, ...interface{})) string {
+	if strings.Contains(script, "GITHUB_OUTPUT") || strings.Contains(script, "GITHUB_ENV") {
+		todo("$GITHUB_OUTPUT and $GITHUB_ENV are only set on GitHub Actions runners")
+	}
+	script = secretExpressionRegex.ReplaceAllString(script, "$$$1")
+	return expressionRegex.ReplaceAllStringFunc(script, func(match string) string {
+		if local, ok := localExpressions[expressionRegex.FindStringSubmatch(match)[1]]; ok {
+			return local
+		}
+		todo("replace %s, which only CI can evaluate", match)
+		return match
+	})
+}
+
+// taskName derives a unique task name from a job ID, prefixing the tool or
+// workflow file name on collisions
+func (g *taskfileGenerator) taskName(pipeline *ir.Pipeline, jobID string) string {
+	name := strings.Trim(taskNameRegex.ReplaceAllString(jobID, "-"), "-:")
+	if name == "" {
+		name = "job"
+	}
+	if g.names[name] {
+		prefix := pipeline.Tool
+		if pipeline.Tool == ir.ToolGitHubActions {
+			prefix = strings.TrimSuffix(path.Base(pipeline.Source), path.Ext(pipeline.Source))
+		}
+		name = strings.Trim(taskNameRegex.ReplaceAllString(prefix, "-"), "-:") + "-" + name
+	}
+
+	return uniqueTaskName(name, g.names)
+}
+
+// taskDeps returns the tasks of the jobs a job needs, looking through jobs
+// without run steps to the jobs they need
+func taskDeps(pipeline *ir.Pipeline, job *ir.Job, jobTasks map[string]*Task, seen map[string]bool) []string {
+	var deps []string
+	for _, target := range job.DependencyTargets() {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		if task := jobTasks[target]; task != nil {
+			deps = appendUnique(deps, task.Name)
+			continue
+		}
+		if needed := pipeline.Job(target); needed != nil {
+			deps = appendUnique(deps, taskDeps(pipeline, needed, jobTasks, seen)...)
+		}
+	}
+	return deps
+}
+
+// taskDir converts a step working directory into a task dir relative to the
+// repository root, which is where CI jobs check out the code
+func taskDir(jobDir, stepDir string) string {
+	if stepDir == "" {
+		return ""
+	}
+
+	roots := append([]string{jobDir}, ir.CheckoutDirs...)
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		if stepDir == root {
+			return ""
+		}
+		if strings.HasPrefix(stepDir, root+"/") {
+			stepDir = strings.TrimPrefix(stepDir, root+"/")
+			break
+		}
+	}
+
+	dir := path.Clean(stepDir)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// sameTask reports whether two tasks run the same commands in the same setting
+func sameTask(a, b *Task) bool {
+	if a.Dir != b.Dir || len(a.Env) != len(b.Env) || !sameStrings(a.Cmds, b.Cmds) {
+		return false
+	}
+	for i := range a.Env {
+		if a.Env[i] != b.Env[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeTemplate keeps go-task from evaluating literal {{ in commands
+func escapeTemplate(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
+
+// quoteShell quotes a value for a POSIX shell when needed
+func quoteShell(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n'\"$`\\|&;<>(){}*?[]#~!") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// toolLabel returns the display name of a CI tool
+func toolLabel(tool string) string {
+	switch tool {
+	case ir.ToolCircleCI:
+		return "CircleCI"
+	case ir.ToolGitHubActions:
+		return "GitHub Actions"
+	}
+	return tool
+}
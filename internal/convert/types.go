@@ -0,0 +1,33 @@
+package convert
+
+// File is a generated configuration file
+type File struct {
+	Path    string // Relative to the output root, with forward slashes
+	Content []byte
+}
+
+// Note is something the converter could not map automatically. The same
+// message is written as a TODO comment next to the generated YAML.
+type Note struct {
+	File    string `json:"file"`
+	Job     string `json:"job,omitempty"`
+	Message string `json:"message"`
+}
+
+// UnknownAction is an action with no equivalent in the target system
+type UnknownAction struct {
+	Uses   string   `json:"uses"`
+	UsedBy []string `json:"used_by"` // "workflow / job" entries
+}
+
+// Result holds the files and notes produced by a conversion
+type Result struct {
+	From           string // ir.ToolCircleCI or ir.ToolGitHubActions
+	To             string
+	Files          []File
+	Notes          []Note
+	UnknownActions []UnknownAction // Sorted by reference
+	Tasks          []*Task         // Tasks of a generated Taskfile
+	Conflicts      []Conflict      // Task name collisions when merging into a Taskfile
+	Rewrites       []Rewrite       // CI jobs changed to call their task
+}
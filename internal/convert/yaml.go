@@ -0,0 +1,160 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Generated files are built as yaml.v3 node trees so key order and TODO
+// comments survive encoding
+
+// newMapping returns an empty mapping node
+func newMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode}
+}
+
+// newSequence returns a sequence node holding the given items
+func newSequence(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Content: items}
+}
+
+// newScalar returns a string node. Multi-line text uses the literal block style.
+func newScalar(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if strings.Contains(value, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	return node
+}
+
+// newStrings returns a sequence of string nodes
+func newStrings(values []string) *yaml.Node {
+	seq := newSequence()
+	for _, value := range values {
+		seq.Content = append(seq.Content, newScalar(value))
+	}
+	return seq
+}
+
+// newValue encodes an arbitrary decoded YAML value, keeping its type
+func newValue(value interface{}) *yaml.Node {
+	if str, ok := value.(string); ok {
+		return newScalar(str)
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return newScalar(fmt.Sprintf("%v", value))
+	}
+	return node
+}
+
+// newValueMap encodes a map with sorted keys
+func newValueMap(values map[string]interface{}) *yaml.Node {
+	node := newMapping()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		set(node, key, newValue(values[key]))
+	}
+	return node
+}
+
+// set appends a key to a mapping node and returns the key node, which is
+// where comments about the entry belong
+func set(mapping *yaml.Node, key string, value *yaml.Node) *yaml.Node {
+	keyNode := newScalar(key)
+	mapping.Content = append(mapping.Content, keyNode, value)
+	return keyNode
+}
+
+// lookup returns the value of a key in a mapping node, or nil
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// addComment appends a line to a node's head comment
+func addComment(node *yaml.Node, text string) {
+	line := "# " + text
+	if node.HeadComment == "" {
+		node.HeadComment = line
+		return
+	}
+	node.HeadComment += "\n" + line
+}
+
+// notes records TODO comments together with the matching conversion notes
+type notes struct {
+	result *Result
+	file   string // Generated file being built
+	job    string // Source job being converted, empty at workflow level
+}
+
+// todo adds a TODO comment to a node and records it in the result
+func (n *notes) todo(node *yaml.Node, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	addComment(node, "TODO: "+message)
+	n.result.Notes = append(n.result.Notes, Note{File: n.file, Job: n.job, Message: message})
+}
+
+// encodeDocument renders a node tree with two-space indentation under a
+// header comment
+func encodeDocument(root *yaml.Node, header []string) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	for _, line := range header {
+		addComment(doc, line)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// trimLines removes trailing whitespace from every line so multi-line
+// commands can use the literal block style
+func trimLines(text string) string {
+	lines := strings.Split(strings.TrimRight(text, " \t\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+var identifierRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// identifier turns a name into a job or file identifier, made unique among taken
+func identifier(name string, taken map[string]bool) string {
+	id := strings.Trim(identifierRegex.ReplaceAllString(name, "-"), "-")
+	if id == "" || !(id[0] == '_' || (id[0] >= 'A' && id[0] <= 'Z') || (id[0] >= 'a' && id[0] <= 'z')) {
+		id = "job-" + id
+	}
+
+	unique := id
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", id, n)
+	}
+	taken[unique] = true
+	return unique
+}
@@ -2,14 +2,18 @@ package discovery
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/nichecode/pipeline-analyzer/internal/circleci"
 	"github.com/nichecode/pipeline-analyzer/internal/docker"
 	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
 	"github.com/nichecode/pipeline-analyzer/internal/gotask"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/report"
 	"github.com/nichecode/pipeline-analyzer/internal/shared"
 )
 
@@ -25,6 +29,12 @@ type AnalysisResult struct {
 type Analyzer struct {
 	repository   *Repository
 	discoveryDir string
+	report       *report.Report
+	pipelines    []*ir.Pipeline
+	taskfilePath string
+	taskNames    map[string]map[string]bool // Resolved Taskfile path → callable task names
+	history      []Snapshot                 // Earlier runs and this one, oldest first
+	out          io.Writer                  // Progress output
 }
 
 // NewAnalyzer creates a new analyzer
@@ -32,26 +42,33 @@ func NewAnalyzer(repo *Repository, discoveryDir string) *Analyzer {
 	return &Analyzer{
 		repository:   repo,
 		discoveryDir: discoveryDir,
+		report:       report.New(repo.RootPath, repo.GitRepo, ""),
+		out:          os.Stdout,
 	}
 }
 
+// SetOutput sets where progress is printed; stdout by default
+func (a *Analyzer) SetOutput(out io.Writer) {
+	a.out = out
+}
+
 // AnalyzeAll analyzes all discovered build tools
 func (a *Analyzer) AnalyzeAll() ([]AnalysisResult, error) {
 	var results []AnalysisResult
 
-	fmt.Printf("🔍 Discovered %d build tools in repository\n\n", len(a.repository.BuildTools))
+	fmt.Fprintf(a.out, "🔍 Discovered %d build tools in repository\n\n", len(a.repository.BuildTools))
 
 	for _, tool := range a.repository.BuildTools {
-		fmt.Printf("--- Analyzing %s ---\n", tool.Name)
+		fmt.Fprintf(a.out, "--- Analyzing %s ---\n", tool.Name)
 		
 		result := a.analyzeTool(tool)
 		results = append(results, result)
 
 		if result.Success {
-			fmt.Printf("✅ %s analysis completed successfully\n", tool.Name)
-			fmt.Printf("📁 Output: %s\n\n", result.OutputDir)
+			fmt.Fprintf(a.out, "✅ %s analysis completed successfully\n", tool.Name)
+			fmt.Fprintf(a.out, "📁 Output: %s\n\n", result.OutputDir)
 		} else {
-			fmt.Printf("❌ %s analysis failed: %s\n\n", tool.Name, result.Error)
+			fmt.Fprintf(a.out, "❌ %s analysis failed: %s\n\n", tool.Name, result.Error)
 		}
 	}
 
@@ -157,16 +174,22 @@ func (a *Analyzer) analyzeCircleCI(configPath, outputDir string) error {
 		return fmt.Errorf("failed to parse CircleCI config: %w", err)
 	}
 
+	// Expand orbs from inline definitions and the offline cache
+	circleci.ResolveOrbs(config, filepath.Join(a.repository.RootPath, circleci.OrbCacheDir))
+
 	// Validate the configuration
 	if err := circleci.IsValidConfig(config); err != nil {
 		return fmt.Errorf("invalid CircleCI configuration: %w", err)
 	}
 
-	fmt.Printf("✅ Configuration parsed successfully\n")
-	fmt.Printf("   - Version: %s\n", config.Version)
-	fmt.Printf("   - Jobs: %d\n", len(config.Jobs))
-	fmt.Printf("   - Workflows: %d\n", len(config.Workflows))
-	fmt.Printf("   - Executors: %d\n", len(config.Executors))
+	fmt.Fprintf(a.out, "✅ Configuration parsed successfully\n")
+	fmt.Fprintf(a.out, "   - Version: %s\n", config.Version)
+	fmt.Fprintf(a.out, "   - Jobs: %d\n", len(config.Jobs))
+	fmt.Fprintf(a.out, "   - Workflows: %d\n", len(config.Workflows))
+	fmt.Fprintf(a.out, "   - Executors: %d\n", len(config.Executors))
+	if len(config.Orbs) > 0 {
+		fmt.Fprintf(a.out, "   - Orbs: %d\n", len(config.Orbs))
+	}
 
 	// Validate output directory
 	if err := circleci.ValidateOutputDir(outputDir); err != nil {
@@ -175,6 +198,8 @@ func (a *Analyzer) analyzeCircleCI(configPath, outputDir string) error {
 
 	// Perform analysis
 	analysis := circleci.AnalyzeConfig(config)
+	a.report.CircleCI = report.FromCircleCI(analysis, a.relativePath(configPath))
+	a.pipelines = append(a.pipelines, circleci.Lower(analysis, a.relativePath(configPath)))
 
 	// Create writer and generate all files
 	writer := circleci.NewWriter(outputDir)
@@ -201,11 +226,11 @@ func (a *Analyzer) analyzeGoTask(configPath, outputDir string) error {
 		return fmt.Errorf("invalid taskfile: %w", err)
 	}
 
-	fmt.Printf("✅ Taskfile parsed successfully\n")
-	fmt.Printf("   - Version: %s\n", taskfile.Version)
-	fmt.Printf("   - Tasks: %d\n", len(taskfile.Tasks))
-	fmt.Printf("   - Includes: %d\n", len(taskfile.Includes))
-	fmt.Printf("   - Global Variables: %d\n", len(taskfile.Vars))
+	fmt.Fprintf(a.out, "✅ Taskfile parsed successfully\n")
+	fmt.Fprintf(a.out, "   - Version: %s\n", taskfile.Version)
+	fmt.Fprintf(a.out, "   - Tasks: %d\n", len(taskfile.Tasks))
+	fmt.Fprintf(a.out, "   - Includes: %d\n", len(taskfile.Includes))
+	fmt.Fprintf(a.out, "   - Global Variables: %d\n", len(taskfile.Vars))
 
 	// Validate output directory
 	if err := gotask.ValidateOutputDir(outputDir); err != nil {
@@ -214,9 +239,12 @@ func (a *Analyzer) analyzeGoTask(configPath, outputDir string) error {
 
 	// Perform analysis
 	analysis := gotask.AnalyzeTaskfile(taskfile)
+	a.taskfilePath = configPath
 	
 	// Post-process includes with the correct base path for better analysis
 	gotask.AnalyzeIncludesWithPath(taskfile, analysis, configPath)
+	a.report.GoTask = report.FromGoTask(analysis, a.relativePath(configPath))
+	a.pipelines = append(a.pipelines, gotask.Lower(analysis, a.relativePath(configPath)))
 
 	// Create writer and generate all files
 	writer := gotask.NewWriter(outputDir)
@@ -261,7 +289,7 @@ func (a *Analyzer) analyzeGitHubActions(configPath, outputDir string) error {
 		return fmt.Errorf("no workflow files found")
 	}
 
-	fmt.Printf("✅ Found %d workflow file(s)\n", len(workflowFiles))
+	fmt.Fprintf(a.out, "✅ Found %d workflow file(s)\n", len(workflowFiles))
 
 	// Create output directory
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -273,18 +301,22 @@ func (a *Analyzer) analyzeGitHubActions(configPath, outputDir string) error {
 	for _, workflowFile := range workflowFiles {
 		result, err := analyzer.AnalyzeWorkflow(workflowFile)
 		if err != nil {
-			fmt.Printf("⚠️  Failed to analyze %s: %v\n", filepath.Base(workflowFile), err)
+			fmt.Fprintf(a.out, "⚠️  Failed to analyze %s: %v\n", filepath.Base(workflowFile), err)
 			continue
 		}
 		allResults = append(allResults, result)
 		
-		fmt.Printf("   - %s: %d jobs, %d steps\n", 
+		fmt.Fprintf(a.out, "   - %s: %d jobs, %d steps\n", 
 			filepath.Base(workflowFile), len(result.Jobs), result.TotalSteps)
 	}
 
 	if len(allResults) == 0 {
 		return fmt.Errorf("failed to analyze any workflow files")
 	}
+	a.report.GitHubActions = report.FromGitHubActions(allResults, a.relativePath(configPath))
+	for _, result := range allResults {
+		a.pipelines = append(a.pipelines, githubactions.Lower(result, a.relativePath(result.FilePath)))
+	}
 
 	// Generate markdown documentation for all workflows
 	writer := githubactions.NewWriter(outputDir)
@@ -303,25 +335,26 @@ func (a *Analyzer) analyzeDocker(configPath, outputDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to analyze Docker configurations: %w", err)
 	}
+	a.report.Docker = analysis
 
 	// Validate output directory
 	if err := docker.ValidateOutputDir(outputDir); err != nil {
 		return fmt.Errorf("output directory validation failed: %w", err)
 	}
 
-	fmt.Printf("✅ Docker analysis completed successfully\n")
-	fmt.Printf("   - Dockerfiles: %d\n", len(analysis.Dockerfiles))
-	fmt.Printf("   - Multi-stage builds: %d\n", analysis.Summary.MultiStageBuilds)
+	fmt.Fprintf(a.out, "✅ Docker analysis completed successfully\n")
+	fmt.Fprintf(a.out, "   - Dockerfiles: %d\n", len(analysis.Dockerfiles))
+	fmt.Fprintf(a.out, "   - Multi-stage builds: %d\n", analysis.Summary.MultiStageBuilds)
 	if len(analysis.DockerCompose) > 0 {
 		totalServices := 0
 		for _, compose := range analysis.DockerCompose {
 			totalServices += compose.ServiceCount
 		}
-		fmt.Printf("   - Docker Compose files: %d\n", len(analysis.DockerCompose))
-		fmt.Printf("   - Docker Compose services: %d\n", totalServices)
+		fmt.Fprintf(a.out, "   - Docker Compose files: %d\n", len(analysis.DockerCompose))
+		fmt.Fprintf(a.out, "   - Docker Compose services: %d\n", totalServices)
 	}
-	fmt.Printf("   - Security issues: %d\n", analysis.Summary.SecurityIssues)
-	fmt.Printf("   - Overall score: %d/100\n", analysis.Summary.OverallScore)
+	fmt.Fprintf(a.out, "   - Security issues: %d\n", analysis.Summary.SecurityIssues)
+	fmt.Fprintf(a.out, "   - Overall score: %d/100\n", analysis.Summary.OverallScore)
 
 	// Create writer and generate all files
 	writer := docker.NewWriter(outputDir)
@@ -380,13 +413,20 @@ func (a *Analyzer) GenerateOverview(results []AnalysisResult) error {
 **Generated:** %s  
 **Git Repository:** %t
 
-## 🔍 Discovered Build Tools
+`, a.repository.RootPath, time.Now().Format(time.RFC3339), a.repository.GitRepo)
+
+	if coverage := a.migrationCoverage(); coverage.Commands > 0 {
+		content += ir.GenerateMigrationCoverageSection(coverage)
+	}
+	content += a.generateTrendSection()
+
+	content += fmt.Sprintf(`## 🔍 Discovered Build Tools
 
 Found **%d** build tools in this repository:
 
 | Tool | Type | Status | Analysis |
 |------|------|--------|----------|
-`, a.repository.RootPath, time.Now().Format(time.RFC3339), a.repository.GitRepo, len(results))
+`, len(results))
 
 	for _, result := range results {
 		status := "✅ Success"
@@ -416,6 +456,15 @@ Each discovered tool has its own analysis directory:
 		}
 	}
 
+	if len(a.pipelines) > 0 {
+		content += fmt.Sprintf("- [%s](%s) - Commands and images shared across tools\n", ir.CrossToolFileName, ir.CrossToolFileName)
+	}
+	content += fmt.Sprintf(`- [%s](%s) - Machine-readable report (schema version %s)
+`, report.FileName, report.FileName, report.SchemaVersion)
+	if len(a.history) > 0 {
+		content += fmt.Sprintf("- [%s](%s) - Metrics of every run, one JSON object per line\n", HistoryFileName, HistoryFileName)
+	}
+
 	content += `
 
 ## 🚀 Getting Started
@@ -481,7 +530,7 @@ func (a *Analyzer) GenerateHTMLIndex(results []AnalysisResult) error {
             <p>Found <strong>` + fmt.Sprintf("%d", len(results)) + `</strong> build tools</p>
             <p>Successfully analyzed: <strong>` + fmt.Sprintf("%d", countSuccessful(results)) + `</strong></p>
         </div>
-
+` + a.generateTrendHTML() + `
         <div class="section">
             <h2>🔍 Discovered Build Tools</h2>
             
@@ -561,6 +610,107 @@ func (a *Analyzer) GenerateHTMLIndex(results []AnalysisResult) error {
 	return os.WriteFile(indexPath, []byte(content), 0644)
 }
 
+// GenerateCrossToolReport writes the cross-tool comparison computed over the
+// lowered pipelines of every analyzed tool
+func (a *Analyzer) GenerateCrossToolReport() error {
+	if len(a.pipelines) == 0 {
+		return nil
+	}
+
+	summary := ir.Compare(a.pipelines)
+	a.report.Pipelines = a.pipelines
+	a.report.CrossTool = summary
+
+	reportPath := filepath.Join(a.discoveryDir, ir.CrossToolFileName)
+	return os.WriteFile(reportPath, []byte(ir.GenerateCrossToolReport(summary)), 0644)
+}
+
+// migrationCoverage measures how many CI command lines already call go-task,
+// checking each called task against the discovered Taskfile
+func (a *Analyzer) migrationCoverage() *ir.MigrationCoverage {
+	if a.report.MigrationCoverage == nil {
+		a.report.MigrationCoverage = ir.MeasureMigrationCoverage(a.pipelines, a.callableTasks)
+	}
+	return a.report.MigrationCoverage
+}
+
+// callableTasks resolves the Taskfile a task call uses and returns its task
+// names. dir is where the call runs, relative to the repository root.
+// --taskfile and --dir values are relative to dir; without them the task
+// CLI looks for a Taskfile in dir and then in its parents, ending at the
+// discovered one.
+func (a *Analyzer) callableTasks(dir, taskfile string) (map[string]bool, bool) {
+	base := filepath.Join(a.repository.RootPath, filepath.FromSlash(dir))
+	path := a.taskfilePath
+	if taskfile != "" {
+		path = taskfile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+			path, _ = gotask.FindTaskfile(path)
+		}
+	} else {
+		for current := base; current != a.repository.RootPath && strings.HasPrefix(current, a.repository.RootPath+string(filepath.Separator)); current = filepath.Dir(current) {
+			if found, err := gotask.FindTaskfile(current); err == nil {
+				path = found
+				break
+			}
+		}
+	}
+	if path == "" {
+		return nil, false
+	}
+
+	if a.taskNames == nil {
+		a.taskNames = make(map[string]map[string]bool)
+	}
+	names, ok := a.taskNames[path]
+	if !ok {
+		var err error
+		if names, err = gotask.CallableTaskNames(path); err != nil {
+			names = nil
+		}
+		a.taskNames[path] = names
+	}
+	return names, names != nil
+}
+
+// WriteJSONReport writes the machine-readable report.json and returns the report
+func (a *Analyzer) WriteJSONReport(results []AnalysisResult, toolVersion string) (*report.Report, error) {
+	a.report.ToolVersion = toolVersion
+	a.report.Tools = []report.ToolResult{}
+	for _, result := range results {
+		a.report.Tools = append(a.report.Tools, report.ToolResult{
+			Type:       result.Tool.Type,
+			Name:       result.Tool.Name,
+			ConfigPath: a.relativePath(result.Tool.ConfigPath),
+			Success:    result.Success,
+			Error:      result.Error,
+			OutputDir:  a.relativePath(result.OutputDir),
+		})
+	}
+
+	reportPath := filepath.Join(a.discoveryDir, report.FileName)
+	if err := report.WriteFile(reportPath, a.report); err != nil {
+		return nil, err
+	}
+
+	return a.report, nil
+}
+
+// relativePath returns path relative to the repository root when possible
+func (a *Analyzer) relativePath(path string) string {
+	if path == "" {
+		return path
+	}
+	rel, err := filepath.Rel(a.repository.RootPath, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
 // countSuccessful counts the number of successful analysis results
 func countSuccessful(results []AnalysisResult) int {
 	count := 0
@@ -0,0 +1,305 @@
+package discovery
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// HistoryFileName is the file in the discovery directory that keeps one
+// metrics snapshot per run, so migration progress survives regeneration
+const HistoryFileName = "history.jsonl"
+
+// maxTrendPoints bounds how many recent runs the trend charts show
+const maxTrendPoints = 20
+
+// Snapshot holds the key metrics of one analysis run
+type Snapshot struct {
+	Timestamp        time.Time `json:"timestamp"`
+	ToolVersion      string    `json:"tool_version,omitempty"`
+	Jobs             int       `json:"jobs"`              // CircleCI and GitHub Actions jobs
+	RawCommands      int       `json:"raw_commands"`      // CI command lines not running task
+	TaskCoverage     float64   `json:"task_coverage"`     // Percent of CI command lines running task
+	DockerScore      *int      `json:"docker_score"`      // Nil without Docker files
+	SecurityIssues   int       `json:"security_issues"`   // Docker and GitHub Actions findings
+	OptimizationTips int       `json:"optimization_tips"` // go-task, Docker and GitHub Actions suggestions
+}
+
+// trendMetric is one charted snapshot metric
+type trendMetric struct {
+	title string
+	value func(Snapshot) (float64, bool)
+}
+
+// trendMetrics lists the charted metrics in display order
+var trendMetrics = []trendMetric{
+	{"Task coverage (%)", func(s Snapshot) (float64, bool) { return s.TaskCoverage, true }},
+	{"Raw shell commands", func(s Snapshot) (float64, bool) { return float64(s.RawCommands), true }},
+	{"CI jobs", func(s Snapshot) (float64, bool) { return float64(s.Jobs), true }},
+	{"Docker score", func(s Snapshot) (float64, bool) {
+		if s.DockerScore == nil {
+			return 0, false
+		}
+		return float64(*s.DockerScore), true
+	}},
+	{"Security issues", func(s Snapshot) (float64, bool) { return float64(s.SecurityIssues), true }},
+	{"Optimization tips", func(s Snapshot) (float64, bool) { return float64(s.OptimizationTips), true }},
+}
+
+// RecordHistory appends a snapshot of this run's metrics to history.jsonl and
+// loads the earlier snapshots for the trend charts
+func (a *Analyzer) RecordHistory(toolVersion string) error {
+	historyPath := filepath.Join(a.discoveryDir, HistoryFileName)
+	history, err := loadHistory(historyPath)
+	if err != nil {
+		return err
+	}
+
+	snapshot := a.snapshot(toolVersion)
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode history snapshot: %w", err)
+	}
+	file, err := os.OpenFile(historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	a.history = append(history, snapshot)
+	return nil
+}
+
+// snapshot collects the metrics of the current run
+func (a *Analyzer) snapshot(toolVersion string) Snapshot {
+	coverage := a.migrationCoverage()
+	snapshot := Snapshot{
+		Timestamp:    time.Now().UTC(),
+		ToolVersion:  toolVersion,
+		RawCommands:  coverage.ShellCommands,
+		TaskCoverage: coverage.Percent(),
+	}
+
+	for _, pipeline := range a.pipelines {
+		if pipeline.Tool == ir.ToolCircleCI || pipeline.Tool == ir.ToolGitHubActions {
+			snapshot.Jobs += len(pipeline.Jobs)
+		}
+	}
+	if a.report.Docker != nil && a.report.Docker.Summary != nil {
+		score := a.report.Docker.Summary.OverallScore
+		snapshot.DockerScore = &score
+		snapshot.SecurityIssues += a.report.Docker.Summary.SecurityIssues
+		snapshot.OptimizationTips += a.report.Docker.Summary.OptimizationIssues
+	}
+	if a.report.GoTask != nil {
+		snapshot.OptimizationTips += len(a.report.GoTask.OptimizationTips)
+	}
+	if a.report.GitHubActions != nil {
+		for _, workflow := range a.report.GitHubActions.Workflows {
+			for _, job := range workflow.Jobs {
+				snapshot.SecurityIssues += len(job.SecurityIssues)
+				snapshot.OptimizationTips += len(job.Recommendations)
+			}
+		}
+	}
+
+	return snapshot
+}
+
+// loadHistory reads the snapshots of earlier runs, skipping unreadable lines
+func loadHistory(historyPath string) ([]Snapshot, error) {
+	file, err := os.Open(historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer file.Close()
+
+	var history []Snapshot
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal([]byte(line), &snapshot); err != nil {
+			shared.GetLogger().Warn("Discovery", "Skipping unreadable history line", map[string]interface{}{
+				"file":  historyPath,
+				"error": err.Error(),
+			})
+			continue
+		}
+		history = append(history, snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return history, nil
+}
+
+// recentHistory returns the snapshots shown in the trend charts
+func (a *Analyzer) recentHistory() []Snapshot {
+	if len(a.history) > maxTrendPoints {
+		return a.history[len(a.history)-maxTrendPoints:]
+	}
+	return a.history
+}
+
+// generateTrendSection renders the metric trends as Mermaid charts and a table
+func (a *Analyzer) generateTrendSection() string {
+	history := a.recentHistory()
+	if len(history) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## 📈 Migration Progress\n\n")
+	if len(history) == 1 {
+		sb.WriteString(fmt.Sprintf("This is the first run recorded in [%s](%s). Trends appear after the next run.\n\n", HistoryFileName, HistoryFileName))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Metrics of the last %d runs, recorded in [%s](%s).\n\n", len(history), HistoryFileName, HistoryFileName))
+
+	labels := make([]string, len(history))
+	for i, snapshot := range history {
+		labels[i] = fmt.Sprintf("%q", snapshot.Timestamp.Local().Format("01-02 15:04"))
+	}
+	// Coverage and raw commands are the headline trends; the table has the rest
+	for i, metric := range trendMetrics[:2] {
+		values := make([]string, len(history))
+		for i, snapshot := range history {
+			value, _ := metric.value(snapshot)
+			values[i] = formatMetric(value)
+		}
+		sb.WriteString("```mermaid\nxychart-beta\n")
+		sb.WriteString(fmt.Sprintf("    title \"%s\"\n", metric.title))
+		sb.WriteString(fmt.Sprintf("    x-axis [%s]\n", strings.Join(labels, ", ")))
+		if i == 0 {
+			sb.WriteString("    y-axis \"Percent\" 0 --> 100\n")
+		}
+		sb.WriteString(fmt.Sprintf("    line [%s]\n", strings.Join(values, ", ")))
+		sb.WriteString("```\n\n")
+	}
+
+	sb.WriteString("| Metric | Previous | Latest | Change |\n")
+	sb.WriteString("|--------|----------|--------|--------|\n")
+	previous, latest := history[len(history)-2], history[len(history)-1]
+	for _, metric := range trendMetrics {
+		before, hadBefore := metric.value(previous)
+		after, hasAfter := metric.value(latest)
+		if !hadBefore && !hasAfter {
+			continue
+		}
+		change := "-"
+		if hadBefore && hasAfter {
+			change = "0"
+			if delta := roundMetric(after - before); delta != 0 {
+				change = fmt.Sprintf("%+g", delta)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", metric.title, optionalMetric(before, hadBefore), optionalMetric(after, hasAfter), change))
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// generateTrendHTML renders the metric trends as inline SVG sparklines
+func (a *Analyzer) generateTrendHTML() string {
+	history := a.recentHistory()
+	if len(history) < 2 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`
+        <div class="section">
+            <h2>📈 Migration Progress</h2>
+            <p>Metrics of the last ` + fmt.Sprintf("%d", len(history)) + ` runs, recorded in <a href="` + HistoryFileName + `">` + HistoryFileName + `</a>.</p>
+            <div class="tool-grid">`)
+
+	for _, metric := range trendMetrics {
+		var values []float64
+		for _, snapshot := range history {
+			if value, ok := metric.value(snapshot); ok {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(`
+                <div class="tool-card">
+                    <h3>%s</h3>
+                    <p><strong>%s</strong></p>
+                    %s
+                </div>`, metric.title, formatMetric(values[len(values)-1]), sparkline(values)))
+	}
+
+	sb.WriteString(`
+            </div>
+        </div>
+`)
+	return sb.String()
+}
+
+// sparkline renders values as an inline SVG line chart
+func sparkline(values []float64) string {
+	const width, height, pad = 220.0, 50.0, 4.0
+
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low = min(low, value)
+		high = max(high, value)
+	}
+	// A flat series is drawn through the middle
+	span := high - low
+	if span == 0 {
+		low, span = low-1, 2
+	}
+
+	points := make([]string, len(values))
+	for i, value := range values {
+		x := pad
+		if len(values) > 1 {
+			x += float64(i) * (width - 2*pad) / float64(len(values)-1)
+		}
+		y := height - pad - (value-low)/span*(height-2*pad)
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+
+	return fmt.Sprintf(`<svg width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" role="img"><polyline fill="none" stroke="#0066cc" stroke-width="2" points="%s"/></svg>`,
+		width, height, width, height, strings.Join(points, " "))
+}
+
+// formatMetric renders a metric value without trailing zeros
+func formatMetric(value float64) string {
+	return fmt.Sprintf("%g", roundMetric(value))
+}
+
+// optionalMetric renders a metric value, or "-" when it was not measured
+func optionalMetric(value float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return formatMetric(value)
+}
+
+// roundMetric rounds a metric to one decimal place
+func roundMetric(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -28,7 +28,7 @@ func AnalyzeDocker(rootPath string) (*DockerAnalysis, error) {
 		dockerfileAnalysis, err := ParseDockerfile(dockerfilePath)
 		if err != nil {
 			// Log error but continue with other files
-			fmt.Printf("Warning: Failed to parse Dockerfile %s: %v\n", dockerfilePath, err)
+			fmt.Fprintf(os.Stderr, "Warning: Failed to parse Dockerfile %s: %v\n", dockerfilePath, err)
 			continue
 		}
 		analysis.Dockerfiles = append(analysis.Dockerfiles, dockerfileAnalysis)
@@ -38,7 +38,7 @@ func AnalyzeDocker(rootPath string) (*DockerAnalysis, error) {
 	for _, composeFile := range composeFiles {
 		composeAnalysis, err := ParseDockerCompose(composeFile)
 		if err != nil {
-			fmt.Printf("Warning: Failed to parse docker-compose %s: %v\n", composeFile, err)
+			fmt.Fprintf(os.Stderr, "Warning: Failed to parse docker-compose %s: %v\n", composeFile, err)
 			continue
 		}
 		analysis.DockerCompose = append(analysis.DockerCompose, composeAnalysis)
@@ -36,6 +36,9 @@ func (a *Analyzer) AnalyzeWorkflow(filePath string) (*AnalysisResult, error) {
 		return nil, fmt.Errorf("failed to parse workflow: %v", err)
 	}
 
+	// Inline local actions and reusable workflows from the repository
+	ResolveLocalReferences(workflow, RepositoryRoot(filePath))
+
 	result := a.analyzeWorkflowData(workflow, filePath)
 	
 	logger.Info("GitHubActions", "Workflow analyzed successfully", map[string]interface{}{
@@ -64,13 +67,14 @@ func (a *Analyzer) analyzeWorkflowData(workflow *Workflow, filePath string) *Ana
 
 	// Analyze each job
 	for jobName, job := range workflow.Jobs {
-		jobAnalysis := a.analyzeJob(jobName, job)
+		jobAnalysis := a.analyzeJob(workflow, jobName, job)
 		result.Jobs = append(result.Jobs, jobAnalysis)
 		result.TotalSteps += jobAnalysis.StepCount
 
-		// Track runner usage
-		runner := a.parser.GetRunnerType(job)
-		result.RunnerUsage[runner]++
+		// Track runner usage, once per matrix instance
+		for _, instance := range jobAnalysis.MatrixInstances {
+			result.RunnerUsage[instance.Runner]++
+		}
 
 		// Track action and service usage
 		for _, action := range jobAnalysis.ActionsUsed {
@@ -94,19 +98,58 @@ func (a *Analyzer) analyzeWorkflowData(workflow *Workflow, filePath string) *Ana
 }
 
 // analyzeJob analyzes a specific job
-func (a *Analyzer) analyzeJob(jobName string, job Job) JobAnalysis {
+func (a *Analyzer) analyzeJob(workflow *Workflow, jobName string, job Job) JobAnalysis {
 	analysis := JobAnalysis{
 		Name:         jobName,
 		Runner:       a.parser.GetRunnerType(job),
-		StepCount:    len(job.Steps),
 		Dependencies: a.parser.GetJobDependencies(job),
 	}
 
+	// Inline local composite actions and reusable workflows
+	analysis.ExpandedSteps, analysis.LocalCalls = ExpandJobSteps(workflow, job)
+	effective := job
+	effective.Steps = make([]Step, len(analysis.ExpandedSteps))
+	for i, step := range analysis.ExpandedSteps {
+		effective.Steps[i] = step.Step
+	}
+	analysis.StepCount = len(effective.Steps)
+
+	// Expand the strategy matrix into concrete job instances
+	analysis.MatrixInstances, analysis.MatrixDynamic = a.parser.ExpandJobMatrix(effective)
+	analysis.MatrixSize = len(analysis.MatrixInstances)
+	analysis.MaxParallel = job.Strategy.MaxParallel
+	if runners := matrixRunners(analysis.MatrixInstances); len(runners) > 0 {
+		analysis.Runner = strings.Join(runners, ", ")
+	}
+	if IsLocalReference(job.Uses) {
+		// The called workflow's jobs pick their own runners
+		analysis.ReusableWorkflow = localPath(job.Uses)
+		if runners := a.calledRunners(workflow, analysis.ReusableWorkflow); len(runners) > 0 {
+			analysis.Runner = strings.Join(runners, ", ")
+			for i := range analysis.MatrixInstances {
+				analysis.MatrixInstances[i].Runner = analysis.Runner
+			}
+		}
+	}
+
+	// Compare the token scopes the job uses with the declared permissions
+	analysis.Permissions = AnalyzePermissions(workflow, job, analysis.ExpandedSteps)
+
+	// Inventory the contexts the job's expressions read
+	analysis.Contexts, analysis.ExpressionIssues = a.parser.JobContexts(job)
+
 	var allCommands []string
 	var actionsUsed []string
 
-	// Analyze each step
-	for _, step := range job.Steps {
+	// Composite actions are replaced by their steps but still count as used
+	for _, call := range analysis.LocalCalls {
+		if call.Kind == LocalKindAction && call.Using == "composite" {
+			actionsUsed = append(actionsUsed, call.Uses)
+		}
+	}
+
+	// Analyze each effective step
+	for _, step := range effective.Steps {
 		if step.Uses != "" {
 			actionsUsed = append(actionsUsed, step.Uses)
 		}
@@ -129,6 +172,45 @@ func (a *Analyzer) analyzeJob(jobName string, job Job) JobAnalysis {
 	return analysis
 }
 
+// calledRunners returns the distinct runners of a local reusable workflow's jobs
+func (a *Analyzer) calledRunners(workflow *Workflow, path string) []string {
+	reusable := workflow.ReusableWorkflows[path]
+	if reusable == nil {
+		return nil
+	}
+
+	var runners []string
+	for _, jobName := range orderedJobNames(reusable.Workflow, a.parser) {
+		job := reusable.Workflow.Jobs[jobName]
+		if IsLocalReference(job.Uses) {
+			continue
+		}
+		if runner := a.parser.GetRunnerType(job); !shared.ContainsString(runners, runner) {
+			runners = append(runners, runner)
+		}
+	}
+	return runners
+}
+
+// matrixRunners returns the distinct runners of a job's matrix instances
+func matrixRunners(instances []MatrixInstance) []string {
+	var runners []string
+	for _, instance := range instances {
+		if !shared.ContainsString(runners, instance.Runner) {
+			runners = append(runners, instance.Runner)
+		}
+	}
+	return runners
+}
+
+// MatrixWaves returns how many rounds a job's matrix needs under max-parallel
+func (j JobAnalysis) MatrixWaves() int {
+	if j.MaxParallel <= 0 || j.MatrixSize <= j.MaxParallel {
+		return 1
+	}
+	return (j.MatrixSize + j.MaxParallel - 1) / j.MaxParallel
+}
+
 // analyzeCommandPatterns analyzes command patterns for go-task opportunities
 func (a *Analyzer) analyzeCommandPatterns(commands []string, patterns map[string][]string) {
 	for _, cmd := range commands {
@@ -211,6 +293,9 @@ func (a *Analyzer) identifySecurityIssues(job JobAnalysis) []string {
 
 	// Check for pinned action versions
 	for _, action := range job.ActionsUsed {
+		if IsLocalReference(action) {
+			continue
+		}
 		if !strings.Contains(action, "@") || strings.Contains(action, "@latest") {
 			issues = append(issues, fmt.Sprintf("⚠️ Action '%s' not pinned to specific version", action))
 		}
@@ -223,6 +308,14 @@ func (a *Analyzer) identifySecurityIssues(job JobAnalysis) []string {
 		}
 	}
 
+	// Check GITHUB_TOKEN scopes against what the job uses
+	if len(job.Permissions.OverGranted) > 0 {
+		issues = append(issues, fmt.Sprintf("🔑 GITHUB_TOKEN over-granted: %s", strings.Join(job.Permissions.OverGranted, "; ")))
+	}
+	if len(job.Permissions.Missing) > 0 {
+		issues = append(issues, fmt.Sprintf("🔑 GITHUB_TOKEN missing scopes: %s", strings.Join(job.Permissions.Missing, "; ")))
+	}
+
 	return issues
 }
 
@@ -247,6 +340,14 @@ func (a *Analyzer) identifyIssues(result *AnalysisResult) []string {
 		issues = append(issues, "💡 Many independent jobs - consider if some should have dependencies")
 	}
 
+	// Check for jobs relying on the repository's default token permissions
+	for _, job := range result.Jobs {
+		if job.Permissions.DeclaredAt == PermissionsFromDefault {
+			issues = append(issues, "🔑 No permissions block - GITHUB_TOKEN gets the repository default; see the suggested per-job permissions")
+			break
+		}
+	}
+
 	return issues
 }
 
@@ -0,0 +1,208 @@
+package githubactions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KnownContexts are the contexts GitHub makes available to expressions
+var KnownContexts = []string{
+	"github", "env", "vars", "job", "jobs", "steps", "runner",
+	"secrets", "strategy", "matrix", "needs", "inputs",
+}
+
+// ContextReferences returns the context paths an expression reads, such as
+// secrets.NPM_TOKEN or needs.build.outputs.tag, in order of appearance
+func ContextReferences(node Node) []string {
+	var refs []string
+	var visit func(Node)
+	visit = func(n Node) {
+		if path, ok := referencePath(n); ok {
+			if !containsReference(refs, path) {
+				refs = append(refs, path)
+			}
+			// Dynamic indexes inside the chain may reference other contexts
+			visitIndexes(n, visit)
+			return
+		}
+
+		switch n := n.(type) {
+		case Property:
+			visit(n.Target)
+		case Index:
+			visit(n.Target)
+			visit(n.Index)
+		case Filter:
+			visit(n.Target)
+		case Unary:
+			visit(n.Operand)
+		case Binary:
+			visit(n.Left)
+			visit(n.Right)
+		case Call:
+			for _, arg := range n.Args {
+				visit(arg)
+			}
+		}
+	}
+	visit(node)
+	return refs
+}
+
+// referencePath renders a dereference chain rooted at a context. Dynamic
+// indexes end the path at the last static segment.
+func referencePath(node Node) (string, bool) {
+	switch n := node.(type) {
+	case Identifier:
+		return n.Name, true
+	case Property:
+		path, ok := referencePath(n.Target)
+		return path + "." + n.Name, ok
+	case Filter:
+		path, ok := referencePath(n.Target)
+		return path + ".*", ok
+	case Index:
+		path, ok := referencePath(n.Target)
+		if !ok {
+			return "", false
+		}
+		if literal, isLiteral := n.Index.(Literal); isLiteral {
+			if key, isString := literal.Value.(string); isString {
+				return path + "." + key, true
+			}
+			return fmt.Sprintf("%s[%s]", path, formatNumber(literal.Value)), true
+		}
+		return path, true
+	}
+	return "", false
+}
+
+// visitIndexes visits the dynamic index expressions of a dereference chain
+func visitIndexes(node Node, visit func(Node)) {
+	switch n := node.(type) {
+	case Property:
+		visitIndexes(n.Target, visit)
+	case Filter:
+		visitIndexes(n.Target, visit)
+	case Index:
+		visitIndexes(n.Target, visit)
+		if _, isLiteral := n.Index.(Literal); !isLiteral {
+			visit(n.Index)
+		}
+	}
+}
+
+func containsReference(refs []string, ref string) bool {
+	for _, existing := range refs {
+		if existing == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// JobContexts inventories the contexts a job's expressions depend on. The
+// result maps each context name to its sorted references; expressions that
+// fail to parse or use unknown contexts and functions are returned as problems.
+func (p *Parser) JobContexts(job Job) (map[string][]string, []string) {
+	var conditions, texts []string
+
+	if job.If != "" {
+		conditions = append(conditions, job.If)
+	}
+	texts = append(texts, job.Name)
+	collectStrings(job.RunsOn, &texts)
+	collectStrings(job.Container, &texts)
+	collectStrings(job.Strategy.Matrix, &texts)
+	for _, value := range job.Env {
+		texts = append(texts, value)
+	}
+	for _, service := range job.Services {
+		texts = append(texts, service.Image, service.Options)
+		for _, value := range service.Env {
+			texts = append(texts, value)
+		}
+		for _, value := range service.Credentials {
+			texts = append(texts, value)
+		}
+	}
+	for _, step := range job.Steps {
+		if step.If != "" {
+			conditions = append(conditions, step.If)
+		}
+		texts = append(texts, step.Name, step.Uses, step.Run, step.WorkingDirectory)
+		for _, value := range step.With {
+			collectStrings(value, &texts)
+		}
+		for _, value := range step.Env {
+			texts = append(texts, value)
+		}
+	}
+
+	// if: conditions are expressions with or without ${{ }}
+	expressions := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		expressions = append(expressions, ConditionExpression(condition))
+	}
+	for _, text := range texts {
+		expressions = append(expressions, ExtractExpressions(text)...)
+	}
+
+	contexts := make(map[string][]string)
+	var problems []string
+	for _, expression := range expressions {
+		node, err := ParseExpression(expression)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("${{ %s }}: %v", expression, err))
+			continue
+		}
+		walkExpression(node, func(n Node) {
+			if call, ok := n.(Call); ok && !IsKnownFunction(call.Name) {
+				problems = append(problems, fmt.Sprintf("${{ %s }}: unknown function %s", expression, call.Name))
+			}
+		})
+		for _, ref := range ContextReferences(node) {
+			name := strings.ToLower(strings.SplitN(ref, ".", 2)[0])
+			if !IsKnownContext(name) {
+				problems = append(problems, fmt.Sprintf("${{ %s }}: unrecognized context %s", expression, name))
+				continue
+			}
+			if !containsReference(contexts[name], ref) {
+				contexts[name] = append(contexts[name], ref)
+			}
+		}
+	}
+
+	for name := range contexts {
+		sort.Strings(contexts[name])
+	}
+	sort.Strings(problems)
+	return contexts, problems
+}
+
+// collectStrings gathers every string inside a decoded YAML value
+func collectStrings(value interface{}, texts *[]string) {
+	switch v := value.(type) {
+	case string:
+		*texts = append(*texts, v)
+	case []interface{}:
+		for _, item := range v {
+			collectStrings(item, texts)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			collectStrings(item, texts)
+		}
+	}
+}
+
+// IsKnownContext reports whether name is a context GitHub provides
+func IsKnownContext(name string) bool {
+	for _, known := range KnownContexts {
+		if strings.EqualFold(known, name) {
+			return true
+		}
+	}
+	return false
+}
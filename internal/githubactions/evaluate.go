@@ -0,0 +1,624 @@
+package githubactions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// unknownValue marks data that is not available without running the workflow,
+// such as secrets or step outputs
+type unknownValue struct{}
+
+// Unknown is the result of evaluating an expression whose value cannot be
+// determined statically
+var Unknown interface{} = unknownValue{}
+
+// IsUnknown reports whether value is Unknown
+func IsUnknown(value interface{}) bool {
+	_, ok := value.(unknownValue)
+	return ok
+}
+
+// filteredArray is the result of a .* filter; dereferencing it maps over its items
+type filteredArray []interface{}
+
+// EvaluationContext supplies the context values and job status an expression is evaluated with
+type EvaluationContext struct {
+	// Contexts maps context names (github, env, needs, ...) to their values.
+	// Missing contexts and missing object keys evaluate to Unknown.
+	Contexts map[string]interface{}
+	// Status is the current job status: success (the default), failure or cancelled
+	Status string
+}
+
+// StatusFunctions are the job status check functions
+var StatusFunctions = []string{"success", "always", "failure", "cancelled"}
+
+// EvaluateExpression parses and evaluates an expression body
+func EvaluateExpression(expression string, ctx *EvaluationContext) (interface{}, error) {
+	node, err := ParseExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(node, ctx)
+}
+
+// EvaluateCondition evaluates an if: condition. Like GitHub, a condition
+// without a status check function is implicitly success() && (condition).
+// The result is Unknown when the condition depends on unavailable data.
+func EvaluateCondition(condition string, ctx *EvaluationContext) (interface{}, error) {
+	node, err := ParseExpression(ConditionExpression(condition))
+	if err != nil {
+		return nil, err
+	}
+	if !UsesStatusFunction(node) {
+		node = Binary{Operator: "&&", Left: Call{Name: "success"}, Right: node}
+	}
+
+	value, err := Evaluate(node, ctx)
+	if err != nil || IsUnknown(value) {
+		return value, err
+	}
+	return IsTruthy(value), nil
+}
+
+// UsesStatusFunction reports whether an expression calls success(), always(),
+// failure() or cancelled()
+func UsesStatusFunction(node Node) bool {
+	found := false
+	walkExpression(node, func(n Node) {
+		if call, ok := n.(Call); ok {
+			for _, name := range StatusFunctions {
+				if strings.EqualFold(call.Name, name) {
+					found = true
+				}
+			}
+		}
+	})
+	return found
+}
+
+// Evaluate evaluates a parsed expression
+func Evaluate(node Node, ctx *EvaluationContext) (interface{}, error) {
+	if ctx == nil {
+		ctx = &EvaluationContext{}
+	}
+	value, err := evaluate(node, ctx)
+	if filtered, ok := value.(filteredArray); ok {
+		value = []interface{}(filtered)
+	}
+	return value, err
+}
+
+func evaluate(node Node, ctx *EvaluationContext) (interface{}, error) {
+	switch n := node.(type) {
+	case Literal:
+		return n.Value, nil
+
+	case Identifier:
+		for name, value := range ctx.Contexts {
+			if strings.EqualFold(name, n.Name) {
+				return normalizeValue(value), nil
+			}
+		}
+		return Unknown, nil
+
+	case Property:
+		target, err := evaluate(n.Target, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return dereference(target, n.Name), nil
+
+	case Index:
+		target, err := evaluate(n.Target, ctx)
+		if err != nil {
+			return nil, err
+		}
+		index, err := evaluate(n.Index, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if IsUnknown(index) {
+			return Unknown, nil
+		}
+		return dereference(target, index), nil
+
+	case Filter:
+		target, err := evaluate(n.Target, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return filter(target), nil
+
+	case Unary:
+		operand, err := evaluate(n.Operand, ctx)
+		if err != nil || IsUnknown(operand) {
+			return operand, err
+		}
+		return !IsTruthy(operand), nil
+
+	case Binary:
+		return evaluateBinary(n, ctx)
+
+	case Call:
+		return evaluateCall(n, ctx)
+	}
+
+	return nil, fmt.Errorf("unsupported expression %s", node)
+}
+
+// evaluateBinary evaluates logical operators with short-circuiting and
+// comparisons with GitHub's loose equality
+func evaluateBinary(n Binary, ctx *EvaluationContext) (interface{}, error) {
+	left, err := evaluate(n.Left, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Operator {
+	case "&&", "||":
+		// && and || return one of their operands, not a boolean
+		stop := n.Operator == "||"
+		if !IsUnknown(left) && IsTruthy(left) == stop {
+			return left, nil
+		}
+		right, err := evaluate(n.Right, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if IsUnknown(left) && (IsUnknown(right) || IsTruthy(right) != stop) {
+			// The result depends on the unknown left operand
+			return Unknown, nil
+		}
+		return right, nil
+	}
+
+	right, err := evaluate(n.Right, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if IsUnknown(left) || IsUnknown(right) {
+		return Unknown, nil
+	}
+
+	switch n.Operator {
+	case "==":
+		return looseEqual(left, right), nil
+	case "!=":
+		return !looseEqual(left, right), nil
+	}
+
+	order, comparable := compare(left, right)
+	if !comparable {
+		return false, nil
+	}
+	switch n.Operator {
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	case ">=":
+		return order >= 0, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %s", n.Operator)
+}
+
+// evaluateCall evaluates the built-in functions
+func evaluateCall(n Call, ctx *EvaluationContext) (interface{}, error) {
+	name := strings.ToLower(n.Name)
+
+	switch name {
+	case "success", "always", "failure", "cancelled":
+		if len(n.Args) > 0 {
+			return nil, fmt.Errorf("%s() takes no arguments", n.Name)
+		}
+		status := ctx.Status
+		if status == "" {
+			status = "success"
+		}
+		return name == "always" || name == status, nil
+	}
+
+	var args []interface{}
+	for _, arg := range n.Args {
+		value, err := evaluate(arg, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if filtered, ok := value.(filteredArray); ok {
+			value = []interface{}(filtered)
+		}
+		args = append(args, value)
+	}
+
+	minArgs, maxArgs, known := functionArity(name)
+	if !known {
+		return nil, fmt.Errorf("unknown function %s", n.Name)
+	}
+	if len(args) < minArgs || (maxArgs >= 0 && len(args) > maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments to %s", n.Name)
+	}
+
+	// hashFiles reads the workspace at run time
+	if name == "hashfiles" {
+		return Unknown, nil
+	}
+	for _, arg := range args {
+		if IsUnknown(arg) {
+			return Unknown, nil
+		}
+	}
+
+	switch name {
+	case "contains":
+		if items, ok := args[0].([]interface{}); ok {
+			for _, item := range items {
+				if looseEqual(item, args[1]) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return strings.Contains(strings.ToLower(ToString(args[0])), strings.ToLower(ToString(args[1]))), nil
+
+	case "startswith":
+		return strings.HasPrefix(strings.ToLower(ToString(args[0])), strings.ToLower(ToString(args[1]))), nil
+
+	case "endswith":
+		return strings.HasSuffix(strings.ToLower(ToString(args[0])), strings.ToLower(ToString(args[1]))), nil
+
+	case "format":
+		return formatString(ToString(args[0]), args[1:])
+
+	case "join":
+		separator := ","
+		if len(args) > 1 {
+			separator = ToString(args[1])
+		}
+		items, ok := args[0].([]interface{})
+		if !ok {
+			return ToString(args[0]), nil
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = ToString(item)
+		}
+		return strings.Join(parts, separator), nil
+
+	case "tojson":
+		data, err := json.MarshalIndent(args[0], "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("toJSON failed: %w", err)
+		}
+		return string(data), nil
+
+	case "fromjson":
+		var value interface{}
+		if err := json.Unmarshal([]byte(ToString(args[0])), &value); err != nil {
+			return nil, fmt.Errorf("fromJSON failed: %w", err)
+		}
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unknown function %s", n.Name)
+}
+
+// IsKnownFunction reports whether name is a built-in expression function
+func IsKnownFunction(name string) bool {
+	for _, status := range StatusFunctions {
+		if strings.EqualFold(status, name) {
+			return true
+		}
+	}
+	_, _, known := functionArity(strings.ToLower(name))
+	return known
+}
+
+// functionArity returns the argument bounds of a built-in function; max is -1 when unbounded
+func functionArity(name string) (min, max int, known bool) {
+	switch name {
+	case "contains", "startswith", "endswith":
+		return 2, 2, true
+	case "format", "hashfiles":
+		return 1, -1, true
+	case "join":
+		return 1, 2, true
+	case "tojson", "fromjson":
+		return 1, 1, true
+	}
+	return 0, 0, false
+}
+
+// formatString implements format('{0} {1}', ...) with {{ and }} escapes
+func formatString(format string, args []interface{}) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '{' && i+1 < len(format) && format[i+1] == '{':
+			sb.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(format) && format[i+1] == '}':
+			sb.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("format string %q has an unclosed '{'", format)
+			}
+			index, err := strconv.Atoi(format[i+1 : i+end])
+			if err != nil || index < 0 || index >= len(args) {
+				return "", fmt.Errorf("format string %q references a missing argument", format)
+			}
+			sb.WriteString(ToString(args[index]))
+			i += end
+		case c == '}':
+			return "", fmt.Errorf("format string %q has an unmatched '}'", format)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// dereference looks up an object key or array index. Keys are case-insensitive;
+// missing keys are Unknown because static contexts are never complete.
+func dereference(target, key interface{}) interface{} {
+	if IsUnknown(target) {
+		return Unknown
+	}
+
+	if filtered, ok := target.(filteredArray); ok {
+		var result filteredArray
+		for _, item := range filtered {
+			value := dereference(item, key)
+			if value != nil && !IsUnknown(value) {
+				result = append(result, value)
+			}
+		}
+		return result
+	}
+
+	switch t := target.(type) {
+	case map[string]interface{}:
+		name := ToString(key)
+		if value, ok := t[name]; ok {
+			return normalizeValue(value)
+		}
+		for candidate, value := range t {
+			if strings.EqualFold(candidate, name) {
+				return normalizeValue(value)
+			}
+		}
+		return Unknown
+
+	case []interface{}:
+		index := ToNumber(key)
+		if math.IsNaN(index) || index < 0 || int(index) >= len(t) {
+			return nil
+		}
+		return normalizeValue(t[int(index)])
+	}
+
+	return nil
+}
+
+// filter implements .* over an object's values or an array's items
+func filter(target interface{}) interface{} {
+	switch t := target.(type) {
+	case unknownValue:
+		return Unknown
+	case filteredArray:
+		var result filteredArray
+		for _, item := range t {
+			if nested, ok := filter(item).(filteredArray); ok {
+				result = append(result, nested...)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make(filteredArray, 0, len(t))
+		for _, item := range t {
+			result = append(result, normalizeValue(item))
+		}
+		return result
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := make(filteredArray, 0, len(t))
+		for _, key := range keys {
+			result = append(result, normalizeValue(t[key]))
+		}
+		return result
+	}
+	return filteredArray{}
+}
+
+// normalizeValue converts Go values from YAML or callers into expression values
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case map[string]string:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = item
+		}
+		return object
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	}
+	return value
+}
+
+// IsTruthy applies GitHub's truthiness: false, 0, NaN, ” and null are falsy
+func IsTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case unknownValue:
+		return false
+	}
+	return true
+}
+
+// ToString converts a value to a string the way expressions interpolate it
+func ToString(value interface{}) string {
+	switch v := normalizeValue(value).(type) {
+	case nil, unknownValue:
+		return ""
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case []interface{}, filteredArray:
+		return "Array"
+	case map[string]interface{}:
+		return "Object"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// ToNumber converts a value to a number: null is 0, booleans are 0 or 1,
+// strings are parsed and everything else is NaN
+func ToNumber(value interface{}) float64 {
+	switch v := normalizeValue(value).(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if trimmed == "" {
+			return 0
+		}
+		number, err := parseNumber(trimmed)
+		if err != nil {
+			return math.NaN()
+		}
+		return number
+	}
+	return math.NaN()
+}
+
+// formatNumber renders a number without a trailing .0 for integers
+func formatNumber(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// looseEqual compares values, converting to numbers when the types differ.
+// Strings compare case-insensitively; objects and arrays are never equal.
+func looseEqual(left, right interface{}) bool {
+	left, right = normalizeValue(left), normalizeValue(right)
+
+	switch l := left.(type) {
+	case nil:
+		if right == nil {
+			return true
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			return l == r
+		}
+	case float64:
+		if r, ok := right.(float64); ok {
+			return l == r
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.EqualFold(l, r)
+		}
+	case []interface{}, map[string]interface{}, filteredArray:
+		return false
+	}
+
+	return ToNumber(left) == ToNumber(right)
+}
+
+// compare orders two values for <, <=, > and >=. Strings compare
+// case-insensitively; mixed types compare as numbers.
+func compare(left, right interface{}) (int, bool) {
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return strings.Compare(strings.ToLower(l), strings.ToLower(r)), true
+		}
+	}
+
+	l, r := ToNumber(left), ToNumber(right)
+	if math.IsNaN(l) || math.IsNaN(r) {
+		return 0, false
+	}
+	switch {
+	case l < r:
+		return -1, true
+	case l > r:
+		return 1, true
+	}
+	return 0, true
+}
+
+// walkExpression calls visit for every node of an expression
+func walkExpression(node Node, visit func(Node)) {
+	visit(node)
+	switch n := node.(type) {
+	case Property:
+		walkExpression(n.Target, visit)
+	case Index:
+		walkExpression(n.Target, visit)
+		walkExpression(n.Index, visit)
+	case Filter:
+		walkExpression(n.Target, visit)
+	case Unary:
+		walkExpression(n.Operand, visit)
+	case Binary:
+		walkExpression(n.Left, visit)
+		walkExpression(n.Right, visit)
+	case Call:
+		for _, arg := range n.Args {
+			walkExpression(arg, visit)
+		}
+	}
+}
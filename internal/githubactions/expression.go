@@ -0,0 +1,511 @@
+package githubactions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expression delimiters in workflow text. Like GitHub's own scanner, an
+// expression ends at the first "}}", even inside a string literal.
+const (
+	expressionOpen  = "${{"
+	expressionClose = "}}"
+)
+
+// TokenKind classifies expression tokens
+type TokenKind int
+
+// Token kinds
+const (
+	TokenEOF TokenKind = iota
+	TokenIdentifier
+	TokenString
+	TokenNumber
+	TokenBoolean
+	TokenNull
+	TokenOperator
+	TokenLeftParen
+	TokenRightParen
+	TokenLeftBracket
+	TokenRightBracket
+	TokenDot
+	TokenComma
+	TokenStar
+)
+
+// Token is a lexical token of a GitHub Actions expression
+type Token struct {
+	Kind  TokenKind
+	Text  string
+	Value interface{} // Parsed value of string, number, boolean and null tokens
+	Pos   int
+}
+
+// Node is a parsed expression
+type Node interface {
+	String() string
+}
+
+// Literal is a string, number, boolean or null literal
+type Literal struct {
+	Value interface{}
+}
+
+// Identifier is a context name such as github, env or secrets
+type Identifier struct {
+	Name string
+}
+
+// Property is a .name dereference
+type Property struct {
+	Target Node
+	Name   string
+}
+
+// Index is a [expr] dereference
+type Index struct {
+	Target Node
+	Index  Node
+}
+
+// Filter is a .* object filter
+type Filter struct {
+	Target Node
+}
+
+// Unary is the ! operator
+type Unary struct {
+	Operator string
+	Operand  Node
+}
+
+// Binary is a comparison or logical operator
+type Binary struct {
+	Operator string
+	Left     Node
+	Right    Node
+}
+
+// Call is a function call
+type Call struct {
+	Name string
+	Args []Node
+}
+
+func (n Literal) String() string {
+	switch v := n.Value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return formatNumber(n.Value)
+}
+
+func (n Identifier) String() string { return n.Name }
+func (n Property) String() string   { return n.Target.String() + "." + n.Name }
+func (n Index) String() string      { return n.Target.String() + "[" + n.Index.String() + "]" }
+func (n Filter) String() string     { return n.Target.String() + ".*" }
+func (n Unary) String() string      { return n.Operator + n.Operand.String() }
+
+func (n Binary) String() string {
+	return "(" + n.Left.String() + " " + n.Operator + " " + n.Right.String() + ")"
+}
+
+func (n Call) String() string {
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = arg.String()
+	}
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Lex splits an expression into tokens
+func Lex(expression string) ([]Token, error) {
+	var tokens []Token
+
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for {
+				if j >= len(expression) {
+					return nil, fmt.Errorf("unterminated string at position %d", i)
+				}
+				if expression[j] == '\'' {
+					if j+1 < len(expression) && expression[j+1] == '\'' {
+						sb.WriteByte('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				sb.WriteByte(expression[j])
+				j++
+			}
+			tokens = append(tokens, Token{Kind: TokenString, Text: expression[i : j+1], Value: sb.String(), Pos: i})
+			i = j + 1
+
+		case isDigit(c) || (c == '-' && i+1 < len(expression) && (isDigit(expression[i+1]) || expression[i+1] == '.')) ||
+			(c == '.' && i+1 < len(expression) && isDigit(expression[i+1]) && !followsOperand(tokens)):
+			j := i + 1
+			for j < len(expression) && (isIdentifierChar(expression[j]) || expression[j] == '.' ||
+				((expression[j] == '+' || expression[j] == '-') && (expression[j-1] == 'e' || expression[j-1] == 'E'))) {
+				j++
+			}
+			text := expression[i:j]
+			value, err := parseNumber(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, i)
+			}
+			tokens = append(tokens, Token{Kind: TokenNumber, Text: text, Value: value, Pos: i})
+			i = j
+
+		case isIdentifierStart(c):
+			j := i + 1
+			for j < len(expression) && isIdentifierChar(expression[j]) {
+				j++
+			}
+			text := expression[i:j]
+			token := Token{Kind: TokenIdentifier, Text: text, Pos: i}
+			switch {
+			case followsDot(tokens):
+				// Property names may be keywords: github.event.null
+			case text == "true" || text == "false":
+				token.Kind, token.Value = TokenBoolean, text == "true"
+			case text == "null":
+				token.Kind = TokenNull
+			case text == "NaN" || text == "Infinity":
+				token.Kind, token.Value = TokenNumber, mustParseNumber(text)
+			}
+			tokens = append(tokens, token)
+			i = j
+
+		default:
+			kind, text := TokenOperator, string(c)
+			switch c {
+			case '(':
+				kind = TokenLeftParen
+			case ')':
+				kind = TokenRightParen
+			case '[':
+				kind = TokenLeftBracket
+			case ']':
+				kind = TokenRightBracket
+			case '.':
+				kind = TokenDot
+			case ',':
+				kind = TokenComma
+			case '*':
+				kind = TokenStar
+			case '=', '!', '<', '>':
+				if i+1 < len(expression) && expression[i+1] == '=' {
+					text += "="
+				} else if c == '=' {
+					return nil, fmt.Errorf("unexpected '=' at position %d", i)
+				}
+			case '&', '|':
+				if i+1 >= len(expression) || expression[i+1] != c {
+					return nil, fmt.Errorf("unexpected %q at position %d", c, i)
+				}
+				text += string(c)
+			default:
+				return nil, fmt.Errorf("unexpected %q at position %d", c, i)
+			}
+			tokens = append(tokens, Token{Kind: kind, Text: text, Pos: i})
+			i += len(text)
+		}
+	}
+
+	return append(tokens, Token{Kind: TokenEOF, Pos: len(expression)}), nil
+}
+
+// ParseExpression parses the body of a ${{ }} expression
+func ParseExpression(expression string) (Node, error) {
+	tokens, err := Lex(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.Kind != TokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", token.Text, token.Pos)
+	}
+	return node, nil
+}
+
+// ExtractExpressions returns the bodies of every ${{ }} expression in text
+func ExtractExpressions(text string) []string {
+	var expressions []string
+	for {
+		start := strings.Index(text, expressionOpen)
+		if start < 0 {
+			return expressions
+		}
+		end := strings.Index(text[start:], expressionClose)
+		if end < 0 {
+			return expressions
+		}
+		expressions = append(expressions, strings.TrimSpace(text[start+len(expressionOpen):start+end]))
+		text = text[start+end+len(expressionClose):]
+	}
+}
+
+// ConditionExpression returns the expression of an if: condition, which may
+// be written with or without ${{ }}
+func ConditionExpression(condition string) string {
+	trimmed := strings.TrimSpace(condition)
+	if strings.HasPrefix(trimmed, expressionOpen) && strings.HasSuffix(trimmed, expressionClose) &&
+		strings.Count(trimmed, expressionOpen) == 1 {
+		return strings.TrimSpace(trimmed[len(expressionOpen) : len(trimmed)-len(expressionClose)])
+	}
+	return trimmed
+}
+
+// expressionParser is a recursive descent parser. Precedence from lowest:
+// ||, &&, == !=, < <= > >=, !, then property access and calls.
+type expressionParser struct {
+	tokens []Token
+	pos    int
+}
+
+func (p *expressionParser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() Token {
+	token := p.tokens[p.pos]
+	if token.Kind != TokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *expressionParser) expect(kind TokenKind, what string) (Token, error) {
+	token := p.next()
+	if token.Kind != kind {
+		return token, fmt.Errorf("expected %s at position %d", what, token.Pos)
+	}
+	return token, nil
+}
+
+func (p *expressionParser) parseOr() (Node, error) {
+	return p.parseBinary([]string{"||"}, p.parseAnd)
+}
+
+func (p *expressionParser) parseAnd() (Node, error) {
+	return p.parseBinary([]string{"&&"}, p.parseEquality)
+}
+
+func (p *expressionParser) parseEquality() (Node, error) {
+	return p.parseBinary([]string{"==", "!="}, p.parseComparison)
+}
+
+func (p *expressionParser) parseComparison() (Node, error) {
+	return p.parseBinary([]string{"<", "<=", ">", ">="}, p.parseUnary)
+}
+
+// parseBinary parses a left-associative chain of the given operators
+func (p *expressionParser) parseBinary(operators []string, operand func() (Node, error)) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek()
+		if token.Kind != TokenOperator || !containsOperator(operators, token.Text) {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = Binary{Operator: token.Text, Left: left, Right: right}
+	}
+}
+
+func (p *expressionParser) parseUnary() (Node, error) {
+	if token := p.peek(); token.Kind == TokenOperator && token.Text == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Unary{Operator: "!", Operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *expressionParser) parsePostfix() (Node, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().Kind {
+		case TokenDot:
+			p.next()
+			token := p.next()
+			switch token.Kind {
+			case TokenStar:
+				node = Filter{Target: node}
+			case TokenIdentifier:
+				node = Property{Target: node, Name: token.Text}
+			default:
+				return nil, fmt.Errorf("expected property name at position %d", token.Pos)
+			}
+		case TokenLeftBracket:
+			p.next()
+			if p.peek().Kind == TokenStar {
+				p.next()
+				node = Filter{Target: node}
+			} else {
+				index, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				node = Index{Target: node, Index: index}
+			}
+			if _, err := p.expect(TokenRightBracket, "']'"); err != nil {
+				return nil, err
+			}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *expressionParser) parsePrimary() (Node, error) {
+	token := p.next()
+	switch token.Kind {
+	case TokenString, TokenNumber, TokenBoolean:
+		return Literal{Value: token.Value}, nil
+	case TokenNull:
+		return Literal{}, nil
+	case TokenLeftParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(TokenRightParen, "')'"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case TokenIdentifier:
+		if p.peek().Kind != TokenLeftParen {
+			return Identifier{Name: token.Text}, nil
+		}
+		p.next()
+		call := Call{Name: token.Text}
+		if p.peek().Kind == TokenRightParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			separator := p.next()
+			if separator.Kind == TokenRightParen {
+				return call, nil
+			}
+			if separator.Kind != TokenComma {
+				return nil, fmt.Errorf("expected ',' or ')' at position %d", separator.Pos)
+			}
+		}
+	case TokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", token.Text, token.Pos)
+}
+
+// followsDot reports whether the previous token is a property dot
+func followsDot(tokens []Token) bool {
+	return len(tokens) > 0 && tokens[len(tokens)-1].Kind == TokenDot
+}
+
+// followsOperand reports whether a '.' continues a dereference rather than starting a number
+func followsOperand(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[len(tokens)-1].Kind {
+	case TokenIdentifier, TokenRightParen, TokenRightBracket:
+		return true
+	}
+	return false
+}
+
+func containsOperator(operators []string, operator string) bool {
+	for _, candidate := range operators {
+		if candidate == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierChar(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c) || c == '-'
+}
+
+// parseNumber parses decimal, hexadecimal, octal and exponent literals
+func parseNumber(text string) (float64, error) {
+	negative := strings.HasPrefix(text, "-")
+	body := strings.TrimPrefix(text, "-")
+
+	var value float64
+	switch {
+	case strings.HasPrefix(body, "0x") || strings.HasPrefix(body, "0X"):
+		n, err := strconv.ParseInt(body[2:], 16, 64)
+		if err != nil {
+			return 0, err
+		}
+		value = float64(n)
+	case strings.HasPrefix(body, "0o") || strings.HasPrefix(body, "0O"):
+		n, err := strconv.ParseInt(body[2:], 8, 64)
+		if err != nil {
+			return 0, err
+		}
+		value = float64(n)
+	default:
+		n, err := strconv.ParseFloat(body, 64)
+		if err != nil {
+			return 0, err
+		}
+		value = n
+	}
+
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func mustParseNumber(text string) float64 {
+	value, _ := strconv.ParseFloat(text, 64)
+	return value
+}
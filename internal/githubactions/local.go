@@ -0,0 +1,525 @@
+package githubactions
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// maxLocalDepth limits how deeply local actions and reusable workflows may nest
+const maxLocalDepth = 16
+
+// Kinds of local references
+const (
+	LocalKindAction   = "action"
+	LocalKindWorkflow = "workflow"
+)
+
+// inputRefRegex matches ${{ inputs.name }} references
+var inputRefRegex = regexp.MustCompile(`\$\{\{\s*inputs\.([A-Za-z0-9_\-]+)\s*\}\}`)
+
+// Input is an input declared by an action or a workflow_call trigger
+type Input struct {
+	Description string      `yaml:"description,omitempty"`
+	Required    bool        `yaml:"required,omitempty"`
+	Default     interface{} `yaml:"default,omitempty"`
+	Type        string      `yaml:"type,omitempty"`
+}
+
+// Output is an output declared by an action or a workflow_call trigger
+type Output struct {
+	Description string `yaml:"description,omitempty"`
+	Value       string `yaml:"value,omitempty"`
+}
+
+// LocalAction is an action defined by an action.yml inside the repository
+type LocalAction struct {
+	Path        string // Repository-relative directory, e.g. .github/actions/setup
+	File        string // Repository-relative path of the action.yml
+	Name        string
+	Description string
+	Using       string // composite, docker, node20, ...
+	Image       string // runs.image of docker actions
+	Main        string // runs.main of JavaScript actions
+	Inputs      map[string]Input
+	Outputs     map[string]Output
+	Steps       []Step   // Steps of composite actions
+	UsedBy      []string // "workflow / job" entries, filled in by CollectLocalActions
+}
+
+// IsComposite reports whether the action runs steps
+func (a *LocalAction) IsComposite() bool {
+	return a.Using == "composite"
+}
+
+// actionDefinition is the on-disk form of action.yml
+type actionDefinition struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Inputs      map[string]Input  `yaml:"inputs"`
+	Outputs     map[string]Output `yaml:"outputs"`
+	Runs        struct {
+		Using string `yaml:"using"`
+		Image string `yaml:"image"`
+		Main  string `yaml:"main"`
+		Steps []Step `yaml:"steps"`
+	} `yaml:"runs"`
+}
+
+// ReusableWorkflow is a local workflow called through jobs.<id>.uses
+type ReusableWorkflow struct {
+	Path     string // Repository-relative path, e.g. .github/workflows/deploy.yml
+	Workflow *Workflow
+	Inputs   map[string]Input
+	Outputs  map[string]Output
+	Secrets  []string
+}
+
+// workflowCallTrigger is the on.workflow_call configuration
+type workflowCallTrigger struct {
+	Inputs  map[string]Input       `yaml:"inputs"`
+	Outputs map[string]Output      `yaml:"outputs"`
+	Secrets map[string]interface{} `yaml:"secrets"`
+}
+
+// ExpandedStep is a job step after local composite actions and reusable
+// workflows have been inlined
+type ExpandedStep struct {
+	Step
+	Provenance []string // Local actions and workflow jobs the step was inlined from, outermost first
+}
+
+// Origin describes where an expanded step came from
+func (s ExpandedStep) Origin() string {
+	return strings.Join(s.Provenance, " → ")
+}
+
+// LocalCall is a job's use of a local action or reusable workflow
+type LocalCall struct {
+	Uses       string            // Reference as written, e.g. ./.github/actions/setup
+	Kind       string            // LocalKindAction or LocalKindWorkflow
+	Using      string            // Action runtime: composite, docker, node20, ...
+	Resolved   bool              // Whether the definition was found
+	Inputs     map[string]string // Effective input values after defaults
+	Missing    []string          // Required inputs without a value
+	Outputs    []string          // Outputs the call provides
+	Provenance []string          // Calls it is nested in, outermost first
+}
+
+// IsLocalReference reports whether a uses: value refers to the repository itself
+func IsLocalReference(uses string) bool {
+	return strings.HasPrefix(uses, "./")
+}
+
+// localPath normalizes a local uses: value into a repository-relative path
+func localPath(uses string) string {
+	return path.Clean(strings.TrimPrefix(uses, "./"))
+}
+
+// RepositoryRoot returns the repository root of a workflow file under .github/workflows
+func RepositoryRoot(workflowPath string) string {
+	dir := filepath.Dir(workflowPath)
+	if filepath.Base(dir) == "workflows" && filepath.Base(filepath.Dir(dir)) == ".github" {
+		return filepath.Dir(filepath.Dir(dir))
+	}
+	return dir
+}
+
+// ResolveLocalReferences loads the local actions and reusable workflows a
+// workflow refers to, including nested ones, into the workflow's
+// LocalActions and ReusableWorkflows maps
+func ResolveLocalReferences(workflow *Workflow, rootPath string) {
+	workflow.LocalActions = make(map[string]*LocalAction)
+	workflow.ReusableWorkflows = make(map[string]*ReusableWorkflow)
+
+	resolver := &localResolver{root: rootPath, workflow: workflow, parser: NewParser()}
+	resolver.resolveJobs(workflow.Jobs, 0)
+}
+
+// localResolver loads local definitions into the root workflow
+type localResolver struct {
+	root     string
+	workflow *Workflow
+	parser   *Parser
+}
+
+func (r *localResolver) resolveJobs(jobs map[string]Job, depth int) {
+	for _, job := range jobs {
+		if IsLocalReference(job.Uses) {
+			r.resolveWorkflow(job.Uses, depth)
+		}
+		r.resolveSteps(job.Steps, depth)
+	}
+}
+
+func (r *localResolver) resolveSteps(steps []Step, depth int) {
+	for _, step := range steps {
+		if IsLocalReference(step.Uses) {
+			r.resolveAction(step.Uses, depth)
+		}
+	}
+}
+
+func (r *localResolver) resolveWorkflow(uses string, depth int) {
+	key := localPath(uses)
+	if _, seen := r.workflow.ReusableWorkflows[key]; seen || depth >= maxLocalDepth {
+		return
+	}
+
+	called, err := r.parser.ParseFile(filepath.Join(r.root, filepath.FromSlash(key)))
+	if err != nil {
+		shared.GetLogger().Warn("GitHubActions", "Failed to load reusable workflow", map[string]interface{}{
+			"workflow": key,
+			"error":    err.Error(),
+		})
+		r.workflow.ReusableWorkflows[key] = nil
+		return
+	}
+
+	reusable := &ReusableWorkflow{Path: key, Workflow: called}
+	if trigger, err := decodeWorkflowCall(called.On); err == nil && trigger != nil {
+		reusable.Inputs = trigger.Inputs
+		reusable.Outputs = trigger.Outputs
+		for name := range trigger.Secrets {
+			reusable.Secrets = append(reusable.Secrets, name)
+		}
+		sort.Strings(reusable.Secrets)
+	}
+
+	r.workflow.ReusableWorkflows[key] = reusable
+	r.resolveJobs(called.Jobs, depth+1)
+}
+
+func (r *localResolver) resolveAction(uses string, depth int) {
+	key := localPath(uses)
+	if _, seen := r.workflow.LocalActions[key]; seen || depth >= maxLocalDepth {
+		return
+	}
+
+	action, err := loadLocalAction(r.root, key)
+	if err != nil {
+		shared.GetLogger().Warn("GitHubActions", "Failed to load local action", map[string]interface{}{
+			"action": key,
+			"error":  err.Error(),
+		})
+		r.workflow.LocalActions[key] = nil
+		return
+	}
+
+	r.workflow.LocalActions[key] = action
+	r.resolveSteps(action.Steps, depth+1)
+}
+
+// loadLocalAction reads action.yml or action.yaml from an action directory
+func loadLocalAction(root, dir string) (*LocalAction, error) {
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		file := path.Join(dir, name)
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+
+		var definition actionDefinition
+		if err := yaml.Unmarshal(data, &definition); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		return &LocalAction{
+			Path:        dir,
+			File:        file,
+			Name:        definition.Name,
+			Description: definition.Description,
+			Using:       definition.Runs.Using,
+			Image:       definition.Runs.Image,
+			Main:        definition.Runs.Main,
+			Inputs:      definition.Inputs,
+			Outputs:     definition.Outputs,
+			Steps:       definition.Runs.Steps,
+		}, nil
+	}
+	return nil, fmt.Errorf("no action.yml or action.yaml in %s", dir)
+}
+
+// decodeWorkflowCall extracts on.workflow_call from a workflow trigger
+func decodeWorkflowCall(on interface{}) (*workflowCallTrigger, error) {
+	events, ok := on.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	raw, ok := events["workflow_call"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode workflow_call: %w", err)
+	}
+	var trigger workflowCallTrigger
+	if err := yaml.Unmarshal(data, &trigger); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow_call: %w", err)
+	}
+	return &trigger, nil
+}
+
+// ExpandJobSteps returns a job's effective steps with local composite actions
+// and reusable workflows inlined, and the local calls it makes
+func ExpandJobSteps(workflow *Workflow, job Job) ([]ExpandedStep, []LocalCall) {
+	expander := &stepExpander{workflow: workflow}
+	if IsLocalReference(job.Uses) {
+		expander.expandWorkflowCall(job.Uses, job.With, nil)
+	} else {
+		expander.expandSteps(job.Steps, nil)
+	}
+	return expander.steps, expander.calls
+}
+
+// stepExpander accumulates the expansion of one job
+type stepExpander struct {
+	workflow *Workflow
+	steps    []ExpandedStep
+	calls    []LocalCall
+}
+
+func (e *stepExpander) expandSteps(steps []Step, provenance []string) {
+	for _, step := range steps {
+		if !IsLocalReference(step.Uses) {
+			e.steps = append(e.steps, ExpandedStep{Step: step, Provenance: provenance})
+			continue
+		}
+
+		key := localPath(step.Uses)
+		action := e.workflow.LocalActions[key]
+		call := LocalCall{Uses: step.Uses, Kind: LocalKindAction, Provenance: provenance}
+		if action == nil {
+			e.calls = append(e.calls, call)
+			e.steps = append(e.steps, ExpandedStep{Step: step, Provenance: provenance})
+			continue
+		}
+
+		call.Resolved = true
+		call.Using = action.Using
+		call.Inputs, call.Missing = resolveInputs(action.Inputs, step.With)
+		call.Outputs = sortedOutputs(action.Outputs)
+		e.calls = append(e.calls, call)
+
+		if !action.IsComposite() || shared.ContainsString(provenance, key) || len(provenance) >= maxLocalDepth {
+			e.steps = append(e.steps, ExpandedStep{Step: step, Provenance: provenance})
+			continue
+		}
+
+		chain := append(append([]string(nil), provenance...), key)
+		body := make([]Step, len(action.Steps))
+		for i, inner := range action.Steps {
+			body[i] = substituteInputs(inner, call.Inputs)
+		}
+		e.expandSteps(body, chain)
+	}
+}
+
+func (e *stepExpander) expandWorkflowCall(uses string, with map[string]interface{}, provenance []string) {
+	key := localPath(uses)
+	reusable := e.workflow.ReusableWorkflows[key]
+	call := LocalCall{Uses: uses, Kind: LocalKindWorkflow, Provenance: provenance}
+	if reusable == nil {
+		e.calls = append(e.calls, call)
+		return
+	}
+
+	call.Resolved = true
+	call.Inputs, call.Missing = resolveInputs(reusable.Inputs, with)
+	call.Outputs = sortedOutputs(reusable.Outputs)
+	e.calls = append(e.calls, call)
+
+	if shared.ContainsString(provenance, key) || len(provenance) >= maxLocalDepth {
+		return
+	}
+
+	parser := NewParser()
+	for _, jobName := range orderedJobNames(reusable.Workflow, parser) {
+		job := reusable.Workflow.Jobs[jobName]
+		chain := append(append([]string(nil), provenance...), key+"#"+jobName)
+		if IsLocalReference(job.Uses) {
+			calledWith := make(map[string]interface{}, len(job.With))
+			for name, value := range job.With {
+				if text, ok := value.(string); ok {
+					value = substituteInputText(text, call.Inputs)
+				}
+				calledWith[name] = value
+			}
+			e.expandWorkflowCall(job.Uses, calledWith, chain)
+			continue
+		}
+
+		body := make([]Step, len(job.Steps))
+		for i, step := range job.Steps {
+			body[i] = substituteInputs(step, call.Inputs)
+		}
+		e.expandSteps(body, chain)
+	}
+}
+
+// orderedJobNames orders a workflow's jobs so that needed jobs come first,
+// keeping alphabetical order otherwise
+func orderedJobNames(workflow *Workflow, parser *Parser) []string {
+	names := make([]string, 0, len(workflow.Jobs))
+	for name := range workflow.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	placed := make(map[string]bool, len(names))
+	var ordered []string
+	for len(ordered) < len(names) {
+		progress := false
+		for _, name := range names {
+			if placed[name] {
+				continue
+			}
+			ready := true
+			for _, dep := range parser.GetJobDependencies(workflow.Jobs[name]) {
+				if _, known := workflow.Jobs[dep]; known && !placed[dep] {
+					ready = false
+				}
+			}
+			if ready {
+				placed[name] = true
+				ordered = append(ordered, name)
+				progress = true
+			}
+		}
+		if !progress {
+			// Cycles keep their alphabetical order
+			for _, name := range names {
+				if !placed[name] {
+					placed[name] = true
+					ordered = append(ordered, name)
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// resolveInputs applies declared defaults to the values passed with with:
+// and lists required inputs that have no value
+func resolveInputs(declared map[string]Input, with map[string]interface{}) (map[string]string, []string) {
+	values := make(map[string]string, len(declared))
+	var missing []string
+
+	for name, input := range declared {
+		if value, ok := with[name]; ok {
+			values[name] = formatMatrixValue(value)
+		} else if input.Default != nil {
+			values[name] = formatMatrixValue(input.Default)
+		} else if input.Required {
+			missing = append(missing, name)
+		}
+	}
+	// Undeclared inputs are still passed through
+	for name, value := range with {
+		if _, ok := values[name]; !ok {
+			values[name] = formatMatrixValue(value)
+		}
+	}
+
+	sort.Strings(missing)
+	return values, missing
+}
+
+// substituteInputs replaces ${{ inputs.x }} references in a step
+func substituteInputs(step Step, values map[string]string) Step {
+	step.Name = substituteInputText(step.Name, values)
+	step.Run = substituteInputText(step.Run, values)
+	step.If = substituteInputText(step.If, values)
+	step.WorkingDirectory = substituteInputText(step.WorkingDirectory, values)
+
+	if len(step.With) > 0 {
+		with := make(map[string]interface{}, len(step.With))
+		for name, value := range step.With {
+			if text, ok := value.(string); ok {
+				value = substituteInputText(text, values)
+			}
+			with[name] = value
+		}
+		step.With = with
+	}
+	if len(step.Env) > 0 {
+		env := make(map[string]string, len(step.Env))
+		for name, value := range step.Env {
+			env[name] = substituteInputText(value, values)
+		}
+		step.Env = env
+	}
+	return step
+}
+
+// substituteInputText replaces ${{ inputs.x }} references with their values,
+// leaving references to unknown inputs untouched
+func substituteInputText(text string, values map[string]string) string {
+	if !strings.Contains(text, "inputs.") {
+		return text
+	}
+	return inputRefRegex.ReplaceAllStringFunc(text, func(ref string) string {
+		if value, ok := values[inputRefRegex.FindStringSubmatch(ref)[1]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// sortedOutputs returns the names of declared outputs in order
+func sortedOutputs(outputs map[string]Output) []string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CollectLocalActions merges the local actions of every analyzed workflow and
+// records which jobs use them
+func CollectLocalActions(results []*AnalysisResult) []*LocalAction {
+	actions := make(map[string]*LocalAction)
+
+	for _, result := range results {
+		workflowName := result.Config.Name
+		if workflowName == "" {
+			workflowName = filepath.Base(result.FilePath)
+		}
+		for key, action := range result.Config.LocalActions {
+			if action != nil && actions[key] == nil {
+				merged := *action
+				merged.UsedBy = nil
+				actions[key] = &merged
+			}
+		}
+		for _, job := range result.Jobs {
+			for _, call := range job.LocalCalls {
+				action := actions[localPath(call.Uses)]
+				if call.Kind != LocalKindAction || action == nil {
+					continue
+				}
+				user := workflowName + " / " + job.Name
+				if !shared.ContainsString(action.UsedBy, user) {
+					action.UsedBy = append(action.UsedBy, user)
+				}
+			}
+		}
+	}
+
+	var list []*LocalAction
+	for _, action := range actions {
+		sort.Strings(action.UsedBy)
+		list = append(list, action)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
@@ -0,0 +1,183 @@
+package githubactions
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+)
+
+// Lower converts an analyzed workflow into the shared pipeline IR
+func Lower(result *AnalysisResult, source string) *ir.Pipeline {
+	parser := NewParser()
+	workflow := result.Config
+
+	pipeline := &ir.Pipeline{
+		Tool:   ir.ToolGitHubActions,
+		Name:   workflow.Name,
+		Source: source,
+		Env:    ir.EnvFromMap(workflow.Env),
+	}
+	if pipeline.Name == "" {
+		pipeline.Name = filepath.Base(result.FilePath)
+	}
+	pipeline.Triggers = lowerTriggers(workflow.On)
+
+	for jobName, job := range workflow.Jobs {
+		irJob := &ir.Job{
+			ID:     jobName,
+			Name:   job.Name,
+			Runner: parser.GetRunnerType(job),
+			Env:    ir.EnvFromMap(job.Env),
+		}
+
+		if image := containerImage(job.Container); image != "" {
+			irJob.Images = append(irJob.Images, ir.Image{Name: image, Role: ir.ImageContainer})
+		}
+		serviceNames := make([]string, 0, len(job.Services))
+		for serviceName := range job.Services {
+			serviceNames = append(serviceNames, serviceName)
+		}
+		sort.Strings(serviceNames)
+		for _, serviceName := range serviceNames {
+			irJob.Images = append(irJob.Images, ir.Image{Name: job.Services[serviceName].Image, Role: ir.ImageService})
+		}
+
+		for _, dep := range parser.GetJobDependencies(job) {
+			irJob.Needs = append(irJob.Needs, ir.Dependency{Target: dep, Kind: ir.DependencyNeeds})
+		}
+
+		// Job defaults override workflow defaults
+		defaultDir := workflow.Defaults.Run.WorkingDirectory
+		if job.Defaults.Run.WorkingDirectory != "" {
+			defaultDir = job.Defaults.Run.WorkingDirectory
+		}
+
+		steps, _ := ExpandJobSteps(workflow, job)
+		for _, step := range steps {
+			irStep := lowerStep(step.Step)
+			irStep.Origin = step.Origin()
+			// Defaults apply to the job's own run steps, not inlined ones
+			if irStep.Kind == ir.StepRun && irStep.WorkingDir == "" && len(step.Provenance) == 0 {
+				irStep.WorkingDir = defaultDir
+			}
+			irJob.Steps = append(irJob.Steps, irStep)
+		}
+
+		pipeline.Jobs = append(pipeline.Jobs, irJob)
+	}
+
+	pipeline.SortJobs()
+	return pipeline
+}
+
+// lowerStep converts a workflow step into an IR step
+func lowerStep(step Step) ir.Step {
+	irStep := ir.Step{
+		Name:       step.Name,
+		Uses:       step.Uses,
+		WorkingDir: step.WorkingDirectory,
+		Env:        ir.EnvFromMap(step.Env),
+	}
+
+	if step.Run != "" {
+		irStep.Kind = ir.StepRun
+		irStep.Commands = ir.SplitCommands(step.Run)
+		return irStep
+	}
+
+	action := strings.SplitN(step.Uses, "@", 2)[0]
+	switch {
+	case action == "actions/checkout":
+		irStep.Kind = ir.StepCheckout
+	case action == "actions/cache" || strings.HasPrefix(action, "actions/cache/"):
+		irStep.Kind = ir.StepCache
+	case action == "actions/upload-artifact" || action == "actions/download-artifact":
+		irStep.Kind = ir.StepArtifact
+	case step.Uses != "":
+		irStep.Kind = ir.StepAction
+	default:
+		irStep.Kind = ir.StepOther
+	}
+
+	return irStep
+}
+
+// containerImage returns the image of a job container definition
+func containerImage(container interface{}) string {
+	switch c := container.(type) {
+	case string:
+		return c
+	case map[string]interface{}:
+		if image, ok := c["image"].(string); ok {
+			return image
+		}
+	}
+	return ""
+}
+
+// lowerTriggers converts the workflow "on" field into IR triggers
+func lowerTriggers(on interface{}) []ir.Trigger {
+	var triggers []ir.Trigger
+
+	switch events := on.(type) {
+	case string:
+		triggers = append(triggers, ir.Trigger{Event: events})
+	case []interface{}:
+		for _, event := range events {
+			if name, ok := event.(string); ok {
+				triggers = append(triggers, ir.Trigger{Event: name})
+			}
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(events))
+		for name := range events {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if name == "schedule" {
+				if schedules, ok := events[name].([]interface{}); ok {
+					for _, schedule := range schedules {
+						if entry, ok := schedule.(map[string]interface{}); ok {
+							cron, _ := entry["cron"].(string)
+							triggers = append(triggers, ir.Trigger{Event: name, Schedule: cron})
+						}
+					}
+				}
+				continue
+			}
+
+			trigger := ir.Trigger{Event: name}
+			if config, ok := events[name].(map[string]interface{}); ok {
+				trigger.Branches = toStringSlice(config["branches"])
+				trigger.Tags = toStringSlice(config["tags"])
+				trigger.Paths = toStringSlice(config["paths"])
+			}
+			triggers = append(triggers, trigger)
+		}
+	}
+
+	return triggers
+}
+
+// toStringSlice converts a YAML string or list value into a string slice
+func toStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				items = append(items, str)
+			}
+		}
+		return items
+	case []string:
+		return v
+	}
+	return nil
+}
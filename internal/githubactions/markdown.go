@@ -95,6 +95,19 @@ Individual job analysis with go-task conversion opportunities:
 			sanitizeFilename(job.Name), sanitizeFilename(job.Name), job.Name)
 	}
 
+	// List local actions
+	if actions := CollectLocalActions(results); len(actions) > 0 {
+		content += `
+### Local Actions
+Actions defined in this repository:
+
+`
+		for _, action := range actions {
+			content += fmt.Sprintf("- [actions/%s.md](actions/%s.md) - `%s` (%s)\n",
+				actionPageName(action.Path), actionPageName(action.Path), action.Path, action.Using)
+		}
+	}
+
 	content += `
 ### Analysis Summaries
 
@@ -102,6 +115,8 @@ Individual job analysis with go-task conversion opportunities:
 - [🛠️ Actions Usage](summaries/actions-usage.md) - GitHub Actions used
 - [🏃 Runners Analysis](summaries/runners-analysis.md) - Runner usage patterns  
 - [⚡ Commands Analysis](summaries/commands-analysis.md) - Commands suitable for go-task
+- [🔑 Token Permissions](summaries/permissions.md) - GITHUB_TOKEN scopes used versus granted
+- [🔗 Supply-Chain Audit](summaries/supply-chain.md) - How actions and images are pinned
 
 ## 🎯 Build Refactoring Recommendations
 
@@ -232,13 +247,13 @@ func (g *MarkdownGenerator) GenerateWorkflowAnalysis(result *AnalysisResult) str
 	content += `
 ## 📋 Jobs Overview
 
-| Job | Runner | Steps | Commands | Actions |
-|-----|--------|-------|----------|---------|
+| Job | Runner | Instances | Steps | Commands | Actions |
+|-----|--------|-----------|-------|----------|---------|
 `
 
 	for _, job := range result.Jobs {
-		content += fmt.Sprintf("| [%s](../jobs/%s.md) | %s | %d | %d | %d |\n",
-			job.Name, sanitizeFilename(job.Name), job.Runner, 
+		content += fmt.Sprintf("| [%s](../jobs/%s.md) | %s | %s | %d | %d | %d |\n",
+			job.Name, sanitizeFilename(job.Name), job.Runner, matrixSizeLabel(job),
 			job.StepCount, len(job.RunCommands), len(job.ActionsUsed))
 	}
 
@@ -285,10 +300,114 @@ func (g *MarkdownGenerator) GenerateJobAnalysis(job JobAnalysis, workflowName st
 - **Run commands:** %d
 - **Actions used:** %d
 - **Dependencies:** %s
+- **Matrix instances:** %s
 
 `, job.Name, workflowName, job.Runner, job.EstimatedTime, job.CachingEnabled,
 		job.StepCount, len(job.RunCommands), len(job.ActionsUsed), 
-		strings.Join(job.Dependencies, ", "))
+		strings.Join(job.Dependencies, ", "), matrixSizeLabel(job))
+
+	if job.MatrixDynamic {
+		content += `## 🧮 Strategy Matrix
+
+The matrix is built from an expression at run time and cannot be expanded statically.
+
+`
+	} else if job.MatrixSize > 1 {
+		content += "## 🧮 Strategy Matrix\n\n"
+		if job.MaxParallel > 0 {
+			content += fmt.Sprintf("At most %d instances run at once (%d waves).\n\n", job.MaxParallel, job.MatrixWaves())
+		}
+		content += "| # | Matrix Values | Runner |\n|---|---------------|--------|\n"
+		for i, instance := range job.MatrixInstances {
+			content += fmt.Sprintf("| %d | %s | `%s` |\n", i+1, instance.Label(), instance.Runner)
+		}
+		content += "\n"
+
+		for i, instance := range job.MatrixInstances {
+			if len(instance.RunCommands) == 0 {
+				continue
+			}
+			content += fmt.Sprintf("<details>\n<summary>Instance %d commands (%s)</summary>\n\n", i+1, instance.Label())
+			for _, cmd := range instance.RunCommands {
+				content += fmt.Sprintf("- `%s`\n", cmd)
+			}
+			content += "\n</details>\n\n"
+		}
+	}
+
+	// Show local actions and reusable workflows with their inlined inputs and outputs
+	if len(job.LocalCalls) > 0 {
+		content += "## 🧩 Local Actions and Reusable Workflows\n\n"
+		if job.ReusableWorkflow != "" {
+			content += fmt.Sprintf("This job calls the reusable workflow `%s`; its jobs' steps are inlined below.\n\n", job.ReusableWorkflow)
+		}
+		for _, call := range job.LocalCalls {
+			content += fmt.Sprintf("### `%s` (%s)\n\n", call.Uses, localCallLabel(call))
+			if len(call.Provenance) > 0 {
+				content += fmt.Sprintf("Used inside %s.\n\n", strings.Join(call.Provenance, " → "))
+			}
+			if !call.Resolved {
+				content += "⚠️ The definition could not be found in the repository.\n\n"
+				continue
+			}
+			if call.Kind == LocalKindAction {
+				content += fmt.Sprintf("[Action details](../actions/%s.md)\n\n", actionPageName(localPath(call.Uses)))
+			}
+			if len(call.Inputs) > 0 {
+				content += "| Input | Value |\n|-------|-------|\n"
+				for _, name := range sortedStringKeys(call.Inputs) {
+					content += fmt.Sprintf("| %s | `%s` |\n", name, call.Inputs[name])
+				}
+				content += "\n"
+			}
+			if len(call.Missing) > 0 {
+				content += fmt.Sprintf("⚠️ **Missing required inputs:** %s\n\n", strings.Join(call.Missing, ", "))
+			}
+			if len(call.Outputs) > 0 {
+				content += fmt.Sprintf("**Outputs:** `%s`\n\n", strings.Join(call.Outputs, "`, `"))
+			}
+		}
+
+		content += "### Effective Steps\n\n| # | Step | Origin |\n|---|------|--------|\n"
+		for i, step := range job.ExpandedSteps {
+			origin := step.Origin()
+			if origin == "" {
+				origin = "job"
+			}
+			content += fmt.Sprintf("| %d | %s | %s |\n", i+1, expandedStepLabel(step), origin)
+		}
+		content += "\n"
+	}
+
+	// Show the contexts the job's expressions read
+	if len(job.Contexts) > 0 || len(job.ExpressionIssues) > 0 {
+		content += `## 🔐 Contexts Used
+
+| Context | References |
+|---------|------------|
+`
+		names := make([]string, 0, len(job.Contexts))
+		for name := range job.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			refs := make([]string, len(job.Contexts[name]))
+			for i, ref := range job.Contexts[name] {
+				refs[i] = "`" + ref + "`"
+			}
+			content += fmt.Sprintf("| %s | %s |\n", name, strings.Join(refs, ", "))
+		}
+		content += "\n"
+
+		if len(job.ExpressionIssues) > 0 {
+			content += "**⚠️ Expression issues:**\n\n"
+			for _, issue := range job.ExpressionIssues {
+				content += fmt.Sprintf("- `%s`\n", issue)
+			}
+			content += "\n"
+		}
+	}
 
 	// Show commands that could become go-task tasks
 	if len(job.RunCommands) > 0 {
@@ -340,6 +459,8 @@ tasks:
 		content += "\n"
 	}
 
+	content += generatePermissionsSection(job.Permissions)
+
 	// Show security issues
 	if len(job.SecurityIssues) > 0 {
 		content += `## 🚨 Security Issues
@@ -360,6 +481,360 @@ tasks:
 	return content
 }
 
+// generatePermissionsSection shows the token scopes a job uses and a minimal permissions block
+func generatePermissionsSection(permissions PermissionsAnalysis) string {
+	content := "## 🔑 Token Permissions\n\n"
+	switch permissions.DeclaredAt {
+	case PermissionsFromJob:
+		content += "Permissions are declared on the job.\n\n"
+	case PermissionsFromWorkflow:
+		content += "Permissions are inherited from the workflow.\n\n"
+	default:
+		content += "No `permissions:` block applies, so GITHUB_TOKEN gets the repository default (often write access to every scope).\n\n"
+	}
+
+	var scopes []string
+	for _, scope := range PermissionScopes {
+		if permissionRank(permissions.Required[scope]) > 0 || permissionRank(permissions.Declared[scope]) > 0 {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) > 0 {
+		content += "| Scope | Needed | Granted | Needed by |\n|-------|--------|---------|-----------|\n"
+		for _, scope := range scopes {
+			granted := "default"
+			if permissions.Declared != nil {
+				granted = orNone(permissions.Declared[scope])
+			}
+			content += fmt.Sprintf("| %s | %s | %s | %s |\n", scope, orNone(permissions.Required[scope]), granted,
+				strings.Join(permissions.ReasonsFor(scope), ", "))
+		}
+		content += "\n"
+	}
+
+	if len(permissions.OverGranted) > 0 {
+		content += "**Over-granted:** " + strings.Join(permissions.OverGranted, "; ") + "\n\n"
+	}
+	if len(permissions.Missing) > 0 {
+		content += "**⚠️ Missing:** " + strings.Join(permissions.Missing, "; ") + "\n\n"
+	}
+	if len(permissions.Unknown) > 0 {
+		content += fmt.Sprintf("Scopes of `%s` are not known; check their documentation.\n\n", strings.Join(permissions.Unknown, "`, `"))
+	}
+
+	content += "Suggested minimal block for this job:\n\n```yaml\n" + permissions.Suggested + "```\n\n"
+	return content
+}
+
+// GeneratePermissionsAnalysis summarizes token permissions across workflows
+func (g *MarkdownGenerator) GeneratePermissionsAnalysis(results []*AnalysisResult) string {
+	content := `# GITHUB_TOKEN Permissions
+
+Scopes each job uses, compared with its declared ` + "`permissions:`" + `. Scopes are
+inferred from known actions, ` + "`gh`" + ` commands, GitHub API calls and ` + "`git push`" + `.
+
+| Workflow | Job | Declared | Needed | Over-granted | Missing |
+|----------|-----|----------|--------|--------------|---------|
+`
+
+	overGranted, defaults := 0, 0
+	for _, result := range results {
+		workflowName := result.Config.Name
+		if workflowName == "" {
+			workflowName = filepath.Base(result.FilePath)
+		}
+
+		jobs := append([]JobAnalysis(nil), result.Jobs...)
+		sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+		for _, job := range jobs {
+			p := job.Permissions
+			if p.DeclaredAt == PermissionsFromDefault {
+				defaults++
+			}
+			overGranted += len(p.OverGranted)
+
+			var needed []string
+			for _, scope := range PermissionScopes {
+				if level := p.Required[scope]; permissionRank(level) > 0 {
+					needed = append(needed, scope+": "+level)
+				}
+			}
+			content += fmt.Sprintf("| %s | [%s](../jobs/%s.md) | %s | %s | %s | %s |\n",
+				workflowName, job.Name, sanitizeFilename(job.Name), p.DeclaredAt,
+				orDash(strings.Join(needed, ", ")), orDash(strings.Join(p.OverGranted, "; ")), orDash(strings.Join(p.Missing, "; ")))
+		}
+	}
+
+	content += fmt.Sprintf(`
+## 📊 Summary
+
+- **Jobs on the repository default token:** %d
+- **Over-granted scopes:** %d
+
+Each job page has a suggested minimal `+"`permissions:`"+` block. Setting
+`+"`permissions: {}`"+` at the workflow level and granting scopes per job keeps
+new jobs least-privileged by default.
+
+## 🔍 Navigation
+
+- [← Back to GitHub Actions Overview](../README.md)
+`, defaults, overGranted)
+
+	return content
+}
+
+// GenerateSupplyChainAnalysis audits how actions, reusable workflows and Docker images are referenced
+func (g *MarkdownGenerator) GenerateSupplyChainAnalysis(results []*AnalysisResult) string {
+	references := AuditSupplyChain(results)
+
+	risks := make(map[string]int)
+	publishers := make(map[string]map[string]int)
+	for _, ref := range references {
+		risks[ref.Risk]++
+		if publishers[ref.Publisher] == nil {
+			publishers[ref.Publisher] = make(map[string]int)
+		}
+		publishers[ref.Publisher][ref.RefType]++
+	}
+
+	content := fmt.Sprintf(`# Supply-Chain Audit
+
+Every `+"`uses:`"+` reference classified by publisher and by what it is pinned to.
+Branches and tags can be moved to different code after review; a full commit SHA
+(or an image digest) cannot.
+
+## 📊 Overview
+
+- **References:** %d
+- **🚨 High risk:** %d
+- **⚠️ Warnings:** %d
+- **✅ OK:** %d
+
+| Publisher | SHA | Tag | Branch | Other |
+|-----------|-----|-----|--------|-------|
+`, len(references), risks[RiskHigh], risks[RiskWarning], risks[RiskOK])
+
+	for _, publisher := range []string{PublisherFirstParty, PublisherVerified, PublisherThirdParty, PublisherDocker, PublisherLocal} {
+		counts, ok := publishers[publisher]
+		if !ok {
+			continue
+		}
+		other := counts[RefShortSHA] + counts[RefDigest] + counts[RefNone]
+		content += fmt.Sprintf("| %s | %d | %d | %d | %d |\n", publisher, counts[RefSHA], counts[RefTag], counts[RefBranch], other)
+	}
+	content += "\n"
+
+	var findings []ActionReference
+	for _, ref := range references {
+		if ref.Risk != RiskOK {
+			findings = append(findings, ref)
+		}
+	}
+	if len(findings) > 0 {
+		content += "## 🚨 Findings\n\n| Risk | Reference | Finding | Used By |\n|------|-----------|---------|---------|\n"
+		for _, ref := range findings {
+			icon := "⚠️"
+			if ref.Risk == RiskHigh {
+				icon = "🚨"
+			}
+			content += fmt.Sprintf("| %s %s | `%s` | %s | %s |\n", icon, ref.Risk, ref.Uses, ref.Finding, strings.Join(ref.UsedBy, ", "))
+		}
+		content += "\n"
+	} else if len(references) > 0 {
+		content += "✅ Every reference is pinned immutably or published by GitHub.\n\n"
+	}
+
+	remoteWorkflows := 0
+	for _, ref := range references {
+		if ref.Reusable {
+			remoteWorkflows++
+		}
+	}
+	if remoteWorkflows > 0 {
+		content += fmt.Sprintf(`## ⚠️ Not Audited
+
+%d remote reusable workflow(s) are checked for how they are pinned, but their
+contents are not fetched: the actions and images they use are not part of this
+audit. Audit them in their own repositories. Local reusable workflows and
+composite actions are followed, including nested ones.
+
+`, remoteWorkflows)
+	}
+
+	if len(references) > 0 {
+		content += "## 📋 All References\n\n| Reference | Publisher | Pinned To | Kind |\n|-----------|-----------|-----------|------|\n"
+		for _, ref := range references {
+			kind := "action"
+			switch {
+			case ref.Reusable:
+				kind = "reusable workflow"
+			case ref.Publisher == PublisherDocker:
+				kind = "docker image"
+			}
+			content += fmt.Sprintf("| `%s` | %s | %s | %s |\n", ref.Uses, ref.Publisher, ref.RefType, kind)
+		}
+		content += "\n"
+	}
+
+	content += `## 🔒 Pinning
+
+Pin to the full commit SHA and keep the version as a comment so update tools can
+still bump it:
+
+` + "```yaml" + `
+- uses: owner/action@<40-character-sha> # v1.2.3
+- uses: docker://alpine@sha256:<digest>
+` + "```" + `
+
+## 🔍 Navigation
+
+- [← Back to GitHub Actions Overview](../README.md)
+- [🛠️ Actions Usage](actions-usage.md)
+`
+
+	return content
+}
+
+// orDash renders empty table cells as a dash
+func orDash(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
+}
+
+// localCallLabel describes the kind of a local call
+func localCallLabel(call LocalCall) string {
+	if call.Kind == LocalKindWorkflow {
+		return "reusable workflow"
+	}
+	if call.Using != "" {
+		return call.Using + " action"
+	}
+	return "action"
+}
+
+// expandedStepLabel names an effective step by its name, action or first command line
+func expandedStepLabel(step ExpandedStep) string {
+	switch {
+	case step.Name != "":
+		return step.Name
+	case step.Uses != "":
+		return "`" + step.Uses + "`"
+	case step.Run != "":
+		return "`" + strings.TrimSpace(strings.SplitN(strings.TrimSpace(step.Run), "\n", 2)[0]) + "`"
+	}
+	return "step"
+}
+
+// sortedStringKeys returns the keys of a string map in order
+func sortedStringKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// actionPageName returns the page name of a local action under actions/
+func actionPageName(path string) string {
+	return sanitizeFilename(strings.TrimPrefix(path, ".github/actions/"))
+}
+
+// GenerateLocalActionAnalysis generates the page of a local action
+func (g *MarkdownGenerator) GenerateLocalActionAnalysis(action *LocalAction) string {
+	name := action.Name
+	if name == "" {
+		name = action.Path
+	}
+
+	content := fmt.Sprintf(`# Local Action: %s
+
+**Path:** `+"`%s`"+`  
+**Runs using:** %s
+
+`, name, action.File, action.Using)
+
+	if action.Description != "" {
+		content += action.Description + "\n\n"
+	}
+
+	if len(action.Inputs) > 0 {
+		content += "## 📥 Inputs\n\n| Input | Required | Default | Description |\n|-------|----------|---------|-------------|\n"
+		names := make([]string, 0, len(action.Inputs))
+		for inputName := range action.Inputs {
+			names = append(names, inputName)
+		}
+		sort.Strings(names)
+		for _, inputName := range names {
+			input := action.Inputs[inputName]
+			defaultValue := ""
+			if input.Default != nil {
+				defaultValue = "`" + formatMatrixValue(input.Default) + "`"
+			}
+			content += fmt.Sprintf("| %s | %t | %s | %s |\n", inputName, input.Required, defaultValue, input.Description)
+		}
+		content += "\n"
+	}
+
+	if len(action.Outputs) > 0 {
+		content += "## 📤 Outputs\n\n| Output | Value | Description |\n|--------|-------|-------------|\n"
+		for _, outputName := range sortedOutputs(action.Outputs) {
+			output := action.Outputs[outputName]
+			value := ""
+			if output.Value != "" {
+				value = "`" + output.Value + "`"
+			}
+			content += fmt.Sprintf("| %s | %s | %s |\n", outputName, value, output.Description)
+		}
+		content += "\n"
+	}
+
+	switch {
+	case action.IsComposite():
+		content += "## 🪜 Steps\n\n"
+		for i, step := range action.Steps {
+			content += fmt.Sprintf("%d. %s\n", i+1, expandedStepLabel(ExpandedStep{Step: step}))
+			if step.Name == "" {
+				continue
+			}
+			for _, cmd := range NewParser().ExtractRunCommands(step) {
+				content += fmt.Sprintf("   - `%s`\n", cmd)
+			}
+		}
+		content += "\n"
+	case action.Image != "":
+		content += fmt.Sprintf("## 🐳 Container\n\nRuns the image `%s`.\n\n", action.Image)
+	case action.Main != "":
+		content += fmt.Sprintf("## 📦 Entry Point\n\nRuns `%s` with %s.\n\n", action.Main, action.Using)
+	}
+
+	if len(action.UsedBy) > 0 {
+		content += "## 🔗 Used By\n\n"
+		for _, user := range action.UsedBy {
+			content += fmt.Sprintf("- %s\n", user)
+		}
+		content += "\n"
+	}
+
+	content += `## 🔍 Navigation
+
+- [← Back to GitHub Actions Overview](../README.md)
+- [🛠️ Actions Usage](../summaries/actions-usage.md)
+`
+
+	return content
+}
+
+// matrixSizeLabel describes how many instances a job runs as
+func matrixSizeLabel(job JobAnalysis) string {
+	if job.MatrixDynamic {
+		return "dynamic"
+	}
+	return fmt.Sprintf("%d", job.MatrixSize)
+}
+
 // Generate summary files
 func (g *MarkdownGenerator) GenerateActionsUsage(results []*AnalysisResult) string {
 	actionUsage := make(map[string]int)
@@ -428,14 +903,39 @@ func (g *MarkdownGenerator) GenerateRunnersAnalysis(results []*AnalysisResult) s
 
 ## 🏃 Runner Usage
 
-| Runner | Jobs Count |
-|--------|------------|
+Matrix jobs count once per instance.
+
+| Runner | Job Instances |
+|--------|---------------|
 `
 
 	for runner, count := range runnerUsage {
 		content += fmt.Sprintf("| `%s` | %d |\n", runner, count)
 	}
 
+	var matrixRows string
+	for _, result := range results {
+		for _, job := range result.Jobs {
+			if job.MatrixSize <= 1 && !job.MatrixDynamic {
+				continue
+			}
+			maxParallel := "unlimited"
+			if job.MaxParallel > 0 {
+				maxParallel = fmt.Sprintf("%d", job.MaxParallel)
+			}
+			matrixRows += fmt.Sprintf("| %s | %s | %s | %s | %d |\n",
+				job.Name, filepath.Base(result.FilePath), matrixSizeLabel(job), maxParallel, job.MatrixWaves())
+		}
+	}
+	if matrixRows != "" {
+		content += `
+## 🧮 Matrix Jobs
+
+| Job | Workflow | Instances | Max Parallel | Waves |
+|-----|----------|-----------|--------------|-------|
+` + matrixRows
+	}
+
 	content += `
 ## 🔍 Navigation
 
@@ -496,6 +996,8 @@ This guide helps you refactor your GitHub Actions workflows to use **go-task as
 - **CI-agnostic** - Easy to switch between GitHub Actions, CircleCI, etc.
 - **Testable** - Debug build issues without pushing to CI
 
+Run ` + "`pipeline-analyzer migrate`" + ` to generate ` + "`Taskfile.generated.yml`" + ` with one task per job as a starting point.
+
 ## 🔄 Refactoring Pattern
 
 **Current Pattern:**
@@ -0,0 +1,230 @@
+package githubactions
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// matrixRefRegex matches ${{ matrix.x }} and ${{ matrix.x.y }} references
+var matrixRefRegex = regexp.MustCompile(`\$\{\{\s*matrix\.([A-Za-z0-9_\-]+(?:\.[A-Za-z0-9_\-]+)*)\s*\}\}`)
+
+// MatrixInstance is one concrete combination of a job's strategy matrix
+type MatrixInstance struct {
+	Values      map[string]interface{}
+	Runner      string
+	RunCommands []string
+}
+
+// Label describes the instance's matrix values, e.g. "node=18, os=ubuntu-latest"
+func (m MatrixInstance) Label() string {
+	keys := make([]string, 0, len(m.Values))
+	for key := range m.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, formatMatrixValue(m.Values[key])))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// ExpandMatrix returns the combinations of a strategy matrix after applying
+// exclude and include. dynamic is true when the matrix is built from an
+// expression and cannot be expanded statically.
+func ExpandMatrix(matrix interface{}) (combinations []map[string]interface{}, dynamic bool) {
+	if matrix == nil {
+		return nil, false
+	}
+
+	definition, ok := matrix.(map[string]interface{})
+	if !ok {
+		// matrix: ${{ fromJSON(...) }}
+		return nil, true
+	}
+
+	var dimensions []string
+	for key, value := range definition {
+		if key == "include" || key == "exclude" {
+			continue
+		}
+		if _, isList := value.([]interface{}); !isList {
+			return nil, true
+		}
+		dimensions = append(dimensions, key)
+	}
+	sort.Strings(dimensions)
+
+	// Cartesian product of the dimensions
+	if len(dimensions) > 0 {
+		combinations = []map[string]interface{}{{}}
+		for _, dimension := range dimensions {
+			var next []map[string]interface{}
+			for _, combination := range combinations {
+				for _, value := range definition[dimension].([]interface{}) {
+					extended := copyValues(combination)
+					extended[dimension] = value
+					next = append(next, extended)
+				}
+			}
+			combinations = next
+		}
+	}
+
+	// exclude removes every combination matching all of an entry's values
+	for _, entry := range matrixEntries(definition["exclude"]) {
+		var kept []map[string]interface{}
+		for _, combination := range combinations {
+			if !matchesEntry(combination, entry) {
+				kept = append(kept, combination)
+			}
+		}
+		combinations = kept
+	}
+
+	// include extends every original combination it does not contradict, or
+	// adds a new one. Like GitHub, only the combinations left after exclude
+	// are extended, never those added by earlier include entries.
+	isDimension := make(map[string]bool, len(dimensions))
+	for _, dimension := range dimensions {
+		isDimension[dimension] = true
+	}
+	original := combinations
+	var added []map[string]interface{}
+	for _, entry := range matrixEntries(definition["include"]) {
+		extended := false
+		for _, combination := range original {
+			if conflictsWithOriginal(combination, entry, isDimension) {
+				continue
+			}
+			for key, value := range entry {
+				combination[key] = value
+			}
+			extended = true
+		}
+		if !extended {
+			added = append(added, copyValues(entry))
+		}
+	}
+
+	return append(original, added...), false
+}
+
+// ExpandJobMatrix expands a job into its matrix instances with matrix
+// references substituted into runs-on and run commands. Jobs without a matrix
+// expand into a single instance.
+func (p *Parser) ExpandJobMatrix(job Job) (instances []MatrixInstance, dynamic bool) {
+	combinations, dynamic := ExpandMatrix(job.Strategy.Matrix)
+	if len(combinations) == 0 {
+		combinations = []map[string]interface{}{{}}
+	}
+
+	for _, values := range combinations {
+		instance := MatrixInstance{
+			Values: values,
+			Runner: SubstituteMatrix(p.GetRunnerType(job), values),
+		}
+		for _, step := range job.Steps {
+			for _, command := range p.ExtractRunCommands(step) {
+				instance.RunCommands = append(instance.RunCommands, SubstituteMatrix(command, values))
+			}
+		}
+		instances = append(instances, instance)
+	}
+
+	return instances, dynamic
+}
+
+// SubstituteMatrix replaces ${{ matrix.x }} references with the instance's values.
+// Like GitHub, keys the instance does not define become empty strings.
+func SubstituteMatrix(text string, values map[string]interface{}) string {
+	if len(values) == 0 || !strings.Contains(text, "matrix.") {
+		return text
+	}
+
+	return matrixRefRegex.ReplaceAllStringFunc(text, func(ref string) string {
+		path := strings.Split(matrixRefRegex.FindStringSubmatch(ref)[1], ".")
+		var value interface{} = values
+		for _, key := range path {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return ""
+			}
+			if value, ok = object[key]; !ok {
+				return ""
+			}
+		}
+		return formatMatrixValue(value)
+	})
+}
+
+// formatMatrixValue renders a matrix value the way expressions convert it to a string
+func formatMatrixValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// matrixEntries reads an include or exclude list
+func matrixEntries(value interface{}) []map[string]interface{} {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var entries []map[string]interface{}
+	for _, item := range list {
+		if entry, ok := item.(map[string]interface{}); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// matchesEntry reports whether a combination has every value of an exclude entry
+func matchesEntry(combination, entry map[string]interface{}) bool {
+	for key, value := range entry {
+		if !reflect.DeepEqual(combination[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// conflictsWithOriginal reports whether an include entry would overwrite one of
+// the combination's original matrix values
+func conflictsWithOriginal(combination, entry map[string]interface{}, isDimension map[string]bool) bool {
+	for key, value := range entry {
+		if !isDimension[key] {
+			continue
+		}
+		if existing, ok := combination[key]; ok && !reflect.DeepEqual(existing, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyValues copies a combination
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(values)+1)
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,503 @@
+package githubactions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GITHUB_TOKEN permission levels
+const (
+	PermissionNone  = "none"
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+// Where a job's effective permissions come from
+const (
+	PermissionsFromJob      = "job"
+	PermissionsFromWorkflow = "workflow"
+	PermissionsFromDefault  = "default"
+)
+
+// PermissionScopes are the GITHUB_TOKEN scopes a permissions block can grant
+var PermissionScopes = []string{
+	"actions", "attestations", "checks", "contents", "deployments", "discussions", "id-token",
+	"issues", "packages", "pages", "pull-requests", "repository-projects", "security-events", "statuses",
+}
+
+// actionPermissions maps well-known actions to the scopes they need
+var actionPermissions = map[string]map[string]string{
+	"actions/checkout":                            {"contents": PermissionRead},
+	"actions/create-release":                      {"contents": PermissionWrite},
+	"actions/upload-release-asset":                {"contents": PermissionWrite},
+	"actions/deploy-pages":                        {"pages": PermissionWrite, "id-token": PermissionWrite},
+	"actions/configure-pages":                     {"pages": PermissionRead},
+	"actions/labeler":                             {"contents": PermissionRead, "pull-requests": PermissionWrite},
+	"actions/stale":                               {"issues": PermissionWrite, "pull-requests": PermissionWrite},
+	"actions/first-interaction":                   {"issues": PermissionWrite, "pull-requests": PermissionWrite},
+	"actions/dependency-review-action":            {"contents": PermissionRead},
+	"actions/attest-build-provenance":             {"id-token": PermissionWrite, "attestations": PermissionWrite, "contents": PermissionRead},
+	"github/codeql-action/init":                   {"security-events": PermissionWrite, "actions": PermissionRead, "contents": PermissionRead},
+	"github/codeql-action/analyze":                {"security-events": PermissionWrite, "actions": PermissionRead, "contents": PermissionRead},
+	"github/codeql-action/upload-sarif":           {"security-events": PermissionWrite},
+	"softprops/action-gh-release":                 {"contents": PermissionWrite},
+	"ncipollo/release-action":                     {"contents": PermissionWrite},
+	"googleapis/release-please-action":            {"contents": PermissionWrite, "pull-requests": PermissionWrite},
+	"google-github-actions/release-please-action": {"contents": PermissionWrite, "pull-requests": PermissionWrite},
+	"release-drafter/release-drafter":             {"contents": PermissionWrite, "pull-requests": PermissionRead},
+	"peter-evans/create-pull-request":             {"contents": PermissionWrite, "pull-requests": PermissionWrite},
+	"peter-evans/create-or-update-comment":        {"issues": PermissionWrite, "pull-requests": PermissionWrite},
+	"marocchino/sticky-pull-request-comment":      {"pull-requests": PermissionWrite},
+	"dependabot/fetch-metadata":                   {"pull-requests": PermissionRead},
+	"EnricoMi/publish-unit-test-result-action":    {"checks": PermissionWrite, "pull-requests": PermissionWrite},
+	"mikepenz/action-junit-report":                {"checks": PermissionWrite},
+	"dorny/test-reporter":                         {"checks": PermissionWrite},
+	"JamesIves/github-pages-deploy-action":        {"contents": PermissionWrite},
+	"peaceiris/actions-gh-pages":                  {"contents": PermissionWrite},
+	"stefanzweifel/git-auto-commit-action":        {"contents": PermissionWrite},
+}
+
+// tokenlessActions need no GITHUB_TOKEN scopes
+var tokenlessActions = []string{
+	"actions/cache", "actions/upload-artifact", "actions/download-artifact", "actions/upload-pages-artifact",
+	"actions/setup-node", "actions/setup-python", "actions/setup-go", "actions/setup-java", "actions/setup-dotnet",
+	"docker/setup-buildx-action", "docker/setup-qemu-action", "docker/build-push-action", "docker/metadata-action",
+	"codecov/codecov-action",
+}
+
+// oidcActions need id-token: write when they authenticate with OIDC, keyed by the input that enables it
+var oidcActions = map[string]string{
+	"aws-actions/configure-aws-credentials": "role-to-assume",
+	"google-github-actions/auth":            "workload_identity_provider",
+	"azure/login":                           "client-id",
+	"hashicorp/vault-action":                "role",
+}
+
+// ghCommandPermissions maps gh subcommands to the scopes they need
+var ghCommandPermissions = map[string]map[string]string{
+	"pr create":        {"pull-requests": PermissionWrite},
+	"pr edit":          {"pull-requests": PermissionWrite},
+	"pr comment":       {"pull-requests": PermissionWrite},
+	"pr review":        {"pull-requests": PermissionWrite},
+	"pr close":         {"pull-requests": PermissionWrite},
+	"pr reopen":        {"pull-requests": PermissionWrite},
+	"pr merge":         {"contents": PermissionWrite, "pull-requests": PermissionWrite},
+	"pr ready":         {"pull-requests": PermissionWrite},
+	"pr view":          {"pull-requests": PermissionRead},
+	"pr list":          {"pull-requests": PermissionRead},
+	"pr diff":          {"pull-requests": PermissionRead},
+	"pr checks":        {"checks": PermissionRead},
+	"pr checkout":      {"contents": PermissionRead},
+	"issue create":     {"issues": PermissionWrite},
+	"issue edit":       {"issues": PermissionWrite},
+	"issue comment":    {"issues": PermissionWrite},
+	"issue close":      {"issues": PermissionWrite},
+	"issue reopen":     {"issues": PermissionWrite},
+	"issue view":       {"issues": PermissionRead},
+	"issue list":       {"issues": PermissionRead},
+	"label create":     {"issues": PermissionWrite},
+	"label edit":       {"issues": PermissionWrite},
+	"label list":       {"issues": PermissionRead},
+	"release create":   {"contents": PermissionWrite},
+	"release upload":   {"contents": PermissionWrite},
+	"release edit":     {"contents": PermissionWrite},
+	"release delete":   {"contents": PermissionWrite},
+	"release view":     {"contents": PermissionRead},
+	"release list":     {"contents": PermissionRead},
+	"release download": {"contents": PermissionRead},
+	"run rerun":        {"actions": PermissionWrite},
+	"run cancel":       {"actions": PermissionWrite},
+	"run delete":       {"actions": PermissionWrite},
+	"run list":         {"actions": PermissionRead},
+	"run view":         {"actions": PermissionRead},
+	"run watch":        {"actions": PermissionRead},
+	"run download":     {"actions": PermissionRead},
+	"workflow run":     {"actions": PermissionWrite},
+	"workflow enable":  {"actions": PermissionWrite},
+	"workflow disable": {"actions": PermissionWrite},
+	"workflow list":    {"actions": PermissionRead},
+	"workflow view":    {"actions": PermissionRead},
+	"cache delete":     {"actions": PermissionWrite},
+	"cache list":       {"actions": PermissionRead},
+}
+
+// apiPathScopes maps REST API path segments to the scope that guards them, most specific first
+var apiPathScopes = []struct {
+	segment string
+	scope   string
+}{
+	{"/code-scanning", "security-events"},
+	{"/check-runs", "checks"},
+	{"/check-suites", "checks"},
+	{"/statuses", "statuses"},
+	{"/deployments", "deployments"},
+	{"/environments", "deployments"},
+	{"/pages", "pages"},
+	{"/packages", "packages"},
+	{"/actions", "actions"},
+	{"/pulls", "pull-requests"},
+	{"/issues", "issues"},
+	{"/labels", "issues"},
+	{"/milestones", "issues"},
+	{"/discussions", "discussions"},
+	{"/projects", "repository-projects"},
+	{"/releases", "contents"},
+	{"/contents", "contents"},
+	{"/git/", "contents"},
+	{"/commits", "contents"},
+	{"/branches", "contents"},
+	{"/tags", "contents"},
+	{"/dispatches", "contents"},
+}
+
+// githubScriptNamespaces maps octokit REST namespaces used in github-script to scopes
+var githubScriptNamespaces = map[string]string{
+	"issues":       "issues",
+	"pulls":        "pull-requests",
+	"repos":        "contents",
+	"git":          "contents",
+	"checks":       "checks",
+	"actions":      "actions",
+	"packages":     "packages",
+	"codeScanning": "security-events",
+	"reactions":    "issues",
+}
+
+var (
+	ghCommandRegex      = regexp.MustCompile(`(?:^|[;&|(\s])gh\s+([a-z-]+)\s+([a-z-]+)`)
+	ghAPIRegex          = regexp.MustCompile(`(?:^|[;&|(\s])gh\s+api\b(.*)`)
+	curlAPIRegex        = regexp.MustCompile(`curl\b.*api\.github\.com(/[^\s'"]*)`)
+	httpMethodRegex     = regexp.MustCompile(`(?:-X|--method|--request)\s*=?\s*['"]?([A-Za-z]+)`)
+	apiPathRegex        = regexp.MustCompile(`(?:^|\s)['"]?/?(repos/[^\s'"]+)`)
+	githubScriptRegex   = regexp.MustCompile(`github\.(?:rest\.)?([A-Za-z]+)\.([A-Za-z]+)\(`)
+	gitPushRegex        = regexp.MustCompile(`(?:^|[;&|(\s])git\s+push\b`)
+	ghcrPushRegex       = regexp.MustCompile(`(?:docker|podman)\s+push\s+['"]?ghcr\.io/`)
+	ghcrPullRegex       = regexp.MustCompile(`(?:docker|podman)\s+(?:pull|run)\b.*ghcr\.io/`)
+	githubPackagesRegex = regexp.MustCompile(`npm\.pkg\.github\.com|maven\.pkg\.github\.com|nuget\.pkg\.github\.com`)
+)
+
+// PermissionNeed is a scope a step needs and why
+type PermissionNeed struct {
+	Scope  string
+	Level  string
+	Reason string // The action or command that needs the scope
+}
+
+// PermissionsAnalysis compares the scopes a job uses with the ones it is granted
+type PermissionsAnalysis struct {
+	DeclaredAt  string            // PermissionsFromJob, PermissionsFromWorkflow or PermissionsFromDefault
+	Declared    map[string]string // Effective granted scopes; nil for the repository default
+	Required    map[string]string // Scope -> least level the job needs
+	Needs       []PermissionNeed  // Every need with its reason
+	OverGranted []string          // Granted scopes above what the job needs
+	Missing     []string          // Needed scopes the declaration does not grant
+	Unknown     []string          // Steps whose needs could not be determined
+	Suggested   string            // Minimal permissions: block for the job
+}
+
+// AnalyzePermissions works out the GITHUB_TOKEN scopes a job needs from its
+// effective steps and compares them with the declared permissions
+func AnalyzePermissions(workflow *Workflow, job Job, steps []ExpandedStep) PermissionsAnalysis {
+	analysis := PermissionsAnalysis{Required: make(map[string]string)}
+
+	switch {
+	case job.Permissions != nil:
+		analysis.DeclaredAt = PermissionsFromJob
+		analysis.Declared = ParsePermissions(job.Permissions)
+	case workflow != nil && workflow.Permissions != nil:
+		analysis.DeclaredAt = PermissionsFromWorkflow
+		analysis.Declared = ParsePermissions(workflow.Permissions)
+	default:
+		analysis.DeclaredAt = PermissionsFromDefault
+	}
+
+	parser := NewParser()
+	for _, step := range steps {
+		needs, known := stepPermissionNeeds(step.Step, parser)
+		if !known {
+			analysis.Unknown = append(analysis.Unknown, step.Uses)
+		}
+		for _, need := range needs {
+			analysis.addNeed(need)
+		}
+	}
+
+	if analysis.Declared != nil {
+		for _, scope := range PermissionScopes {
+			granted, required := analysis.Declared[scope], analysis.Required[scope]
+			switch {
+			case permissionRank(granted) > permissionRank(required):
+				needed := required
+				if needed == "" {
+					needed = PermissionNone
+				}
+				analysis.OverGranted = append(analysis.OverGranted, fmt.Sprintf("%s: %s (needs %s)", scope, granted, needed))
+			case permissionRank(granted) < permissionRank(required):
+				analysis.Missing = append(analysis.Missing, fmt.Sprintf("%s: %s (granted %s)", scope, required, orNone(granted)))
+			}
+		}
+	}
+
+	analysis.Unknown = uniqueSortedStrings(analysis.Unknown)
+	analysis.Suggested = FormatPermissions(analysis.Required)
+	return analysis
+}
+
+// addNeed records a need, keeping the highest level per scope
+func (a *PermissionsAnalysis) addNeed(need PermissionNeed) {
+	for _, existing := range a.Needs {
+		if existing == need {
+			return
+		}
+	}
+	a.Needs = append(a.Needs, need)
+	if permissionRank(need.Level) > permissionRank(a.Required[need.Scope]) {
+		a.Required[need.Scope] = need.Level
+	}
+}
+
+// ReasonsFor lists why a scope is needed
+func (a PermissionsAnalysis) ReasonsFor(scope string) []string {
+	var reasons []string
+	for _, need := range a.Needs {
+		if need.Scope == scope {
+			reasons = append(reasons, fmt.Sprintf("%s (%s)", need.Reason, need.Level))
+		}
+	}
+	return reasons
+}
+
+// ParsePermissions expands a permissions: value into a scope -> level map.
+// read-all and write-all grant every scope; unlisted scopes are none.
+func ParsePermissions(value interface{}) map[string]string {
+	permissions := make(map[string]string, len(PermissionScopes))
+	for _, scope := range PermissionScopes {
+		permissions[scope] = PermissionNone
+	}
+
+	switch v := value.(type) {
+	case string:
+		level := PermissionNone
+		switch v {
+		case "read-all":
+			level = PermissionRead
+		case "write-all":
+			level = PermissionWrite
+		}
+		for _, scope := range PermissionScopes {
+			permissions[scope] = level
+		}
+		// id-token only supports write and none
+		if level == PermissionRead {
+			permissions["id-token"] = PermissionNone
+		}
+	case map[string]interface{}:
+		for scope, level := range v {
+			if text, ok := level.(string); ok {
+				permissions[scope] = text
+			}
+		}
+	}
+
+	return permissions
+}
+
+// FormatPermissions renders a minimal permissions: block
+func FormatPermissions(required map[string]string) string {
+	var scopes []string
+	for scope, level := range required {
+		if permissionRank(level) > 0 {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return "permissions: {}\n"
+	}
+	sort.Strings(scopes)
+
+	var sb strings.Builder
+	sb.WriteString("permissions:\n")
+	for _, scope := range scopes {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", scope, required[scope]))
+	}
+	return sb.String()
+}
+
+// stepPermissionNeeds returns the scopes a step needs. known is false for
+// actions the analyzer has no mapping for.
+func stepPermissionNeeds(step Step, parser *Parser) (needs []PermissionNeed, known bool) {
+	if step.Run != "" {
+		for _, command := range parser.ExtractRunCommands(step) {
+			needs = append(needs, commandPermissionNeeds(command)...)
+		}
+		return needs, true
+	}
+	if step.Uses == "" || strings.HasPrefix(step.Uses, "docker://") {
+		return nil, true
+	}
+
+	action := actionName(step.Uses)
+	if scopes, ok := actionPermissions[action]; ok {
+		for scope, level := range scopes {
+			needs = append(needs, PermissionNeed{Scope: scope, Level: level, Reason: action})
+		}
+		sort.Slice(needs, func(i, j int) bool { return needs[i].Scope < needs[j].Scope })
+		return needs, true
+	}
+
+	switch {
+	case action == "actions/github-script":
+		script, _ := step.With["script"].(string)
+		needs = githubScriptNeeds(script)
+		return needs, len(needs) > 0
+
+	case action == "actions/download-artifact":
+		// Downloading from another run reads the Actions API
+		if _, crossRun := step.With["run-id"]; crossRun {
+			needs = append(needs, PermissionNeed{Scope: "actions", Level: PermissionRead, Reason: action + " (run-id)"})
+		}
+		return needs, true
+
+	case action == "docker/login-action":
+		if registry, _ := step.With["registry"].(string); strings.HasPrefix(registry, "ghcr.io") {
+			needs = append(needs, PermissionNeed{Scope: "packages", Level: PermissionWrite, Reason: action + " (ghcr.io)"})
+		}
+		return needs, true
+	}
+
+	if input, ok := oidcActions[action]; ok {
+		if _, oidc := step.With[input]; oidc {
+			needs = append(needs, PermissionNeed{Scope: "id-token", Level: PermissionWrite, Reason: action + " (OIDC)"})
+		}
+		return needs, true
+	}
+	for _, tokenless := range tokenlessActions {
+		if action == tokenless {
+			return nil, true
+		}
+	}
+
+	return nil, false
+}
+
+// commandPermissionNeeds maps gh, GitHub API and git commands to scopes
+func commandPermissionNeeds(command string) []PermissionNeed {
+	var needs []PermissionNeed
+
+	if match := ghAPIRegex.FindStringSubmatch(command); match != nil {
+		if need, ok := apiPermissionNeed(match[1], "gh api"); ok {
+			needs = append(needs, need)
+		}
+	} else {
+		for _, match := range ghCommandRegex.FindAllStringSubmatch(command, -1) {
+			subcommand := match[1] + " " + match[2]
+			scopes := ghCommandPermissions[subcommand]
+			for _, scope := range sortedStringKeys(scopes) {
+				needs = append(needs, PermissionNeed{Scope: scope, Level: scopes[scope], Reason: "gh " + subcommand})
+			}
+		}
+	}
+
+	if match := curlAPIRegex.FindStringSubmatch(command); match != nil {
+		if need, ok := apiPermissionNeed(match[1]+" "+command, "GitHub API"); ok {
+			needs = append(needs, need)
+		}
+	}
+	if gitPushRegex.MatchString(command) {
+		needs = append(needs, PermissionNeed{Scope: "contents", Level: PermissionWrite, Reason: "git push"})
+	}
+	if ghcrPushRegex.MatchString(command) || (githubPackagesRegex.MatchString(command) && strings.Contains(command, "publish")) {
+		needs = append(needs, PermissionNeed{Scope: "packages", Level: PermissionWrite, Reason: "package publish"})
+	} else if ghcrPullRegex.MatchString(command) {
+		needs = append(needs, PermissionNeed{Scope: "packages", Level: PermissionRead, Reason: "ghcr.io pull"})
+	}
+
+	return needs
+}
+
+// apiPermissionNeed derives the scope of a REST call from its path and method
+func apiPermissionNeed(args, tool string) (PermissionNeed, bool) {
+	path := ""
+	if match := apiPathRegex.FindStringSubmatch(args); match != nil {
+		path = "/" + match[1]
+	} else if strings.HasPrefix(strings.TrimSpace(args), "/") {
+		path = strings.Fields(args)[0]
+	}
+	if path == "" {
+		return PermissionNeed{}, false
+	}
+
+	level := PermissionRead
+	if match := httpMethodRegex.FindStringSubmatch(args); match != nil && !strings.EqualFold(match[1], "GET") {
+		level = PermissionWrite
+	} else if strings.Contains(args, " -f ") || strings.Contains(args, " -F ") || strings.Contains(args, "--field") || strings.Contains(args, "--raw-field") {
+		// gh api switches to POST when fields are given
+		level = PermissionWrite
+	}
+
+	for _, candidate := range apiPathScopes {
+		if strings.Contains(path, candidate.segment) {
+			return PermissionNeed{Scope: candidate.scope, Level: level, Reason: fmt.Sprintf("%s %s", tool, path)}, true
+		}
+	}
+	return PermissionNeed{Scope: "contents", Level: PermissionRead, Reason: fmt.Sprintf("%s %s", tool, path)}, true
+}
+
+// githubScriptNeeds maps octokit calls in an actions/github-script script to scopes
+func githubScriptNeeds(script string) []PermissionNeed {
+	var needs []PermissionNeed
+	for _, match := range githubScriptRegex.FindAllStringSubmatch(script, -1) {
+		scope, ok := githubScriptNamespaces[match[1]]
+		if !ok {
+			continue
+		}
+		level := PermissionWrite
+		if strings.HasPrefix(match[2], "get") || strings.HasPrefix(match[2], "list") || strings.HasPrefix(match[2], "check") {
+			level = PermissionRead
+		}
+		if match[1] == "repos" && strings.Contains(match[2], "Status") {
+			scope = "statuses"
+		}
+		needs = append(needs, PermissionNeed{Scope: scope, Level: level, Reason: fmt.Sprintf("github-script %s.%s", match[1], match[2])})
+	}
+	return needs
+}
+
+// actionName strips the version from a uses: reference
+func actionName(uses string) string {
+	return strings.SplitN(uses, "@", 2)[0]
+}
+
+// permissionRank orders permission levels
+func permissionRank(level string) int {
+	switch level {
+	case PermissionWrite:
+		return 2
+	case PermissionRead:
+		return 1
+	}
+	return 0
+}
+
+func orNone(level string) string {
+	if level == "" {
+		return PermissionNone
+	}
+	return level
+}
+
+// uniqueSortedStrings removes duplicates and sorts
+func uniqueSortedStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
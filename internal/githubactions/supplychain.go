@@ -0,0 +1,216 @@
+package githubactions
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// Publishers of action references
+const (
+	PublisherFirstParty = "first-party"
+	PublisherVerified   = "verified"
+	PublisherThirdParty = "third-party"
+	PublisherLocal      = "local"
+	PublisherDocker     = "docker"
+)
+
+// Kinds of ref an action reference is pinned to
+const (
+	RefSHA      = "sha"
+	RefShortSHA = "short-sha"
+	RefTag      = "tag"
+	RefBranch   = "branch"
+	RefDigest   = "digest"
+	RefNone     = "none"
+)
+
+// Supply-chain risk levels
+const (
+	RiskOK      = "ok"
+	RiskWarning = "warning"
+	RiskHigh    = "high"
+)
+
+// firstPartyOwners publish the actions GitHub maintains
+var firstPartyOwners = []string{"actions", "github"}
+
+// verifiedOwners are organizations with verified creator badges on the Marketplace
+var verifiedOwners = []string{
+	"aws-actions", "azure", "docker", "google-github-actions", "hashicorp", "codecov", "microsoft",
+	"gradle", "pnpm", "sonarsource", "snyk", "slackapi", "cloudflare", "pulumi", "goreleaser",
+	"golangci", "ruby", "oven-sh", "denoland", "astral-sh", "datadog", "jfrog", "octokit",
+}
+
+var (
+	fullSHARegex  = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	shortSHARegex = regexp.MustCompile(`^[0-9a-f]{7,39}$`)
+	versionRegex  = regexp.MustCompile(`^v?\d+(\.\d+)*([-+.][0-9A-Za-z.-]+)?$`)
+)
+
+// ActionReference is a classified uses: reference
+type ActionReference struct {
+	Uses      string   // Reference as written
+	Name      string   // owner/repo[/path], image or local path
+	Ref       string   // Version after @, or the image tag
+	Publisher string   // PublisherFirstParty, PublisherVerified, ...
+	RefType   string   // RefSHA, RefTag, RefBranch, ...
+	Reusable  bool     // A reusable workflow rather than an action
+	Risk      string   // RiskOK, RiskWarning or RiskHigh
+	Finding   string   // Why the reference is risky, empty when ok
+	UsedBy    []string // "workflow / job" entries
+}
+
+// ClassifyActionReference classifies a uses: value by publisher and ref type
+func ClassifyActionReference(uses string) ActionReference {
+	ref := ActionReference{Uses: uses, Name: uses, Risk: RiskOK}
+
+	switch {
+	case IsLocalReference(uses):
+		ref.Publisher, ref.RefType = PublisherLocal, RefNone
+		return ref
+
+	case strings.HasPrefix(uses, "docker://"):
+		image := strings.TrimPrefix(uses, "docker://")
+		ref.Publisher, ref.Name = PublisherDocker, image
+		if name, digest, ok := strings.Cut(image, "@"); ok {
+			ref.Name, ref.Ref, ref.RefType = name, digest, RefDigest
+			return ref
+		}
+		ref.RefType = RefTag
+		if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+			ref.Name, ref.Ref = image[:colon], image[colon+1:]
+		}
+		ref.Risk, ref.Finding = RiskHigh, "Docker image is not pinned by digest"
+		return ref
+	}
+
+	name, version, hasRef := strings.Cut(uses, "@")
+	ref.Name, ref.Ref = name, version
+	ref.Reusable = strings.Contains(name, "/.github/workflows/")
+
+	owner := strings.ToLower(strings.SplitN(name, "/", 2)[0])
+	switch {
+	case shared.ContainsString(firstPartyOwners, owner):
+		ref.Publisher = PublisherFirstParty
+	case shared.ContainsString(verifiedOwners, owner):
+		ref.Publisher = PublisherVerified
+	default:
+		ref.Publisher = PublisherThirdParty
+	}
+
+	switch {
+	case !hasRef || version == "":
+		ref.RefType = RefNone
+	case fullSHARegex.MatchString(version):
+		ref.RefType = RefSHA
+	case shortSHARegex.MatchString(version) && !versionRegex.MatchString(version):
+		ref.RefType = RefShortSHA
+	case versionRegex.MatchString(version):
+		ref.RefType = RefTag
+	default:
+		ref.RefType = RefBranch
+	}
+
+	switch ref.RefType {
+	case RefNone:
+		ref.Risk, ref.Finding = RiskHigh, "No ref - uses the default branch"
+	case RefBranch:
+		ref.Risk, ref.Finding = RiskHigh, fmt.Sprintf("Mutable branch ref %q", version)
+	case RefShortSHA:
+		ref.Risk, ref.Finding = RiskWarning, "Short SHA - pin the full 40-character commit SHA"
+	case RefTag:
+		switch ref.Publisher {
+		case PublisherThirdParty:
+			ref.Risk, ref.Finding = RiskHigh, "Third-party code pinned to a movable tag - pin a commit SHA"
+		case PublisherVerified:
+			ref.Risk, ref.Finding = RiskWarning, "Tag can be moved - pin a commit SHA"
+		}
+	}
+
+	return ref
+}
+
+// AuditSupplyChain classifies every action, reusable workflow and Docker
+// reference used across the analyzed workflows, including steps inlined from
+// local composite actions and local reusable workflows, however deeply nested.
+// Remote reusable workflows are classified but not fetched, so the actions
+// they use are not audited.
+func AuditSupplyChain(results []*AnalysisResult) []ActionReference {
+	references := make(map[string]*ActionReference)
+	add := func(uses, user string) {
+		if uses == "" {
+			return
+		}
+		ref, ok := references[uses]
+		if !ok {
+			classified := ClassifyActionReference(uses)
+			ref = &classified
+			references[uses] = ref
+		}
+		if !shared.ContainsString(ref.UsedBy, user) {
+			ref.UsedBy = append(ref.UsedBy, user)
+		}
+	}
+
+	for _, result := range results {
+		workflowName := result.Config.Name
+		if workflowName == "" {
+			workflowName = filepath.Base(result.FilePath)
+		}
+		for _, job := range result.Jobs {
+			user := workflowName + " / " + job.Name
+			if configJob, ok := result.Config.Jobs[job.Name]; ok && configJob.Uses != "" && !IsLocalReference(configJob.Uses) {
+				add(configJob.Uses, user)
+			}
+			for _, step := range job.ExpandedSteps {
+				add(step.Uses, user)
+			}
+		}
+		// Jobs of local reusable workflows may call remote reusable workflows
+		for _, reusable := range result.Config.ReusableWorkflows {
+			if reusable == nil {
+				continue
+			}
+			for jobName, job := range reusable.Workflow.Jobs {
+				if job.Uses != "" && !IsLocalReference(job.Uses) {
+					add(job.Uses, reusable.Path+" / "+jobName)
+				}
+			}
+		}
+		// Local Docker actions may run a remote image
+		for _, action := range result.Config.LocalActions {
+			if action != nil && strings.HasPrefix(action.Image, "docker://") {
+				add(action.Image, action.Path)
+			}
+		}
+	}
+
+	var list []ActionReference
+	for _, ref := range references {
+		sort.Strings(ref.UsedBy)
+		list = append(list, *ref)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if riskRank(list[i].Risk) != riskRank(list[j].Risk) {
+			return riskRank(list[i].Risk) > riskRank(list[j].Risk)
+		}
+		return list[i].Uses < list[j].Uses
+	})
+	return list
+}
+
+// riskRank orders risk levels
+func riskRank(risk string) int {
+	switch risk {
+	case RiskHigh:
+		return 2
+	case RiskWarning:
+		return 1
+	}
+	return 0
+}
@@ -7,7 +7,12 @@ type Workflow struct {
 	Name string                 `yaml:"name,omitempty"`
 	On   interface{}            `yaml:"on,omitempty"` // Can be string, array, or object
 	Env  map[string]string      `yaml:"env,omitempty"`
+	Permissions interface{}     `yaml:"permissions,omitempty"` // Can be read-all, write-all or a scope map
+	Defaults    Defaults        `yaml:"defaults,omitempty"`
 	Jobs map[string]Job         `yaml:"jobs"`
+
+	LocalActions      map[string]*LocalAction      `yaml:"-"` // Local actions by repository-relative path, nil when unresolved
+	ReusableWorkflows map[string]*ReusableWorkflow `yaml:"-"` // Local reusable workflows by repository-relative path, nil when unresolved
 }
 
 // Job represents a job within a workflow
@@ -23,6 +28,22 @@ type Job struct {
 	Steps        []Step              `yaml:"steps"`
 	TimeoutMinutes int               `yaml:"timeout-minutes,omitempty"`
 	Permissions  interface{}         `yaml:"permissions,omitempty"`
+	Uses         string                 `yaml:"uses,omitempty"`    // Reusable workflow the job calls
+	With         map[string]interface{} `yaml:"with,omitempty"`    // Inputs for the reusable workflow
+	Secrets      interface{}            `yaml:"secrets,omitempty"` // Secrets map or "inherit"
+	Outputs      map[string]string      `yaml:"outputs,omitempty"`
+	Defaults     Defaults               `yaml:"defaults,omitempty"`
+}
+
+// Defaults are the defaults: of a workflow or job
+type Defaults struct {
+	Run RunDefaults `yaml:"run,omitempty"`
+}
+
+// RunDefaults apply to the run steps of a workflow or job
+type RunDefaults struct {
+	Shell            string `yaml:"shell,omitempty"`
+	WorkingDirectory string `yaml:"working-directory,omitempty"`
 }
 
 // Strategy defines job strategy (matrix, fail-fast, etc.)
@@ -85,4 +106,18 @@ type JobAnalysis struct {
 	CachingEnabled  bool
 	SecurityIssues  []string
 	Recommendations []string
+
+	MatrixSize      int              // Job instances the strategy matrix expands into, 1 without a matrix
+	MaxParallel     int              // strategy.max-parallel, 0 when unlimited
+	MatrixDynamic   bool             // Matrix built from an expression and not expanded
+	MatrixInstances []MatrixInstance // Concrete instances with matrix values substituted
+
+	Contexts         map[string][]string // Context name -> references such as secrets.NPM_TOKEN
+	ExpressionIssues []string            // Expressions that fail to parse or use unknown contexts
+
+	ReusableWorkflow string         // Local reusable workflow the job calls, if any
+	LocalCalls       []LocalCall    // Local actions and reusable workflows the job uses
+	ExpandedSteps    []ExpandedStep // Effective steps with local definitions inlined
+
+	Permissions PermissionsAnalysis // GITHUB_TOKEN scopes used versus declared
 }
\ No newline at end of file
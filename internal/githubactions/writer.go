@@ -25,6 +25,7 @@ func (w *Writer) WriteAllFiles(results []*AnalysisResult, configPath string) err
 		filepath.Join(w.outputDir, "workflows"),
 		filepath.Join(w.outputDir, "jobs"),
 		filepath.Join(w.outputDir, "summaries"),
+		filepath.Join(w.outputDir, "actions"),
 	}
 
 	for _, dir := range dirs {
@@ -45,6 +46,11 @@ func (w *Writer) WriteAllFiles(results []*AnalysisResult, configPath string) err
 		}
 	}
 
+	// Write local action pages
+	if err := w.writeLocalActionFiles(results); err != nil {
+		return fmt.Errorf("failed to write local action files: %w", err)
+	}
+
 	// Write summary files
 	if err := w.writeSummaryFiles(results); err != nil {
 		return fmt.Errorf("failed to write summary files: %w", err)
@@ -89,6 +95,20 @@ func (w *Writer) writeWorkflowFiles(result *AnalysisResult) error {
 	return nil
 }
 
+// writeLocalActionFiles writes a page per local action
+func (w *Writer) writeLocalActionFiles(results []*AnalysisResult) error {
+	generator := NewMarkdownGenerator()
+
+	for _, action := range CollectLocalActions(results) {
+		actionPath := filepath.Join(w.outputDir, "actions", actionPageName(action.Path)+".md")
+		if err := os.WriteFile(actionPath, []byte(generator.GenerateLocalActionAnalysis(action)), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // writeSummaryFiles writes summary analysis files
 func (w *Writer) writeSummaryFiles(results []*AnalysisResult) error {
 	generator := NewMarkdownGenerator()
@@ -98,6 +118,8 @@ func (w *Writer) writeSummaryFiles(results []*AnalysisResult) error {
 		"runners-analysis.md": generator.GenerateRunnersAnalysis(results),
 		"commands-analysis.md": generator.GenerateCommandsAnalysis(results),
 		"go-task-migration.md": generator.GenerateGoTaskMigration(results),
+		"permissions.md":       generator.GeneratePermissionsAnalysis(results),
+		"supply-chain.md":      generator.GenerateSupplyChainAnalysis(results),
 	}
 
 	for filename, content := range summaries {
@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
 )
 
 // AnalyzeTaskfile performs comprehensive analysis of a Taskfile
@@ -13,6 +15,7 @@ func AnalyzeTaskfile(taskfile *Taskfile) *Analysis {
 		Taskfile:         taskfile,
 		TaskUsage:        make(map[string]int),
 		TaskDependencies: make(map[string][]string),
+		TaskCalls:        make(map[string][]TaskCall),
 		CommandPatterns:  make(map[string]PatternCount),
 		VariableUsage:    make(map[string][]string),
 		EnvironmentUsage: make(map[string][]string),
@@ -31,8 +34,7 @@ func AnalyzeTaskfile(taskfile *Taskfile) *Analysis {
 	// Analyze variables and environment
 	analyzeVariables(taskfile, analysis)
 	
-	// Analyze includes  
-	// Note: We don't have access to the original taskfile path here, so include analysis is limited
+	// Record includes; AnalyzeIncludesWithPath loads them
 	analyzeIncludes(taskfile, analysis)
 	
 	// Build dependency graph and detect cycles
@@ -40,83 +42,133 @@ func AnalyzeTaskfile(taskfile *Taskfile) *Analysis {
 	analysis.CriticalPath = findCriticalPath(analysis.TaskDependencies)
 	
 	// Generate optimization tips
-	analysis.OptimizationTips = generateOptimizationTips(taskfile, analysis)
+	analysis.OptimizationTips = generateOptimizationTips(analysis)
 
 	return analysis
 }
 
-// AnalyzeIncludesWithPath re-analyzes includes with the correct base path
+// AnalyzeIncludesWithPath loads the includes recursively from the Taskfile's
+// location and re-runs the dependency analysis over the whole project
 func AnalyzeIncludesWithPath(taskfile *Taskfile, analysis *Analysis, taskfilePath string) {
-	for name, includeRaw := range taskfile.Includes {
+	project := LoadProject(taskfile, taskfilePath)
+	analysis.Project = project
+	analysis.TotalIncludes = len(project.Includes)
+
+	// Dependencies and usage use full task names across all Taskfiles
+	analysis.TaskDependencies = make(map[string][]string)
+	analysis.TaskCalls = make(map[string][]TaskCall)
+	analysis.TaskUsage = make(map[string]int)
+	for _, taskName := range project.TaskNames() {
+		deps := project.Tasks[taskName].Dependencies
+		analysis.TaskDependencies[taskName] = deps
+		analysis.TaskCalls[taskName] = project.Tasks[taskName].Calls
+		for _, dep := range deps {
+			analysis.TaskUsage[dep]++
+		}
+	}
+
+	analysis.FileChecks = checkTaskFiles(project, analysis.TaskDependencies)
+	analysis.Diagnostics = ValidateProject(project)
+
+	analysis.IncludeAnalysis = make(map[string]*IncludeAnalysis)
+	for _, include := range project.Includes {
 		includeAnalysis := &IncludeAnalysis{
-			Namespace: name,
+			Path:      include.Path,
+			Namespace: include.Namespace,
+			TaskCount: len(include.Tasks),
+			Parent:    include.Parent,
+			Dir:       include.Dir,
+			Optional:  include.Optional,
+			Flatten:   include.Flatten,
+			Internal:  include.Internal,
+			Aliases:   include.Aliases,
+			Excludes:  include.Excludes,
+			Error:     include.Error,
 			Tasks:     make(map[string]*TaskAnalysis),
 		}
-		
-		// Handle different include formats (string or object)
-		var includePath string
-		var includeDir string
-		
-		switch include := includeRaw.(type) {
-		case string:
-			includePath = include
-			includeAnalysis.Path = include
-		case map[string]interface{}:
-			if taskfilePath, ok := include["taskfile"].(string); ok {
-				includePath = taskfilePath
-				includeAnalysis.Path = taskfilePath
-			}
-			if dir, ok := include["dir"].(string); ok {
-				includeDir = dir
-			}
-		}
-		
-		// Try to parse included taskfile for deeper analysis
-		if includePath != "" {
-			// Create a mock Include struct for ParseIncludedTaskfile
-			mockInclude := Include{
-				Taskfile: includePath,
-				Dir:      includeDir,
-			}
-			
-			// Parse the included taskfile using the main taskfile path as base
-			includedTaskfile, err := ParseIncludedTaskfile(mockInclude, taskfilePath)
-			if err == nil {
-				// Analyze each task in the included file
-				includeAnalysis.TaskCount = len(includedTaskfile.Tasks)
-				
-				for taskName, task := range includedTaskfile.Tasks {
-					// Create full analysis for each task
-					taskAnalysis := &TaskAnalysis{
-						Name:         taskName,
-						Description:  task.Desc,
-						Summary:      task.Summary,
-						Commands:     ExtractTaskCommands(task),
-						Dependencies: ExtractTaskDependencies(task),
-						Sources:      task.Sources,
-						Generates:    task.Generates,
-						Preconditions: ExtractPreconditions(task),
-						Platforms:    ExtractTaskPlatforms(task),
-						Aliases:      task.Aliases,
-						IsInternal:   task.Internal,
-						HasWatch:     false, // Would need to check for watch mode
-						UsageCount:   0,     // Would need to analyze cross-file dependencies
-						Type:         DetectTaskType(task, taskName),
-					}
-					
-					includeAnalysis.Tasks[taskName] = taskAnalysis
+
+		for _, taskName := range include.Tasks {
+			projectTask := project.Tasks[taskName]
+			taskAnalysis := analyzeProjectTask(projectTask, analysis)
+			includeAnalysis.Tasks[projectTask.LocalName] = taskAnalysis
+
+			// Tasks of other Taskfiles this include relies on
+			for _, dep := range taskAnalysis.Dependencies {
+				if depTask, exists := project.Tasks[dep]; exists && depTask.Include == include.Key {
+					continue
+				}
+				if !shared.ContainsString(includeAnalysis.Dependencies, dep) {
+					includeAnalysis.Dependencies = append(includeAnalysis.Dependencies, dep)
 				}
 			}
 		}
-		
-		// Replace the existing include analysis
-		analysis.IncludeAnalysis[name] = includeAnalysis
+		sort.Strings(includeAnalysis.Dependencies)
+
+		analysis.IncludeAnalysis[include.Key] = includeAnalysis
+	}
+
+	analysis.CircularDeps = detectCircularDependencies(analysis.TaskDependencies)
+	analysis.CriticalPath = findCriticalPath(analysis.TaskDependencies)
+	analysis.OptimizationTips = generateOptimizationTips(analysis)
+}
+
+// analyzeProjectTask analyzes a task of an included Taskfile
+func analyzeProjectTask(projectTask *ProjectTask, analysis *Analysis) *TaskAnalysis {
+	task := projectTask.Task
+	taskAnalysis := &TaskAnalysis{
+		Name:          projectTask.Name,
+		Description:   task.Desc,
+		Summary:       task.Summary,
+		Commands:      ExtractTaskCommands(task),
+		Dependencies:  projectTask.Dependencies,
+		Calls:         projectTask.Calls,
+		Sources:       task.Sources,
+		Generates:     task.Generates,
+		Variables:     task.Vars,
+		Environment:   task.Env,
+		Patterns:      make(map[string]int),
+		Preconditions: ExtractPreconditions(task),
+		Platforms:     ExtractTaskPlatforms(task),
+		Aliases:       projectTask.Aliases,
+		IsInternal:    projectTask.Internal,
+		HasWatch:      task.Watch,
+		UsageCount:    analysis.TaskUsage[projectTask.Name],
+		Type:          DetectTaskType(task, projectTask.LocalName),
+	}
+	if resolved := projectTask.resolver.ResolveTask(projectTask.LocalName, nil); resolved != nil {
+		taskAnalysis.ResolvedCommands = resolved.Commands
+		taskAnalysis.ResolvedVars = resolved.Vars
+	}
+	taskAnalysis.CallSites = projectTask.resolver.CallSites(projectTask.LocalName)
+
+	if !IsOptimizedForCaching(task) && GetTaskComplexity(task) > 2 {
+		taskAnalysis.OptimizationOps = append(taskAnalysis.OptimizationOps,
+			"Consider adding sources and generates for caching")
+	}
+	if task.Desc == "" {
+		taskAnalysis.OptimizationOps = append(taskAnalysis.OptimizationOps,
+			"Add description for better documentation")
 	}
+	taskAnalysis.OptimizationOps = append(taskAnalysis.OptimizationOps, fileCheckMessages(analysis, projectTask.Name)...)
+
+	return taskAnalysis
+}
+
+// fileCheckMessages returns the sources and generates findings of a task
+func fileCheckMessages(analysis *Analysis, taskName string) []string {
+	var messages []string
+	for _, tip := range analysis.FileChecks {
+		if tip.Task == taskName {
+			messages = append(messages, tip.Message)
+		}
+	}
+	return messages
 }
 
 // analyzeTaskDependencies analyzes task dependencies and usage patterns
 func analyzeTaskDependencies(taskfile *Taskfile, analysis *Analysis) {
 	for taskName, task := range taskfile.Tasks {
+		analysis.TaskCalls[taskName] = ExtractTaskCalls(task)
 		deps := ExtractTaskDependencies(task)
 		analysis.TaskDependencies[taskName] = deps
 		
@@ -200,78 +252,22 @@ func analyzeVariables(taskfile *Taskfile, analysis *Analysis) {
 	}
 }
 
-// analyzeIncludes analyzes included taskfiles
+// analyzeIncludes records the includes of a Taskfile. Their paths are
+// relative to the Taskfile, so AnalyzeIncludesWithPath loads them.
 func analyzeIncludes(taskfile *Taskfile, analysis *Analysis) {
 	for name, includeRaw := range taskfile.Includes {
-		includeAnalysis := &IncludeAnalysis{
+		include := parseInclude(includeRaw)
+		analysis.IncludeAnalysis[name] = &IncludeAnalysis{
+			Path:      include.Taskfile,
 			Namespace: name,
+			Dir:       include.Dir,
+			Optional:  include.Optional,
+			Flatten:   include.Flatten,
+			Internal:  include.Internal,
+			Aliases:   include.Aliases,
+			Excludes:  include.Excludes,
 			Tasks:     make(map[string]*TaskAnalysis),
 		}
-		
-		// Handle different include formats (string or object)
-		var includePath string
-		var includeDir string
-		
-		switch include := includeRaw.(type) {
-		case string:
-			includePath = include
-			includeAnalysis.Path = include
-		case map[string]interface{}:
-			if taskfilePath, ok := include["taskfile"].(string); ok {
-				includePath = taskfilePath
-				includeAnalysis.Path = taskfilePath
-			}
-			if dir, ok := include["dir"].(string); ok {
-				includeDir = dir
-			}
-		}
-		
-		// Try to parse included taskfile for deeper analysis
-		if includePath != "" {
-			// Create a mock Include struct for ParseIncludedTaskfile
-			mockInclude := Include{
-				Taskfile: includePath,
-				Dir:      includeDir,
-			}
-			
-			// Get the directory of the main taskfile for relative path resolution
-			// We need to pass the base path where the main Taskfile.yml is located
-			basePath := "."
-			
-			// Parse the included taskfile (this function handles relative paths)
-			includedTaskfile, err := ParseIncludedTaskfile(mockInclude, basePath)
-			if err != nil {
-				// If we can't parse it, just record basic info
-				includeAnalysis.TaskCount = 0
-			} else {
-				// Analyze each task in the included file
-				includeAnalysis.TaskCount = len(includedTaskfile.Tasks)
-				
-				for taskName, task := range includedTaskfile.Tasks {
-					// Create full analysis for each task
-					taskAnalysis := &TaskAnalysis{
-						Name:         taskName,
-						Description:  task.Desc,
-						Summary:      task.Summary,
-						Commands:     ExtractTaskCommands(task),
-						Dependencies: ExtractTaskDependencies(task),
-						Sources:      task.Sources,
-						Generates:    task.Generates,
-						Preconditions: ExtractPreconditions(task),
-						Platforms:    ExtractTaskPlatforms(task),
-						Aliases:      task.Aliases,
-						IsInternal:   task.Internal,
-						HasWatch:     false, // Would need to check for watch mode
-						UsageCount:   0,     // Would need to analyze cross-file dependencies
-						Type:         DetectTaskType(task, taskName),
-					}
-					
-					includeAnalysis.Tasks[taskName] = taskAnalysis
-				}
-			}
-		}
-		
-		analysis.IncludeAnalysis[name] = includeAnalysis
 	}
 }
 
@@ -330,12 +326,18 @@ func detectCircularDependencies(dependencies map[string][]string) [][]string {
 func findCriticalPath(dependencies map[string][]string) []string {
 	depths := make(map[string]int)
 	paths := make(map[string][]string)
+	visiting := make(map[string]bool)
 	
 	var calculateDepth func(string) int
 	calculateDepth = func(task string) int {
 		if depth, exists := depths[task]; exists {
 			return depth
 		}
+		if visiting[task] {
+			return 0 // Circular dependency
+		}
+		visiting[task] = true
+		defer func() { visiting[task] = false }()
 		
 		maxDepth := 0
 		longestPath := []string{task}
@@ -368,11 +370,12 @@ func findCriticalPath(dependencies map[string][]string) []string {
 }
 
 // generateOptimizationTips generates optimization suggestions
-func generateOptimizationTips(taskfile *Taskfile, analysis *Analysis) []OptimizationTip {
+func generateOptimizationTips(analysis *Analysis) []OptimizationTip {
 	var tips []OptimizationTip
+	tasks := analysisTasks(analysis)
 	
 	// Check for tasks without caching optimization
-	for taskName, task := range taskfile.Tasks {
+	for taskName, task := range tasks {
 		if !IsOptimizedForCaching(task) && GetTaskComplexity(task) > 2 {
 			tips = append(tips, OptimizationTip{
 				Type:       "caching",
@@ -396,7 +399,7 @@ func generateOptimizationTips(taskfile *Taskfile, analysis *Analysis) []Optimiza
 	}
 	
 	// Check for unused tasks
-	for taskName := range taskfile.Tasks {
+	for taskName := range tasks {
 		if analysis.TaskUsage[taskName] == 0 {
 			// Check if it's a root task (not depended on by others)
 			tips = append(tips, OptimizationTip{
@@ -409,8 +412,11 @@ func generateOptimizationTips(taskfile *Taskfile, analysis *Analysis) []Optimiza
 		}
 	}
 	
+	// Sources and generates findings, once the tree is known
+	tips = append(tips, analysis.FileChecks...)
+	
 	// Check for missing descriptions
-	for taskName, task := range taskfile.Tasks {
+	for taskName, task := range tasks {
 		if task.Desc == "" && !task.Internal {
 			tips = append(tips, OptimizationTip{
 				Type:       "documentation",
@@ -425,6 +431,21 @@ func generateOptimizationTips(taskfile *Taskfile, analysis *Analysis) []Optimiza
 	return tips
 }
 
+// analysisTasks returns the analyzed tasks by full name: those of the whole
+// project once includes are loaded, otherwise those of the Taskfile
+func analysisTasks(analysis *Analysis) map[string]Task {
+	if analysis.Project == nil {
+		return analysis.Taskfile.Tasks
+	}
+	tasks := make(map[string]Task, len(analysis.Project.Tasks))
+	for taskName, projectTask := range analysis.Project.Tasks {
+		task := projectTask.Task
+		task.Internal = projectTask.Internal
+		tasks[taskName] = task
+	}
+	return tasks
+}
+
 // AnalyzeTask performs detailed analysis of a single task
 func AnalyzeTask(taskfile *Taskfile, taskName string, analysis *Analysis) *TaskAnalysis {
 	task, exists := taskfile.Tasks[taskName]
@@ -452,6 +473,21 @@ func AnalyzeTask(taskfile *Taskfile, taskName string, analysis *Analysis) *TaskA
 		Type:            DetectTaskType(task, taskName),
 	}
 
+	// Once includes are loaded, dependencies use full task names
+	taskAnalysis.Calls = ExtractTaskCalls(task)
+	if analysis.Project != nil {
+		taskAnalysis.Dependencies = analysis.TaskDependencies[taskName]
+		taskAnalysis.Calls = analysis.TaskCalls[taskName]
+	}
+
+	// Render the commands with the variables go-task would pass them
+	resolver := NewVarResolver(taskfile)
+	if resolved := resolver.ResolveTask(taskName, nil); resolved != nil {
+		taskAnalysis.ResolvedCommands = resolved.Commands
+		taskAnalysis.ResolvedVars = resolved.Vars
+	}
+	taskAnalysis.CallSites = resolver.CallSites(taskName)
+
 	// Count pattern usage in this task
 	for pattern, patternCount := range analysis.CommandPatterns {
 		for _, patternTask := range patternCount.Tasks {
@@ -471,6 +507,7 @@ func AnalyzeTask(taskfile *Taskfile, taskName string, analysis *Analysis) *TaskA
 		taskAnalysis.OptimizationOps = append(taskAnalysis.OptimizationOps, 
 			"Add description for better documentation")
 	}
+	taskAnalysis.OptimizationOps = append(taskAnalysis.OptimizationOps, fileCheckMessages(analysis, taskName)...)
 
 	return taskAnalysis
 }
@@ -622,9 +659,14 @@ func GetTasksByPattern(analysis *Analysis, pattern string) []string {
 
 // BuildDependencyGraph creates a dependency graph structure
 func BuildDependencyGraph(analysis *Analysis) *DependencyGraph {
+	taskNames := GetAllTaskNames(analysis.Taskfile)
+	if analysis.Project != nil {
+		taskNames = analysis.Project.TaskNames()
+	}
 	graph := &DependencyGraph{
-		Tasks:  GetAllTaskNames(analysis.Taskfile),
+		Tasks:  taskNames,
 		Edges:  analysis.TaskDependencies,
+		Calls:  analysis.TaskCalls,
 		Levels: make(map[string]int),
 		Cycles: analysis.CircularDeps,
 	}
@@ -0,0 +1,387 @@
+package gotask
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// fileCheckTip is the optimization tip type of the sources and generates checks
+const fileCheckTip = "files"
+
+// skippedTreeDirs are not listed when expanding globs: version control and
+// the analyzer's own output
+var skippedTreeDirs = map[string]bool{".git": true, ".discovery": true}
+
+// writePatterns find the files a command writes. The last group is the path;
+// the -o pattern also captures the program so option uses of -o are skipped.
+var writePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|[\s\d])>>?\s*([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\btee\s+(?:-a\s+)?([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\btouch\s+([^\s;&|<>()]+)`),
+	regexp.MustCompile(`(?:^|[;&|(]\s*)([^\s;&|<>()]+)[^;&|]*?\s-o\s*([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\bwget\b[^;&|]*?\s-O\s*([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\s--output(?:-file)?(?:\s+|=)([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\btar\s+-?[A-Za-z]*c[A-Za-z]*f\s+([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\btar\b[^;&|]*?\s(?:-c|--create)\b[^;&|]*?\s(?:-f\s*|--file[=\s]\s*)([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\bzip\s+(?:-[A-Za-z0-9]+\s+)*([^\s;&|<>()]+)`),
+	regexp.MustCompile(`\b(?:cp|mv)\s+(?:[^\s;&|<>()]+\s+)+([^\s;&|<>()]+)\s*(?:$|[;&|)])`),
+}
+
+// optionOutputCommands use -o for something other than an output file
+var optionOutputCommands = map[string]bool{
+	"set":   true,
+	"grep":  true,
+	"egrep": true,
+	"ssh":   true,
+	"scp":   true,
+	"unzip": true,
+	"mount": true,
+	"ps":    true,
+	"bash":  true,
+	"sh":    true,
+}
+
+// taskFiles is a task's sources and generates expanded against the tree
+type taskFiles struct {
+	name        string
+	sources     []string // Globs relative to the root Taskfile's directory
+	excludes    []string
+	generates   []string
+	sourceFiles map[string]bool // Files the sources match, after excludes
+	unmatched   []string        // Source globs that match no files
+}
+
+// checkTaskFiles expands the sources and generates globs of the project's
+// tasks against the tree of the root Taskfile. It reports globs that match
+// nothing, tasks that read another task's outputs without depending on it
+// and files the commands write that generates does not declare.
+func checkTaskFiles(project *Project, dependencies map[string][]string) []OptimizationTip {
+	root := filepath.Dir(project.Root)
+	var tree []string
+	var err error
+	for _, projectTask := range project.Tasks {
+		// Only list the tree when there are globs to expand
+		if len(projectTask.Task.Sources) > 0 || len(projectTask.Task.Generates) > 0 {
+			tree, err = listTree(root)
+			break
+		}
+	}
+	if err != nil {
+		shared.GetLogger().Warn("GoTask", "Skipping sources and generates checks", map[string]interface{}{
+			"dir":   root,
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	var tips []OptimizationTip
+	var checked []*taskFiles
+	for _, taskName := range project.TaskNames() {
+		projectTask := project.Tasks[taskName]
+		task := projectTask.Task
+		if len(task.Sources) == 0 && len(task.Generates) == 0 && len(ExtractTaskCommands(task)) == 0 {
+			continue
+		}
+
+		// Globs and written paths are relative to the task's directory
+		dir := filepath.ToSlash(projectTask.Dir)
+		if task.Dir != "" {
+			taskDir, ok := projectTask.resolver.RenderTask(projectTask.LocalName, task.Dir)
+			if !ok || path.IsAbs(taskDir) {
+				continue
+			}
+			dir = path.Join(dir, filepath.ToSlash(taskDir))
+		}
+		files := &taskFiles{name: taskName, sourceFiles: make(map[string]bool)}
+		files.sources = treeGlobs(projectTask, dir, task.Sources)
+		files.excludes = treeGlobs(projectTask, dir, task.SourceExcludes)
+		files.generates = treeGlobs(projectTask, dir, task.Generates)
+
+		for _, glob := range files.sources {
+			matches := expandGlob(tree, root, glob)
+			if len(matches) == 0 {
+				files.unmatched = append(files.unmatched, glob)
+			}
+			for _, match := range matches {
+				files.sourceFiles[match] = true
+			}
+		}
+		for _, glob := range files.excludes {
+			excluded := 0
+			for file := range files.sourceFiles {
+				if matchGlob(glob, file) {
+					delete(files.sourceFiles, file)
+					excluded++
+				}
+			}
+			if excluded == 0 {
+				tips = append(tips, OptimizationTip{
+					Type:       fileCheckTip,
+					Task:       taskName,
+					Message:    fmt.Sprintf("Exclude glob `%s` matches none of the task's sources", glob),
+					Severity:   "low",
+					Suggestion: "Remove the exclude or fix its pattern",
+				})
+			}
+		}
+		for _, glob := range files.generates {
+			if len(expandGlob(tree, root, glob)) == 0 {
+				tips = append(tips, OptimizationTip{
+					Type:       fileCheckTip,
+					Task:       taskName,
+					Message:    fmt.Sprintf("Generates glob `%s` matches no files in the tree", glob),
+					Severity:   "low",
+					Suggestion: "Expected when outputs are not committed; otherwise check that the path is where the commands write",
+				})
+			}
+		}
+
+		// Written files, found heuristically in the resolved commands
+		commands := ExtractTaskCommands(task)
+		if resolved := projectTask.resolver.ResolveTask(projectTask.LocalName, nil); resolved != nil {
+			commands = resolved.Commands
+		}
+		var undeclared []string
+		for _, written := range detectWrites(commands) {
+			file := path.Join(dir, written)
+			if strings.HasPrefix(file, "../") || declaresOutput(files.generates, file) || shared.ContainsString(undeclared, file) {
+				continue
+			}
+			undeclared = append(undeclared, file)
+			tips = append(tips, OptimizationTip{
+				Type:       fileCheckTip,
+				Task:       taskName,
+				Message:    fmt.Sprintf("Commands write `%s`, which generates does not declare", file),
+				Severity:   "low",
+				Suggestion: "Add the file to generates so go-task can tell when the output is missing or stale",
+			})
+		}
+
+		checked = append(checked, files)
+	}
+
+	// Sources that are another task's outputs need not exist yet
+	for _, consumer := range checked {
+		for _, glob := range consumer.unmatched {
+			generated := false
+			for _, producer := range checked {
+				for _, output := range producer.generates {
+					generated = generated || matchGlob(glob, output) || matchGlob(output, glob)
+				}
+			}
+			if generated {
+				continue
+			}
+			tips = append(tips, OptimizationTip{
+				Type:       fileCheckTip,
+				Task:       consumer.name,
+				Message:    fmt.Sprintf("Source glob `%s` matches no files", glob),
+				Severity:   "medium",
+				Suggestion: "Fix or remove the pattern; sources that match nothing never invalidate the task's cache",
+			})
+		}
+	}
+
+	// A task reading another task's outputs must run after it
+	for _, consumer := range checked {
+		for _, producer := range checked {
+			if consumer == producer || len(producer.generates) == 0 || len(consumer.sources) == 0 {
+				continue
+			}
+			overlap := overlappingGlob(producer, consumer)
+			if overlap == "" || dependsOn(dependencies, consumer.name, producer.name) {
+				continue
+			}
+			tips = append(tips, OptimizationTip{
+				Type:       fileCheckTip,
+				Task:       consumer.name,
+				Message:    fmt.Sprintf("Sources include `%s`, generated by %s, without a dependency on it", overlap, producer.name),
+				Severity:   "medium",
+				Suggestion: fmt.Sprintf("Add %s to the deps of %s so its outputs are up to date first", producer.name, consumer.name),
+			})
+		}
+	}
+
+	return tips
+}
+
+// treeGlobs renders a task's globs and makes them relative to the root
+// Taskfile's directory. Globs only known at run time or outside the tree are
+// dropped.
+func treeGlobs(projectTask *ProjectTask, dir string, globs []string) []string {
+	var result []string
+	for _, glob := range globs {
+		rendered, ok := projectTask.resolver.RenderTask(projectTask.LocalName, glob)
+		if !ok || path.IsAbs(rendered) {
+			continue
+		}
+		rendered = path.Join(dir, filepath.ToSlash(rendered))
+		if rendered == ".." || strings.HasPrefix(rendered, "../") {
+			continue
+		}
+		result = append(result, rendered)
+	}
+	return result
+}
+
+// listTree lists the files under root as slash-separated relative paths
+func listTree(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if filePath != root && skippedTreeDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relative, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relative))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// expandGlob returns the files of the tree a glob matches. A glob without
+// wildcards also matches an existing directory.
+func expandGlob(tree []string, root, glob string) []string {
+	if !strings.ContainsAny(glob, "*?[") {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(glob))); err == nil {
+			return []string{glob}
+		}
+		return nil
+	}
+
+	var matches []string
+	for _, file := range tree {
+		if matchGlob(glob, file) {
+			matches = append(matches, file)
+		}
+	}
+	return matches
+}
+
+// matchGlob reports whether a slash-separated path matches a glob, where **
+// matches any number of directories
+func matchGlob(glob, name string) bool {
+	return matchGlobSegments(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+// matchGlobSegments matches path segments against glob segments
+func matchGlobSegments(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(glob[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], name[0]); !ok {
+			return false
+		}
+		glob, name = glob[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// declaresOutput reports whether generates globs cover a file, directly or
+// through a generated directory
+func declaresOutput(generates []string, file string) bool {
+	for _, glob := range generates {
+		// A copy into a directory writes the directory the outputs live in
+		if matchGlob(glob, file) || strings.HasPrefix(file, strings.TrimSuffix(glob, "/")+"/") || strings.HasPrefix(glob, file+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// overlappingGlob returns a producer's generates glob that the consumer's
+// sources read, or "" when they are disjoint. Outputs often do not exist yet,
+// so the globs are compared with each other as well as by matched files.
+func overlappingGlob(producer, consumer *taskFiles) string {
+	for _, generated := range producer.generates {
+		for file := range consumer.sourceFiles {
+			if matchGlob(generated, file) || file == generated {
+				return generated
+			}
+		}
+		for _, source := range consumer.sources {
+			if !matchGlob(source, generated) && !matchGlob(generated, source) {
+				continue
+			}
+			excluded := false
+			for _, exclude := range consumer.excludes {
+				if matchGlob(exclude, generated) {
+					excluded = true
+				}
+			}
+			if !excluded {
+				return generated
+			}
+		}
+	}
+	return ""
+}
+
+// dependsOn reports whether a task runs another through its deps and calls
+func dependsOn(dependencies map[string][]string, from, to string) bool {
+	visited := make(map[string]bool)
+	pending := []string{from}
+	for len(pending) > 0 {
+		task := pending[0]
+		pending = pending[1:]
+		for _, dep := range dependencies[task] {
+			if dep == to {
+				return true
+			}
+			if !visited[dep] {
+				visited[dep] = true
+				pending = append(pending, dep)
+			}
+		}
+	}
+	return false
+}
+
+// detectWrites finds the relative paths commands write to, such as
+// redirects, tee, touch, archives, copies and -o flags
+func detectWrites(commands []string) []string {
+	var written []string
+	for _, command := range commands {
+		for _, pattern := range writePatterns {
+			for _, match := range pattern.FindAllStringSubmatch(command, -1) {
+				if len(match) > 2 && optionOutputCommands[path.Base(match[1])] {
+					continue
+				}
+				target := strings.Trim(match[len(match)-1], `"'`)
+				if target == "" || target == "-" || strings.ContainsAny(target, "{}$&*?`") ||
+					strings.HasPrefix(target, "/") || strings.HasPrefix(target, "~") || strings.HasPrefix(target, "-") {
+					continue
+				}
+				written = append(written, path.Clean(target))
+			}
+		}
+	}
+	return written
+}
@@ -0,0 +1,338 @@
+package gotask
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// Project is a Taskfile with its includes loaded recursively into one
+// namespaced task graph, as go-task sees it when run from the root Taskfile
+type Project struct {
+	Root       string                  // Path of the root Taskfile
+	Tasks      map[string]*ProjectTask // By full name, such as docker:lint:run
+	Includes   []*ProjectInclude       // Parents before their children
+	namespaces map[string]string       // Namespace aliases, such as d, to full namespaces
+	aliases    map[string]string       // Full task aliases to full task names
+}
+
+// ProjectTask is a task of the project under its namespaced name
+type ProjectTask struct {
+	Name         string // Full name, such as docker:build
+	LocalName    string // Name in the Taskfile that defines it
+	Namespace    string // Namespace its references resolve in; empty for the root
+	Include      string // Key of the include that contributed it; empty for the root
+	Taskfile     string // Path of the Taskfile that defines it
+	Dir          string // Working directory given by the include's dir:
+	Task         Task
+	Internal     bool       // Internal itself or through an internal include
+	Aliases      []string   // Full alias names
+	Calls        []TaskCall // deps:, cmds and deferred task calls resolved to full task names
+	Dependencies []string   // Names of the called tasks; unknown references are kept as written
+	Unresolved   []string   // Called tasks that match no task of the project
+	resolver     *VarResolver
+}
+
+// ProjectInclude is one includes: entry of the project's Taskfiles
+type ProjectInclude struct {
+	Key       string // Full include name, such as docker:lint
+	Namespace string // Namespace of its tasks; the parent's namespace when flattened
+	Parent    string // Key of the including include; empty for the root Taskfile
+	Path      string // taskfile: as written
+	Taskfile  string // Resolved path of the included Taskfile
+	Dir       string
+	Optional  bool
+	Flatten   bool
+	Internal  bool
+	Aliases   []string
+	Excludes  []string
+	Vars      map[string]interface{}
+	Tasks     []string // Full names of the tasks it contributes, sorted
+	Error     string   // Why it was not loaded; empty when loaded or optional and missing
+}
+
+// LoadProject loads the includes of a Taskfile recursively, honoring dir,
+// optional, flatten, internal, aliases, excludes and vars
+func LoadProject(taskfile *Taskfile, taskfilePath string) *Project {
+	project := &Project{
+		Root:       taskfilePath,
+		Tasks:      make(map[string]*ProjectTask),
+		namespaces: make(map[string]string),
+		aliases:    make(map[string]string),
+	}
+
+	root := &projectLoader{
+		project:  project,
+		taskfile: taskfile,
+		path:     taskfilePath,
+		resolver: NewVarResolver(taskfile),
+		stack:    []string{absPath(taskfilePath)},
+	}
+	root.addTasks(nil)
+	root.loadIncludes()
+
+	for _, task := range project.Tasks {
+		for _, call := range ExtractTaskCalls(task.Task) {
+			ref, _ := task.resolver.Render(call.Task)
+			name, ok := project.ResolveTaskRef(task.Namespace, ref)
+			if !ok {
+				task.Unresolved = append(task.Unresolved, ref)
+			}
+			call.Task = name
+			task.Calls = append(task.Calls, call)
+			task.Dependencies = append(task.Dependencies, name)
+		}
+	}
+	return project
+}
+
+// projectLoader loads one Taskfile of the project and its includes
+type projectLoader struct {
+	project   *Project
+	taskfile  *Taskfile
+	path      string
+	include   *ProjectInclude // Nil for the root Taskfile
+	namespace string
+	dir       string
+	internal  bool
+	resolver  *VarResolver
+	stack     []string // Absolute paths of the including Taskfiles, for cycle detection
+}
+
+// addTasks adds the Taskfile's tasks to the project under its namespace
+func (l *projectLoader) addTasks(excludes []string) {
+	key := ""
+	if l.include != nil {
+		key = l.include.Key
+	}
+
+	for _, localName := range sortedVarNames(l.taskfile.Tasks) {
+		if shared.ContainsString(excludes, localName) {
+			continue
+		}
+		task := l.taskfile.Tasks[localName]
+		name := joinNamespace(l.namespace, localName)
+		projectTask := &ProjectTask{
+			Name:      name,
+			LocalName: localName,
+			Namespace: l.namespace,
+			Include:   key,
+			Taskfile:  l.path,
+			Dir:       l.dir,
+			Task:      task,
+			Internal:  task.Internal || l.internal,
+			resolver:  l.resolver,
+		}
+		for _, alias := range task.Aliases {
+			fullAlias := joinNamespace(l.namespace, alias)
+			projectTask.Aliases = append(projectTask.Aliases, fullAlias)
+			l.project.aliases[fullAlias] = name
+		}
+		l.project.Tasks[name] = projectTask
+		if l.include != nil {
+			l.include.Tasks = append(l.include.Tasks, name)
+		}
+	}
+}
+
+// loadIncludes loads the includes: entries of the Taskfile
+func (l *projectLoader) loadIncludes() {
+	parent := ""
+	if l.include != nil {
+		parent = l.include.Key
+	}
+
+	for _, name := range sortedVarNames(l.taskfile.Includes) {
+		include := parseInclude(l.taskfile.Includes[name])
+		projectInclude := &ProjectInclude{
+			Key:       joinNamespace(parent, name),
+			Namespace: joinNamespace(l.namespace, name),
+			Parent:    parent,
+			Path:      include.Taskfile,
+			Dir:       include.Dir,
+			Optional:  include.Optional,
+			Flatten:   include.Flatten,
+			Internal:  include.Internal,
+			Aliases:   include.Aliases,
+			Excludes:  include.Excludes,
+			Vars:      include.Vars,
+		}
+		if include.Flatten {
+			projectInclude.Namespace = l.namespace
+		}
+		l.project.Includes = append(l.project.Includes, projectInclude)
+		for _, alias := range include.Aliases {
+			l.project.namespaces[joinNamespace(l.namespace, alias)] = projectInclude.Namespace
+		}
+
+		includePath, err := l.includePath(include)
+		if err != nil {
+			if !include.Optional {
+				projectInclude.Error = err.Error()
+			}
+			continue
+		}
+		projectInclude.Taskfile = includePath
+
+		if shared.ContainsString(l.stack, absPath(includePath)) {
+			projectInclude.Error = fmt.Sprintf("include cycle through %s", include.Taskfile)
+			continue
+		}
+		if len(l.stack) > maxIncludeDepth {
+			projectInclude.Error = fmt.Sprintf("includes nested deeper than %d levels", maxIncludeDepth)
+			continue
+		}
+
+		included, err := ParseTaskfile(includePath)
+		if err != nil {
+			projectInclude.Error = err.Error()
+			continue
+		}
+
+		// Tasks run in the including Taskfile's working directory unless dir: is given
+		dir := l.dir
+		if include.Dir != "" {
+			includeDir, _ := l.resolver.Render(include.Dir)
+			dir = includeDir
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(filepath.Dir(l.relativePath()), dir)
+			}
+			projectInclude.Dir = includeDir
+		}
+
+		child := &projectLoader{
+			project:   l.project,
+			taskfile:  included,
+			path:      includePath,
+			include:   projectInclude,
+			namespace: projectInclude.Namespace,
+			dir:       dir,
+			internal:  l.internal || include.Internal,
+			resolver:  l.resolver.Included(projectInclude.Namespace, include.Vars, included),
+			stack:     append(append([]string{}, l.stack...), absPath(includePath)),
+		}
+		child.addTasks(include.Excludes)
+		child.loadIncludes()
+		sort.Strings(projectInclude.Tasks)
+	}
+}
+
+// includePath finds the Taskfile an include refers to. The path is relative
+// to the including Taskfile and may be a directory or use its variables.
+func (l *projectLoader) includePath(include Include) (string, error) {
+	if include.Taskfile == "" {
+		return "", fmt.Errorf("include has no taskfile")
+	}
+	includePath, ok := l.resolver.Render(include.Taskfile)
+	if !ok {
+		return "", fmt.Errorf("taskfile path %s is only known at run time", include.Taskfile)
+	}
+	if !filepath.IsAbs(includePath) {
+		includePath = filepath.Join(filepath.Dir(l.path), includePath)
+	}
+
+	stat, err := os.Stat(includePath)
+	if err != nil {
+		return "", fmt.Errorf("taskfile %s not found", include.Taskfile)
+	}
+	if stat.IsDir() {
+		return FindTaskfile(includePath)
+	}
+	return includePath, nil
+}
+
+// relativePath returns the Taskfile's path relative to the root Taskfile's directory
+func (l *projectLoader) relativePath() string {
+	relative, err := filepath.Rel(filepath.Dir(l.project.Root), l.path)
+	if err != nil {
+		return l.path
+	}
+	return relative
+}
+
+// ResolveTaskRef resolves a task reference made in a namespace to a full task
+// name. A leading colon refers to the root Taskfile; other references are
+// tried in the namespace first, then from the root. Unknown references are
+// returned as written.
+func (p *Project) ResolveTaskRef(namespace, ref string) (string, bool) {
+	if strings.HasPrefix(ref, ":") {
+		return p.resolveFromRoot(strings.TrimPrefix(ref, ":"))
+	}
+	if namespace != "" {
+		if name, ok := p.resolveFromRoot(joinNamespace(namespace, ref)); ok {
+			return name, true
+		}
+	}
+	return p.resolveFromRoot(ref)
+}
+
+// resolveFromRoot resolves a full task reference, following namespace and
+// task aliases
+func (p *Project) resolveFromRoot(ref string) (string, bool) {
+	if _, exists := p.Tasks[ref]; exists {
+		return ref, true
+	}
+
+	// Replace aliased namespaces one segment at a time, such as d:lint:run
+	segments := strings.Split(ref, ":")
+	namespace := ""
+	for _, segment := range segments[:len(segments)-1] {
+		next := joinNamespace(namespace, segment)
+		if canonical, ok := p.namespaces[next]; ok {
+			next = canonical
+		}
+		namespace = next
+	}
+	name := joinNamespace(namespace, segments[len(segments)-1])
+	if _, exists := p.Tasks[name]; exists {
+		return name, true
+	}
+	if target, ok := p.aliases[name]; ok {
+		return target, true
+	}
+	return ref, false
+}
+
+// TaskNames returns the full names of all tasks of the project, sorted
+func (p *Project) TaskNames() []string {
+	return sortedVarNames(p.Tasks)
+}
+
+// parseInclude reads an includes: entry, which is a path or a mapping
+func parseInclude(includeRaw interface{}) Include {
+	var include Include
+	switch value := includeRaw.(type) {
+	case string:
+		include.Taskfile = value
+	case map[string]interface{}:
+		include.Taskfile, _ = value["taskfile"].(string)
+		include.Dir, _ = value["dir"].(string)
+		include.Optional, _ = value["optional"].(bool)
+		include.Flatten, _ = value["flatten"].(bool)
+		include.Internal, _ = value["internal"].(bool)
+		include.Aliases = interfaceStrings(value["aliases"])
+		include.Excludes = interfaceStrings(value["excludes"])
+		include.Vars, _ = value["vars"].(map[string]interface{})
+	}
+	return include
+}
+
+// joinNamespace prefixes a name with a namespace
+func joinNamespace(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + ":" + name
+}
+
+// absPath returns the absolute form of a path, or the path itself
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
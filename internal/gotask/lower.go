@@ -0,0 +1,90 @@
+package gotask
+
+import (
+	"path"
+	"path/filepath"
+
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+)
+
+// Lower converts an analyzed Taskfile into the shared pipeline IR, with one
+// IR job per task. Once includes are loaded, every task of the namespaced
+// project graph is lowered under its full name, such as docker:build.
+func Lower(analysis *Analysis, source string) *ir.Pipeline {
+	taskfile := analysis.Taskfile
+	pipeline := &ir.Pipeline{
+		Tool:   ir.ToolGoTask,
+		Name:   "Taskfile",
+		Source: source,
+		Env:    ir.EnvFromMap(taskfile.Env),
+	}
+
+	if project := analysis.Project; project != nil {
+		for _, taskName := range project.TaskNames() {
+			projectTask := project.Tasks[taskName]
+			resolve := func(ref string) string {
+				if name, ok := project.ResolveTaskRef(projectTask.Namespace, ref); ok {
+					return name
+				}
+				return ref
+			}
+			dir := projectTask.Task.Dir
+			if projectTask.Dir != "" && !path.IsAbs(dir) {
+				dir = path.Join(filepath.ToSlash(projectTask.Dir), dir)
+			}
+			pipeline.Jobs = append(pipeline.Jobs, lowerTask(taskName, projectTask.Task, dir, projectTask.Calls, resolve))
+		}
+	} else {
+		for taskName, task := range taskfile.Tasks {
+			resolve := func(ref string) string { return ref }
+			pipeline.Jobs = append(pipeline.Jobs, lowerTask(taskName, task, task.Dir, ExtractTaskCalls(task), resolve))
+		}
+	}
+
+	pipeline.SortJobs()
+	return pipeline
+}
+
+// lowerTask converts one task into an IR job. calls are its task calls and
+// resolve maps a task: reference in cmds to the name of the called task.
+func lowerTask(name string, task Task, dir string, calls []TaskCall, resolve func(string) string) *ir.Job {
+	job := &ir.Job{
+		ID:         name,
+		Name:       task.Desc,
+		WorkingDir: dir,
+		Env:        ir.EnvFromMap(task.Env),
+	}
+
+	// Task call kinds match the IR dependency kinds
+	for _, call := range calls {
+		job.Needs = append(job.Needs, ir.Dependency{Target: call.Task, Kind: call.Kind})
+	}
+
+	if task.Cmd != "" {
+		job.Steps = append(job.Steps, ir.Step{Kind: ir.StepRun, Commands: ir.SplitCommands(task.Cmd)})
+	}
+	for _, cmd := range task.Cmds {
+		command := extractCommand(cmd)
+		if command == "" {
+			continue
+		}
+
+		// Task calls, deferred or not, are steps as well as edges
+		if cmdMap, ok := cmd.(map[string]interface{}); ok {
+			if deferred, ok := cmdMap["defer"].(map[string]interface{}); ok {
+				cmdMap = deferred
+			}
+			if called, ok := cmdMap["task"].(string); ok {
+				job.Steps = append(job.Steps, ir.Step{Kind: ir.StepTask, Uses: resolve(called)})
+				continue
+			}
+		}
+
+		step := ir.Step{Kind: ir.StepRun, Commands: ir.SplitCommands(command)}
+		if len(step.Commands) > 0 {
+			job.Steps = append(job.Steps, step)
+		}
+	}
+
+	return job
+}
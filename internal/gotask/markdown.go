@@ -2,6 +2,7 @@ package gotask
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -32,6 +33,13 @@ func GenerateMainReadme(analysis *Analysis, taskfilePath string) string {
 		sb.WriteString(fmt.Sprintf("- **Critical Path Length:** %d tasks\n", len(analysis.CriticalPath)))
 	}
 	
+	errors, warnings := CountDiagnostics(analysis.Diagnostics)
+	if errors+warnings > 0 {
+		sb.WriteString(fmt.Sprintf("- **Schema Diagnostics:** %d errors, %d warnings ⚠️\n", errors, warnings))
+	} else {
+		sb.WriteString("- **Schema Diagnostics:** None ✅\n")
+	}
+	
 	// Performance metrics
 	metrics := GetPerformanceMetrics(analysis.Taskfile)
 	sb.WriteString(fmt.Sprintf("- **Tasks with Caching:** %d/%d (%.1f%%)\n", 
@@ -40,6 +48,21 @@ func GenerateMainReadme(analysis *Analysis, taskfilePath string) string {
 	
 	sb.WriteString("\n")
 
+	// Schema diagnostics
+	if len(analysis.Diagnostics) > 0 {
+		sb.WriteString("## 🩺 Schema Diagnostics\n\n")
+		sb.WriteString("Findings of the Taskfile v3 schema check; `pipeline-analyzer lint` prints the same list.\n\n")
+		sb.WriteString("| Location | Severity | Message | Rule |\n")
+		sb.WriteString("|----------|----------|---------|------|\n")
+		for _, diagnostic := range analysis.Diagnostics {
+			diagnostic = diagnostic.RelativeTo(filepath.Dir(taskfilePath))
+			sb.WriteString(fmt.Sprintf("| `%s:%d:%d` | %s | %s | %s |\n",
+				diagnostic.File, diagnostic.Line, diagnostic.Column, diagnostic.Severity,
+				strings.ReplaceAll(diagnostic.Message, "|", "\\|"), diagnostic.Rule))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Workflow diagram
 	diagram := GenerateTaskflowDiagram(analysis)
 	if diagram != "" {
@@ -61,6 +84,9 @@ func GenerateMainReadme(analysis *Analysis, taskfilePath string) string {
 	sb.WriteString("### Tasks\n")
 	sb.WriteString("Individual task analysis with commands and optimization opportunities:\n\n")
 	taskNames := GetAllTaskNames(analysis.Taskfile)
+	if analysis.Project != nil {
+		taskNames = analysis.Project.TaskNames()
+	}
 	sort.Strings(taskNames)
 	for _, taskName := range taskNames {
 		normalizedName := NormalizeTaskName(taskName)
@@ -71,7 +97,10 @@ func GenerateMainReadme(analysis *Analysis, taskfilePath string) string {
 	if analysis.TotalIncludes > 0 {
 		sb.WriteString("### Includes\n")
 		sb.WriteString("Analysis of included Taskfiles:\n\n")
-		includeNames := GetAllIncludeNames(analysis.Taskfile)
+		var includeNames []string
+		for includeName := range analysis.IncludeAnalysis {
+			includeNames = append(includeNames, includeName)
+		}
 		sort.Strings(includeNames)
 		for _, includeName := range includeNames {
 			normalizedName := NormalizeTaskName(includeName)
@@ -221,6 +250,17 @@ func GenerateOptimizationGuide(analysis *Analysis) string {
 		}
 	}
 
+	// Sources and generates
+	if tips, exists := tipsByType[fileCheckTip]; exists {
+		sb.WriteString("## 📂 Sources & Generates\n\n")
+		sb.WriteString("Checked against the repository tree; wrong globs and undeclared outputs make cached tasks stale:\n\n")
+		
+		for _, tip := range tips {
+			sb.WriteString(fmt.Sprintf("- **%s**: %s. %s\n", tip.Task, tip.Message, tip.Suggestion))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Documentation improvements
 	if tips, exists := tipsByType["documentation"]; exists {
 		sb.WriteString("## 📝 Documentation Improvements\n\n")
@@ -352,9 +392,9 @@ func GenerateTaskMarkdown(taskAnalysis *TaskAnalysis) string {
 	if len(taskAnalysis.Dependencies) > 0 {
 		sb.WriteString("## 🔗 Dependencies\n\n")
 		sb.WriteString("This task depends on:\n\n")
-		for _, dep := range taskAnalysis.Dependencies {
+		for i, dep := range taskAnalysis.Dependencies {
 			normalizedName := NormalizeTaskName(dep)
-			sb.WriteString(fmt.Sprintf("- [%s](%s.md)\n", dep, normalizedName))
+			sb.WriteString(fmt.Sprintf("- [%s](%s.md)%s\n", dep, normalizedName, taskCallNote(taskAnalysis.Calls, i)))
 		}
 		sb.WriteString("\n")
 	}
@@ -390,6 +430,14 @@ func GenerateTaskMarkdown(taskAnalysis *TaskAnalysis) string {
 			sb.WriteString("```bash\n")
 			sb.WriteString(command)
 			sb.WriteString("\n```\n\n")
+
+			// Show the command as go-task runs it when templates were resolved
+			if i < len(taskAnalysis.ResolvedCommands) && taskAnalysis.ResolvedCommands[i] != command {
+				sb.WriteString("**Resolved:**\n")
+				sb.WriteString("```bash\n")
+				sb.WriteString(taskAnalysis.ResolvedCommands[i])
+				sb.WriteString("\n```\n\n")
+			}
 			
 			// Command analysis
 			classification := shared.ClassifyCommand(command)
@@ -423,6 +471,37 @@ func GenerateTaskMarkdown(taskAnalysis *TaskAnalysis) string {
 		}
 	}
 
+	// Template variables the commands read
+	if len(taskAnalysis.ResolvedVars) > 0 {
+		sb.WriteString("## 🧩 Template Variables\n\n")
+		sb.WriteString("| Variable | Value | Source |\n")
+		sb.WriteString("|----------|-------|--------|\n")
+		for _, variable := range taskAnalysis.ResolvedVars {
+			source := variable.Source
+			if variable.Symbolic {
+				source += " (run time)"
+			}
+			value := strings.ReplaceAll(shared.TruncateString(strings.Join(strings.Fields(variable.Value), " "), 80), "|", "\\|")
+			sb.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", variable.Name, value, source))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(taskAnalysis.CallSites) > 0 {
+		sb.WriteString("## 📞 Call Sites\n\n")
+		for _, site := range taskAnalysis.CallSites {
+			var vars []string
+			for name, value := range site.Vars {
+				vars = append(vars, fmt.Sprintf("%s=%v", name, value))
+			}
+			sort.Strings(vars)
+			sb.WriteString(fmt.Sprintf("Called from [%s](%s.md) with `%s`:\n\n", site.Caller, NormalizeTaskName(site.Caller), strings.Join(vars, " ")))
+			sb.WriteString("```bash\n")
+			sb.WriteString(strings.Join(site.Commands, "\n"))
+			sb.WriteString("\n```\n\n")
+		}
+	}
+
 	// Preconditions
 	if len(taskAnalysis.Preconditions) > 0 {
 		sb.WriteString("## ✅ Preconditions\n\n")
@@ -514,16 +593,17 @@ func GenerateDependencyGraph(analysis *Analysis) string {
 		sb.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", normalizedName, task))
 	}
 
-	// Add dependency edges
-	for task, deps := range graph.Edges {
+	// Add dependency edges; sequential calls are labeled and deferred calls dotted
+	for _, task := range graph.Tasks {
 		normalizedTask := shared.NormalizeFileName(task)
-		for _, dep := range deps {
-			normalizedDep := shared.NormalizeFileName(dep)
-			sb.WriteString(fmt.Sprintf("    %s --> %s\n", normalizedDep, normalizedTask))
+		for _, call := range graph.Calls[task] {
+			normalizedDep := shared.NormalizeFileName(call.Task)
+			sb.WriteString(fmt.Sprintf("    %s %s %s\n", normalizedDep, taskCallEdge(call).Arrow(), normalizedTask))
 		}
 	}
 
 	sb.WriteString("```\n\n")
+	sb.WriteString("Solid arrows are `deps`, arrows labeled `call` are `task:` calls in `cmds` and dotted arrows are `defer` calls.\n\n")
 
 	// Circular dependencies
 	if len(graph.Cycles) > 0 {
@@ -605,7 +685,8 @@ func GenerateTaskflowDiagram(analysis *Analysis) string {
 	
 	// Find main/common entry point tasks
 	mainTasks := []string{}
-	for taskName, task := range analysis.Taskfile.Tasks {
+	for _, taskName := range GetAllTaskNames(analysis.Taskfile) {
+		task := analysis.Taskfile.Tasks[taskName]
 		// Look for common entry points
 		if taskName == "default" || taskName == "build" || taskName == "test" || 
 		   taskName == "dev" || taskName == "start" || taskName == "deploy" {
@@ -635,30 +716,13 @@ func GenerateTaskflowDiagram(analysis *Analysis) string {
 			}
 			diagram.Nodes = append(diagram.Nodes, node)
 			
-			// Add dependency edges
-			for _, dep := range task.Deps {
-				// Handle both string and task object dependencies
-				var depName string
-				if taskDep, ok := dep.(map[string]interface{}); ok {
-					if task, exists := taskDep["task"]; exists {
-						depName = task.(string)
-					}
-				} else if str, ok := dep.(string); ok {
-					depName = str
-				}
-				
-				if depName != "" {
-					// Only add if dependency is in our main tasks
-					for _, mainTask := range mainTasks {
-						if mainTask == depName {
-							edge := shared.MermaidEdge{
-								From: shared.CleanNodeID(depName),
-								To:   shared.CleanNodeID(taskName),
-							}
-							diagram.Edges = append(diagram.Edges, edge)
-							break
-						}
-					}
+			// Add dependency and task call edges between main tasks
+			for _, call := range analysis.TaskCalls[taskName] {
+				if shared.ContainsString(mainTasks, call.Task) {
+					edge := taskCallEdge(call)
+					edge.From = shared.CleanNodeID(call.Task)
+					edge.To = shared.CleanNodeID(taskName)
+					diagram.Edges = append(diagram.Edges, edge)
 				}
 			}
 		}
@@ -670,4 +734,41 @@ func GenerateTaskflowDiagram(analysis *Analysis) string {
 	}
 	
 	return diagram.Generate()
-}
\ No newline at end of file
+}
+
+// taskCallEdge styles a Mermaid edge by task call kind
+func taskCallEdge(call TaskCall) shared.MermaidEdge {
+	edge := shared.MermaidEdge{}
+	switch call.Kind {
+	case TaskCallSequential:
+		edge.Label = "call"
+	case TaskCallDeferred:
+		edge.Label = "defer"
+		edge.Dotted = true
+	}
+	if call.Loop {
+		edge.Label = strings.TrimSpace(edge.Label + " for each")
+	}
+	return edge
+}
+
+// taskCallNote describes how a task is called, for dependency lists
+func taskCallNote(calls []TaskCall, i int) string {
+	if i >= len(calls) {
+		return ""
+	}
+	var note string
+	switch calls[i].Kind {
+	case TaskCallSequential:
+		note = "sequential call in cmds"
+	case TaskCallDeferred:
+		note = "deferred call, runs when the task exits"
+	}
+	if calls[i].Loop {
+		note = strings.TrimPrefix(note+", once per for: item", ", ")
+	}
+	if note == "" {
+		return ""
+	}
+	return " — " + note
+}
@@ -108,6 +108,53 @@ func parseTaskfileWithRecovery(data []byte, taskfilePath string) (*Taskfile, err
 	return taskfile, nil
 }
 
+// UnmarshalYAML decodes a task, moving the {exclude: glob} entries of
+// sources and generates into SourceExcludes and GenerateExcludes
+func (t *Task) UnmarshalYAML(node *yaml.Node) error {
+	type plainTask Task
+	if node.Kind != yaml.MappingNode {
+		return node.Decode((*plainTask)(t))
+	}
+
+	mapping := *node
+	mapping.Content = append([]*yaml.Node{}, node.Content...)
+	var sourceExcludes, generateExcludes []string
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		switch mapping.Content[i].Value {
+		case "sources":
+			mapping.Content[i+1], sourceExcludes = splitGlobExcludes(mapping.Content[i+1])
+		case "generates":
+			mapping.Content[i+1], generateExcludes = splitGlobExcludes(mapping.Content[i+1])
+		}
+	}
+
+	if err := mapping.Decode((*plainTask)(t)); err != nil {
+		return err
+	}
+	t.SourceExcludes = sourceExcludes
+	t.GenerateExcludes = generateExcludes
+	return nil
+}
+
+// splitGlobExcludes separates the {exclude: glob} entries of a glob list
+func splitGlobExcludes(list *yaml.Node) (*yaml.Node, []string) {
+	if list.Kind != yaml.SequenceNode {
+		return list, nil
+	}
+
+	globs := *list
+	globs.Content = nil
+	var excludes []string
+	for _, entry := range list.Content {
+		if entry.Kind == yaml.MappingNode && len(entry.Content) == 2 && entry.Content[0].Value == "exclude" {
+			excludes = append(excludes, entry.Content[1].Value)
+			continue
+		}
+		globs.Content = append(globs.Content, entry)
+	}
+	return &globs, excludes
+}
+
 // parseTaskFromMap safely parses a task from a map
 func parseTaskFromMap(taskMap map[string]interface{}) Task {
 	task := Task{}
@@ -173,6 +220,10 @@ func extractCommand(cmdInterface interface{}) string {
 		if taskName, ok := cmd["task"].(string); ok {
 			return "task " + taskName
 		}
+		// Handle deferred commands and task calls: defer: ...
+		if deferred, ok := cmd["defer"]; ok {
+			return extractCommand(deferred)
+		}
 	}
 	return ""
 }
@@ -180,38 +231,70 @@ func extractCommand(cmdInterface interface{}) string {
 // ExtractTaskDependencies extracts dependencies from a task
 func ExtractTaskDependencies(task Task) []string {
 	var dependencies []string
+	for _, call := range ExtractTaskCalls(task) {
+		dependencies = append(dependencies, call.Task)
+	}
+	return dependencies
+}
+
+// ExtractTaskCalls extracts the tasks a task runs: its deps, the task: calls
+// in its cmds and the deferred task: calls. A call in a for: loop over a
+// literal list is expanded when its task name uses {{.ITEM}}.
+func ExtractTaskCalls(task Task) []TaskCall {
+	var calls []TaskCall
 
-	// Extract formal dependencies from deps: field
 	for _, depInterface := range task.Deps {
-		dep := extractDependency(depInterface)
-		if dep != "" {
-			dependencies = append(dependencies, dep)
+		if dep, ok := depInterface.(string); ok {
+			if dep != "" {
+				calls = append(calls, TaskCall{Task: dep, Kind: TaskCallDep})
+			}
+			continue
 		}
+		calls = append(calls, extractTaskCall(depInterface, TaskCallDep)...)
 	}
 
-	// Extract task calls from commands (task: taskname in cmds)
 	for _, cmdInterface := range task.Cmds {
-		if cmdMap, ok := cmdInterface.(map[string]interface{}); ok {
-			if taskName, ok := cmdMap["task"].(string); ok {
-				dependencies = append(dependencies, taskName)
-			}
+		cmdMap, ok := cmdInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if deferred, ok := cmdMap["defer"].(map[string]interface{}); ok {
+			calls = append(calls, extractTaskCall(deferred, TaskCallDeferred)...)
+			continue
 		}
+		calls = append(calls, extractTaskCall(cmdMap, TaskCallSequential)...)
 	}
 
-	return dependencies
+	return calls
 }
 
-// extractDependency extracts dependency from interface (string or Dependency object)
-func extractDependency(depInterface interface{}) string {
-	switch dep := depInterface.(type) {
-	case string:
-		return dep
-	case map[string]interface{}:
-		if task, ok := dep["task"].(string); ok {
-			return task
-		}
+// extractTaskCall reads a task: call, expanding for: loops where the names are known
+func extractTaskCall(callInterface interface{}, kind string) []TaskCall {
+	call, ok := callInterface.(map[string]interface{})
+	if !ok {
+		return nil
 	}
-	return ""
+	name, ok := call["task"].(string)
+	if !ok || name == "" {
+		return nil
+	}
+	vars, _ := call["vars"].(map[string]interface{})
+
+	loop, isLoop := call["for"]
+	if !isLoop {
+		return []TaskCall{{Task: name, Kind: kind, Vars: vars}}
+	}
+	items, isList := loop.([]interface{})
+	if !isList || !strings.Contains(name, "{{.ITEM}}") {
+		return []TaskCall{{Task: name, Kind: kind, Vars: vars, Loop: true}}
+	}
+
+	var calls []TaskCall
+	for _, item := range items {
+		itemName := strings.ReplaceAll(name, "{{.ITEM}}", fmt.Sprint(item))
+		calls = append(calls, TaskCall{Task: itemName, Kind: kind, Vars: vars, Loop: true})
+	}
+	return calls
 }
 
 // ExtractPreconditions extracts preconditions from a task
@@ -351,6 +434,10 @@ func getVariableType(value interface{}) string {
 	case []interface{}:
 		return "array"
 	case map[string]interface{}:
+		// Dynamic variables are evaluated by a shell at run time
+		if _, ok := v["sh"]; ok {
+			return "shell"
+		}
 		return "object"
 	default:
 		return reflect.TypeOf(value).String()
@@ -402,6 +489,97 @@ func GetAllIncludeNames(taskfile *Taskfile) []string {
 	return includeNames
 }
 
+// maxIncludeDepth bounds nested includes when resolving task names
+const maxIncludeDepth = 10
+
+// CallableTaskNames returns the names the task CLI accepts for a Taskfile:
+// its tasks and their aliases, plus the tasks of included Taskfiles under
+// each namespace and namespace alias. Internal tasks and includes are left
+// out, and flattened includes add their tasks without a namespace.
+func CallableTaskNames(taskfilePath string) (map[string]bool, error) {
+	taskfile, err := ParseTaskfile(taskfilePath)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	addCallableTaskNames(names, taskfile, taskfilePath, []string{""}, nil, 0)
+	return names, nil
+}
+
+// addCallableTaskNames adds the task names of a Taskfile under every prefix
+func addCallableTaskNames(names map[string]bool, taskfile *Taskfile, taskfilePath string, prefixes, excludes []string, depth int) {
+	for name, task := range taskfile.Tasks {
+		if task.Internal || shared.ContainsString(excludes, name) {
+			continue
+		}
+		for _, prefix := range prefixes {
+			names[prefix+name] = true
+			for _, alias := range task.Aliases {
+				names[prefix+alias] = true
+			}
+		}
+	}
+	if depth >= maxIncludeDepth {
+		return
+	}
+
+	for namespace, includeRaw := range taskfile.Includes {
+		var include Include
+		switch value := includeRaw.(type) {
+		case string:
+			include.Taskfile = value
+		case map[string]interface{}:
+			include.Taskfile, _ = value["taskfile"].(string)
+			include.Flatten, _ = value["flatten"].(bool)
+			include.Internal, _ = value["internal"].(bool)
+			include.Aliases = interfaceStrings(value["aliases"])
+			include.Excludes = interfaceStrings(value["excludes"])
+		}
+		// Templated paths are only known when task runs
+		if include.Internal || include.Taskfile == "" || strings.Contains(include.Taskfile, "{{") {
+			continue
+		}
+
+		// The taskfile path is relative to the including Taskfile and may be a directory
+		includePath := include.Taskfile
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(taskfilePath), includePath)
+		}
+		if stat, err := os.Stat(includePath); err == nil && stat.IsDir() {
+			if includePath, err = FindTaskfile(includePath); err != nil {
+				continue
+			}
+		}
+		included, err := ParseTaskfile(includePath)
+		if err != nil {
+			continue
+		}
+
+		nested := prefixes
+		if !include.Flatten {
+			nested = nil
+			for _, prefix := range prefixes {
+				for _, ns := range append([]string{namespace}, include.Aliases...) {
+					nested = append(nested, prefix+ns+":")
+				}
+			}
+		}
+		addCallableTaskNames(names, included, includePath, nested, include.Excludes, depth+1)
+	}
+}
+
+// interfaceStrings converts a decoded YAML list to strings
+func interfaceStrings(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var result []string
+	for _, item := range items {
+		if text, ok := item.(string); ok {
+			result = append(result, text)
+		}
+	}
+	return result
+}
+
 // NormalizeTaskName removes special characters from task names for file naming
 func NormalizeTaskName(name string) string {
 	name = strings.ReplaceAll(name, "/", "-")
@@ -0,0 +1,566 @@
+package gotask
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"   // go-task rejects or misreads the Taskfile
+	SeverityWarning = "warning" // Accepted, but most likely a mistake
+)
+
+// Diagnostic is a Taskfile schema finding at a position in a file
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string
+	Rule     string // Such as unknown-key, type or required
+	Message  string
+}
+
+// String formats the diagnostic as file:line:column: severity: message [rule]
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// RelativeTo returns the diagnostic with its file relative to a directory
+func (d Diagnostic) RelativeTo(dir string) Diagnostic {
+	if relative, err := filepath.Rel(dir, d.File); err == nil {
+		d.File = filepath.ToSlash(relative)
+	}
+	return d
+}
+
+// yamlErrorLine finds the line number in a YAML syntax error
+var yamlErrorLine = regexp.MustCompile(`line (\d+): `)
+
+// schemaRule describes the values a Taskfile node accepts. A node may take
+// several forms, such as a task given as a command string or a mapping.
+type schemaRule struct {
+	scalar   bool                   // Accepts a string, number or bool
+	boolean  bool                   // The scalar must be true or false
+	enum     []string               // Allowed scalar values
+	items    *schemaRule            // Accepts a list of these
+	fields   map[string]*schemaRule // Accepts a mapping with these keys
+	values   *schemaRule            // Accepts a mapping with any keys, of these values
+	required []string               // Keys a mapping must have
+	any      bool                   // Accepts anything
+	check    func(v *schemaValidator, node *yaml.Node, where string)
+}
+
+// taskfileSchema is the Taskfile v3 schema
+var taskfileSchema = buildTaskfileSchema()
+
+// buildTaskfileSchema builds the rules of the Taskfile v3 schema
+func buildTaskfileSchema() *schemaRule {
+	anyValue := &schemaRule{any: true}
+	str := &schemaRule{scalar: true}
+	boolean := &schemaRule{scalar: true, boolean: true}
+	list := &schemaRule{items: str}
+	enum := func(values ...string) *schemaRule { return &schemaRule{scalar: true, enum: values} }
+
+	variable := &schemaRule{
+		scalar: true,
+		items:  anyValue,
+		values: anyValue,
+		check:  checkDynamicVar,
+	}
+	vars := &schemaRule{values: variable}
+	forLoop := &schemaRule{
+		scalar: true,
+		items:  anyValue,
+		fields: map[string]*schemaRule{"var": str, "split": str, "as": str, "matrix": anyValue},
+	}
+	setOptions := &schemaRule{items: enum("allexport", "a", "errexit", "e", "noexec", "n", "noglob", "f", "nounset", "u", "xtrace", "x", "pipefail")}
+	shoptOptions := &schemaRule{items: enum("expand_aliases", "globstar", "nullglob")}
+	glob := &schemaRule{scalar: true, fields: map[string]*schemaRule{"exclude": str}, required: []string{"exclude"}}
+
+	call := &schemaRule{
+		scalar:   true,
+		fields:   map[string]*schemaRule{"task": str, "vars": vars, "silent": boolean, "for": forLoop},
+		required: []string{"task"},
+	}
+	command := &schemaRule{
+		scalar: true,
+		fields: map[string]*schemaRule{
+			"cmd":          str,
+			"task":         str,
+			"vars":         vars,
+			"silent":       boolean,
+			"ignore_error": boolean,
+			"platforms":    list,
+			"set":          setOptions,
+			"shopt":        shoptOptions,
+			"defer":        call,
+			"for":          forLoop,
+		},
+		check: checkCommand,
+	}
+
+	task := &schemaRule{
+		scalar: true,
+		items:  command,
+		fields: map[string]*schemaRule{
+			"cmds":          {items: command},
+			"cmd":           str,
+			"deps":          {items: call},
+			"label":         str,
+			"desc":          str,
+			"prompt":        {scalar: true, items: str},
+			"summary":       str,
+			"aliases":       list,
+			"sources":       {items: glob},
+			"generates":     {items: glob},
+			"status":        list,
+			"preconditions": {items: &schemaRule{scalar: true, fields: map[string]*schemaRule{"sh": str, "msg": str}, required: []string{"sh"}}},
+			"requires":      {fields: map[string]*schemaRule{"vars": {items: &schemaRule{scalar: true, fields: map[string]*schemaRule{"name": str, "enum": list}, required: []string{"name"}}}}},
+			"dir":           str,
+			"set":           setOptions,
+			"shopt":         shoptOptions,
+			"vars":          vars,
+			"env":           vars,
+			"dotenv":        list,
+			"silent":        boolean,
+			"interactive":   boolean,
+			"internal":      boolean,
+			"method":        enum("checksum", "timestamp", "none"),
+			"prefix":        str,
+			"ignore_error":  boolean,
+			"run":           enum("always", "once", "when_changed"),
+			"platforms":     list,
+			"watch":         boolean,
+			"failfast":      boolean,
+		},
+		check: checkTask,
+	}
+
+	include := &schemaRule{
+		scalar: true,
+		fields: map[string]*schemaRule{
+			"taskfile": str,
+			"dir":      str,
+			"optional": boolean,
+			"flatten":  boolean,
+			"internal": boolean,
+			"aliases":  list,
+			"excludes": list,
+			"vars":     vars,
+			"checksum": str,
+		},
+		required: []string{"taskfile"},
+	}
+
+	return &schemaRule{
+		fields: map[string]*schemaRule{
+			"version": str,
+			"output": {
+				scalar: true,
+				enum:   []string{"interleaved", "group", "prefixed"},
+				fields: map[string]*schemaRule{"group": {fields: map[string]*schemaRule{"begin": str, "end": str, "error_only": boolean}}},
+			},
+			"method":   enum("checksum", "timestamp", "none"),
+			"includes": {values: include},
+			"vars":     vars,
+			"env":      vars,
+			"tasks":    {values: task},
+			"silent":   boolean,
+			"dotenv":   list,
+			"run":      enum("always", "once", "when_changed"),
+			"interval": str,
+			"set":      setOptions,
+			"shopt":    shoptOptions,
+		},
+		required: []string{"version"},
+		check:    checkVersion,
+	}
+}
+
+// schemaValidator collects the diagnostics of one file
+type schemaValidator struct {
+	file        string
+	diagnostics []Diagnostic
+}
+
+// ValidateTaskfile checks a Taskfile against the Taskfile v3 schema
+func ValidateTaskfile(taskfilePath string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(taskfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read taskfile: %w", err)
+	}
+	return ValidateTaskfileData(data, taskfilePath), nil
+}
+
+// ValidateTaskfileData checks the contents of a Taskfile against the
+// Taskfile v3 schema, reporting positions in the given file
+func ValidateTaskfileData(data []byte, file string) []Diagnostic {
+	v := &schemaValidator{file: file}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		line := 1
+		if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		message := yamlErrorLine.ReplaceAllString(strings.TrimPrefix(err.Error(), "yaml: "), "")
+		v.diagnostics = append(v.diagnostics, Diagnostic{File: file, Line: line, Column: 1, Severity: SeverityError, Rule: "yaml", Message: message})
+		return v.diagnostics
+	}
+	if len(document.Content) == 0 {
+		v.diagnostics = append(v.diagnostics, Diagnostic{File: file, Line: 1, Column: 1, Severity: SeverityError, Rule: "required", Message: "Taskfile is empty"})
+		return v.diagnostics
+	}
+
+	v.validate(taskfileSchema, document.Content[0], "")
+	sortDiagnostics(v.diagnostics)
+	return v.diagnostics
+}
+
+// ValidateProject checks the Taskfiles of a project that could be loaded,
+// and reports the includes that could not be at their includes: entry
+func ValidateProject(project *Project) []Diagnostic {
+	var diagnostics []Diagnostic
+	validated := make(map[string]bool)
+	files := []string{project.Root}
+	for _, include := range project.Includes {
+		if include.Taskfile != "" && include.Error == "" {
+			files = append(files, include.Taskfile)
+		}
+	}
+	for _, file := range files {
+		if validated[absPath(file)] {
+			continue
+		}
+		validated[absPath(file)] = true
+		fileDiagnostics, err := ValidateTaskfile(file)
+		if err != nil {
+			shared.GetLogger().Warn("GoTask", "Skipping schema validation", map[string]interface{}{
+				"file":  file,
+				"error": err.Error(),
+			})
+			continue
+		}
+		diagnostics = append(diagnostics, fileDiagnostics...)
+	}
+
+	// Includes that could not be loaded, at their entry in the including Taskfile
+	includeFiles := map[string]string{"": project.Root}
+	for _, include := range project.Includes {
+		includeFiles[include.Key] = include.Taskfile
+	}
+	for _, include := range project.Includes {
+		// An include without a taskfile is reported by the schema check
+		if include.Error == "" || include.Path == "" {
+			continue
+		}
+		file := includeFiles[include.Parent]
+		name := strings.TrimPrefix(include.Key, include.Parent+":")
+		line, column := keyPosition(file, "includes", name)
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     file,
+			Line:     line,
+			Column:   column,
+			Severity: SeverityError,
+			Rule:     "include",
+			Message:  fmt.Sprintf("include %s could not be loaded: %s", include.Key, include.Error),
+		})
+	}
+
+	// Diagnostics of a file stay together, in the order the files were loaded
+	order := make(map[string]int)
+	for i, file := range files {
+		if _, exists := order[file]; !exists {
+			order[file] = i
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return order[diagnostics[i].File] < order[diagnostics[j].File]
+	})
+	for start := 0; start < len(diagnostics); {
+		end := start
+		for end < len(diagnostics) && diagnostics[end].File == diagnostics[start].File {
+			end++
+		}
+		sortDiagnostics(diagnostics[start:end])
+		start = end
+	}
+	return diagnostics
+}
+
+// LintProject validates a Taskfile and the Taskfiles it includes. A Taskfile
+// that does not parse is reported on its own.
+func LintProject(taskfilePath string) ([]Diagnostic, error) {
+	diagnostics, err := ValidateTaskfile(taskfilePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Rule == "yaml" {
+			return diagnostics, nil
+		}
+	}
+	taskfile, err := ParseTaskfile(taskfilePath)
+	if err != nil {
+		return diagnostics, nil
+	}
+	return ValidateProject(LoadProject(taskfile, taskfilePath)), nil
+}
+
+// CountDiagnostics counts the errors and warnings among diagnostics
+func CountDiagnostics(diagnostics []Diagnostic) (int, int) {
+	errors, warnings := 0, 0
+	for _, diagnostic := range diagnostics {
+		switch diagnostic.Severity {
+		case SeverityError:
+			errors++
+		case SeverityWarning:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// validate checks a node against a rule; where is the node's path, such as tasks.build.deps[0]
+func (v *schemaValidator) validate(rule *schemaRule, node *yaml.Node, where string) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if rule.any || node.Tag == "!!null" {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !rule.scalar {
+			v.typeError(rule, node, where)
+			return
+		}
+		if rule.boolean && node.Tag != "!!bool" {
+			v.add(node, SeverityError, "type", "%s must be true or false, not %q", where, node.Value)
+		}
+		if len(rule.enum) > 0 && !shared.ContainsString(rule.enum, node.Value) && !strings.Contains(node.Value, "{{") {
+			v.add(node, SeverityError, "enum", "%s must be one of %s, not %q%s", where, strings.Join(rule.enum, ", "), node.Value, didYouMean(node.Value, rule.enum))
+		}
+	case yaml.SequenceNode:
+		if rule.items == nil {
+			v.typeError(rule, node, where)
+			return
+		}
+		for i, item := range node.Content {
+			v.validate(rule.items, item, fmt.Sprintf("%s[%d]", where, i))
+		}
+	case yaml.MappingNode:
+		if rule.fields == nil && rule.values == nil {
+			v.typeError(rule, node, where)
+			return
+		}
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			path := joinSchemaPath(where, key.Value)
+			if seen[key.Value] {
+				v.add(key, SeverityError, "duplicate-key", "%s is defined more than once", path)
+			}
+			seen[key.Value] = true
+
+			if rule.values != nil {
+				v.validate(rule.values, value, path)
+				continue
+			}
+			fieldRule, known := rule.fields[key.Value]
+			if !known {
+				v.add(key, SeverityWarning, "unknown-key", "unknown key %q in %s%s", key.Value, schemaPlace(where), didYouMean(key.Value, sortedVarNames(rule.fields)))
+				continue
+			}
+			v.validate(fieldRule, value, path)
+		}
+		for _, required := range rule.required {
+			if !seen[required] {
+				v.add(node, SeverityError, "required", "%s is missing the required key %q", schemaPlace(where), required)
+			}
+		}
+	}
+
+	if rule.check != nil {
+		rule.check(v, node, where)
+	}
+}
+
+// typeError reports a node of a form the rule does not accept
+func (v *schemaValidator) typeError(rule *schemaRule, node *yaml.Node, where string) {
+	var forms []string
+	if rule.scalar {
+		forms = append(forms, "a string")
+	}
+	if rule.items != nil {
+		forms = append(forms, "a list")
+	}
+	if rule.fields != nil || rule.values != nil {
+		forms = append(forms, "a mapping")
+	}
+	given := map[yaml.Kind]string{yaml.ScalarNode: "a string", yaml.SequenceNode: "a list", yaml.MappingNode: "a mapping"}[node.Kind]
+	v.add(node, SeverityError, "type", "%s must be %s, not %s", schemaPlace(where), strings.Join(forms, " or "), given)
+}
+
+// add records a diagnostic at a node's position
+func (v *schemaValidator) add(node *yaml.Node, severity, rule, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		File:     v.file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkVersion warns about Taskfiles that are not version 3
+func checkVersion(v *schemaValidator, node *yaml.Node, where string) {
+	version := mappingValue(node, "version")
+	if version != nil && version.Kind == yaml.ScalarNode && version.Value != "3" && !strings.HasPrefix(version.Value, "3.") {
+		v.add(version, SeverityWarning, "version", "version %q is not Taskfile v3; only the v3 schema is checked", version.Value)
+	}
+}
+
+// checkTask reports keys that conflict with each other
+func checkTask(v *schemaValidator, node *yaml.Node, where string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	if cmd := mappingKey(node, "cmd"); cmd != nil && mappingKey(node, "cmds") != nil {
+		v.add(cmd, SeverityWarning, "conflict", "%s sets both cmd and cmds; use one of them", where)
+	}
+}
+
+// checkCommand reports cmds entries that neither run a command nor call a task
+func checkCommand(v *schemaValidator, node *yaml.Node, where string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	var actions []string
+	for _, key := range []string{"cmd", "task", "defer"} {
+		if mappingKey(node, key) != nil {
+			actions = append(actions, key)
+		}
+	}
+	switch {
+	case len(actions) == 0:
+		v.add(node, SeverityError, "required", "%s needs one of cmd, task or defer", where)
+	case len(actions) > 1:
+		v.add(node, SeverityError, "conflict", "%s sets %s; use only one", where, strings.Join(actions, " and "))
+	}
+}
+
+// checkDynamicVar reports variable mappings that are not sh, ref or map variables
+func checkDynamicVar(v *schemaValidator, node *yaml.Node, where string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	if mappingKey(node, "sh") == nil && mappingKey(node, "ref") == nil && mappingKey(node, "map") == nil {
+		v.add(node, SeverityError, "type", "%s must set sh, ref or map; use map: for a mapping value", where)
+		return
+	}
+	for _, key := range []string{"sh", "ref", "dir"} {
+		if value := mappingValue(node, key); value != nil && value.Kind != yaml.ScalarNode {
+			v.add(value, SeverityError, "type", "%s.%s must be a string", where, key)
+		}
+	}
+}
+
+// mappingKey returns the key node of a mapping entry
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node of a mapping entry
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// keyPosition finds the line and column of a nested key in a YAML file, or
+// the start of the file
+func keyPosition(file string, keys ...string) (int, int) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 1, 1
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document.Content) == 0 {
+		return 1, 1
+	}
+
+	node, line, column := document.Content[0], 1, 1
+	for _, key := range keys {
+		keyNode := mappingKey(node, key)
+		if keyNode == nil {
+			break
+		}
+		line, column = keyNode.Line, keyNode.Column
+		node = mappingValue(node, key)
+	}
+	return line, column
+}
+
+// didYouMean suggests the closest known name to a misspelled one
+func didYouMean(name string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		distance := shared.CalculateLevenshteinDistance(strings.ToLower(name), candidate)
+		if distance < bestDistance && distance < len(name) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// joinSchemaPath appends a key to a node path
+func joinSchemaPath(where, key string) string {
+	if where == "" {
+		return key
+	}
+	return where + "." + key
+}
+
+// schemaPlace names a node path in messages
+func schemaPlace(where string) string {
+	if where == "" {
+		return "the Taskfile"
+	}
+	return where
+}
+
+// sortDiagnostics orders diagnostics by position
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+}
@@ -0,0 +1,531 @@
+package gotask
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// Variable sources, in go-task precedence order from lowest to highest
+const (
+	VarSourceSpecial  = "special"  // Set by go-task, such as .TASK or .ROOT_DIR
+	VarSourceCLI      = "cli"      // Given on the command line, such as .CLI_ARGS
+	VarSourceEnv      = "env"      // Not declared, so read from the environment
+	VarSourceGlobal   = "global"   // vars: of the root Taskfile
+	VarSourceInclude  = "include"  // vars: of the includes: entry
+	VarSourceIncluded = "included" // vars: of the included Taskfile
+	VarSourceCall     = "call"     // vars: given by the calling task
+	VarSourceTask     = "task"     // vars: of the task itself
+)
+
+// Special variables go-task only knows at run time
+var runtimeSpecialVars = map[string]string{
+	"ROOT_TASKFILE":    VarSourceSpecial,
+	"ROOT_DIR":         VarSourceSpecial,
+	"TASKFILE":         VarSourceSpecial,
+	"TASKFILE_DIR":     VarSourceSpecial,
+	"TASK_DIR":         VarSourceSpecial,
+	"TASK_EXE":         VarSourceSpecial,
+	"TASK_VERSION":     VarSourceSpecial,
+	"USER_WORKING_DIR": VarSourceSpecial,
+	"CHECKSUM":         VarSourceSpecial,
+	"TIMESTAMP":        VarSourceSpecial,
+	"ITEM":             VarSourceSpecial,
+	"EXIT_CODE":        VarSourceSpecial,
+	"ALIAS":            VarSourceSpecial,
+	"MATCH":            VarSourceSpecial,
+	"CLI_ARGS":         VarSourceCLI,
+	"CLI_ARGS_LIST":    VarSourceCLI,
+	"CLI_FORCE":        VarSourceCLI,
+	"CLI_SILENT":       VarSourceCLI,
+	"CLI_VERBOSE":      VarSourceCLI,
+	"CLI_OFFLINE":      VarSourceCLI,
+	"CLI_ASSUME_YES":   VarSourceCLI,
+}
+
+// templateRefPattern matches the variables a template action reads, such as .VERSION
+var templateRefPattern = regexp.MustCompile(`(?:^|[^\w.)\]])\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ResolvedVar is a variable as the templates of a task see it
+type ResolvedVar struct {
+	Name     string
+	Value    string
+	Source   string
+	Symbolic bool // Only known at run time: sh: vars, CLI, environment and most special vars
+}
+
+// ResolvedTask is a task's commands with its static variables substituted
+type ResolvedTask struct {
+	Commands []string      // Parallel to ExtractTaskCommands; symbolic parts are kept as written
+	Vars     []ResolvedVar // Variables the commands reference, sorted by name
+}
+
+// CallSite is a call of a task with vars: from another task's deps or cmds
+type CallSite struct {
+	Caller   string
+	Vars     map[string]interface{}
+	Commands []string // The task's commands resolved with the call's vars
+}
+
+// VarResolver evaluates go-task variables for the tasks of one Taskfile
+type VarResolver struct {
+	tasks     map[string]Task
+	namespace string
+	scope     *varScope
+}
+
+// NewVarResolver evaluates the global vars of a Taskfile for its tasks
+func NewVarResolver(taskfile *Taskfile) *VarResolver {
+	scope := newVarScope()
+	scope.apply(VarSourceGlobal, taskfile.Vars)
+	return &VarResolver{tasks: taskfile.Tasks, scope: scope}
+}
+
+// Included returns a resolver for the tasks of an included Taskfile, whose
+// full namespace is given. They see the including Taskfile's variables, then
+// the include's vars, then the included Taskfile's own globals.
+func (r *VarResolver) Included(namespace string, includeVars map[string]interface{}, included *Taskfile) *VarResolver {
+	scope := r.scope.clone()
+	scope.apply(VarSourceInclude, includeVars)
+	scope.apply(VarSourceIncluded, included.Vars)
+	return &VarResolver{tasks: included.Tasks, namespace: namespace, scope: scope}
+}
+
+// Render evaluates the template actions of text with the resolver's global
+// variables, such as those of an include path. It reports whether nothing
+// was left for run time.
+func (r *VarResolver) Render(text string) (string, bool) {
+	rendered, symbolic := r.scope.render(text)
+	return rendered, len(symbolic) == 0 && !strings.Contains(rendered, "{{")
+}
+
+// ResolveTask renders a task's commands. Call vars are applied before the
+// task's own vars, which take precedence in go-task; tasks read call vars
+// through defaults such as {{.VERSION | default "dev"}}.
+func (r *VarResolver) ResolveTask(name string, callVars map[string]interface{}) *ResolvedTask {
+	task, exists := r.tasks[name]
+	if !exists {
+		return nil
+	}
+	scope := r.taskScope(name, task, callVars)
+
+	resolved := &ResolvedTask{}
+	referenced := make(map[string]bool)
+	for _, command := range ExtractTaskCommands(task) {
+		rendered, _ := scope.render(command)
+		resolved.Commands = append(resolved.Commands, rendered)
+		for _, ref := range templateRefs(command) {
+			referenced[ref] = true
+		}
+	}
+
+	for _, ref := range sortedVarNames(referenced) {
+		variable, declared := scope.vars[ref]
+		if !declared {
+			variable = ResolvedVar{Name: ref, Value: "read from the environment", Source: VarSourceEnv, Symbolic: true}
+		}
+		resolved.Vars = append(resolved.Vars, variable)
+	}
+	return resolved
+}
+
+// RenderTask evaluates text, such as a sources glob, with the variables a
+// task sees. It reports whether nothing was left for run time.
+func (r *VarResolver) RenderTask(name, text string) (string, bool) {
+	task, exists := r.tasks[name]
+	if !exists {
+		return r.Render(text)
+	}
+	rendered, symbolic := r.taskScope(name, task, nil).render(text)
+	return rendered, len(symbolic) == 0 && !strings.Contains(rendered, "{{")
+}
+
+// taskScope returns the variables visible to a task's templates
+func (r *VarResolver) taskScope(name string, task Task, callVars map[string]interface{}) *varScope {
+	taskName := name
+	if r.namespace != "" {
+		taskName = r.namespace + ":" + name
+	}
+	scope := r.scope.clone()
+	scope.define(ResolvedVar{Name: "TASK", Value: taskName, Source: VarSourceSpecial}, taskName)
+	scope.apply(VarSourceCall, callVars)
+	scope.apply(VarSourceTask, task.Vars)
+	return scope
+}
+
+// CallSites lists the calls of a task that pass vars, with the commands
+// they resolve to when those differ from a plain call
+func (r *VarResolver) CallSites(name string) []CallSite {
+	plain := r.ResolveTask(name, nil)
+	if plain == nil {
+		return nil
+	}
+
+	var sites []CallSite
+	for _, caller := range sortedVarNames(r.tasks) {
+		task := r.tasks[caller]
+		for _, entry := range append(append([]interface{}{}, task.Deps...), task.Cmds...) {
+			call, ok := entry.(map[string]interface{})
+			if !ok || call["task"] != name {
+				continue
+			}
+			vars, ok := call["vars"].(map[string]interface{})
+			if !ok || len(vars) == 0 {
+				continue
+			}
+			resolved := r.ResolveTask(name, vars)
+			if !reflect.DeepEqual(resolved.Commands, plain.Commands) {
+				sites = append(sites, CallSite{Caller: caller, Vars: vars, Commands: resolved.Commands})
+			}
+		}
+	}
+	return sites
+}
+
+// varScope is the set of variables visible at one point of resolution
+type varScope struct {
+	vars   map[string]ResolvedVar
+	values map[string]interface{} // Template data: the static variables only
+}
+
+// newVarScope returns a scope holding the run-time special variables
+func newVarScope() *varScope {
+	scope := &varScope{vars: make(map[string]ResolvedVar), values: make(map[string]interface{})}
+	for name, source := range runtimeSpecialVars {
+		scope.vars[name] = ResolvedVar{Name: name, Value: "set at run time", Source: source, Symbolic: true}
+	}
+	return scope
+}
+
+// clone copies the scope so a task's vars do not leak into other tasks
+func (s *varScope) clone() *varScope {
+	clone := &varScope{vars: make(map[string]ResolvedVar, len(s.vars)), values: make(map[string]interface{}, len(s.values))}
+	for name, variable := range s.vars {
+		clone.vars[name] = variable
+	}
+	for name, value := range s.values {
+		clone.values[name] = value
+	}
+	return clone
+}
+
+// define sets a variable, shadowing any earlier one of the same name
+func (s *varScope) define(variable ResolvedVar, value interface{}) {
+	s.vars[variable.Name] = variable
+	if variable.Symbolic {
+		delete(s.values, variable.Name)
+	} else {
+		s.values[variable.Name] = value
+	}
+}
+
+// apply evaluates one layer of declared vars on top of the scope. go-task
+// evaluates vars in file order; the parsed map has no order, so a var that
+// reads another var of the same layer is evaluated after it.
+func (s *varScope) apply(source string, declared map[string]interface{}) {
+	pending := sortedVarNames(declared)
+	for len(pending) > 0 {
+		var waiting []string
+		for _, name := range pending {
+			if readsPendingVar(declared[name], name, pending) {
+				waiting = append(waiting, name)
+				continue
+			}
+			s.set(name, source, declared[name])
+		}
+		if len(waiting) == len(pending) {
+			// A cycle: evaluate the rest against what is known
+			for _, name := range waiting {
+				s.set(name, source, declared[name])
+			}
+			return
+		}
+		pending = waiting
+	}
+}
+
+// set evaluates a declared var. sh: vars are symbolic, ref: vars copy
+// another var and map: vars are static values.
+func (s *varScope) set(name, source string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		rendered, symbolic := s.render(v)
+		s.define(ResolvedVar{Name: name, Value: rendered, Source: source, Symbolic: len(symbolic) > 0}, rendered)
+	case map[string]interface{}:
+		if sh, ok := v["sh"]; ok {
+			s.define(ResolvedVar{Name: name, Value: fmt.Sprintf("sh: %v", sh), Source: source, Symbolic: true}, nil)
+			return
+		}
+		if ref, ok := v["ref"].(string); ok {
+			target := strings.TrimPrefix(strings.TrimSpace(ref), ".")
+			variable, declared := s.vars[target]
+			if !declared {
+				variable = ResolvedVar{Value: "ref: " + ref, Symbolic: true}
+			}
+			variable.Name, variable.Source = name, source
+			s.define(variable, s.values[target])
+			return
+		}
+		if static, ok := v["map"]; ok {
+			s.define(ResolvedVar{Name: name, Value: fmt.Sprint(static), Source: source}, static)
+			return
+		}
+		s.define(ResolvedVar{Name: name, Value: fmt.Sprint(v), Source: source}, v)
+	case nil:
+		s.define(ResolvedVar{Name: name, Source: source}, "")
+	default:
+		s.define(ResolvedVar{Name: name, Value: fmt.Sprint(v), Source: source}, v)
+	}
+}
+
+// render evaluates the template actions of text that only read static
+// variables and deterministic functions. Other actions are kept as written
+// and the variables they read are returned.
+func (s *varScope) render(text string) (string, []string) {
+	var sb strings.Builder
+	var symbolic []string
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(text[start:], "}}")
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		sb.WriteString(text[:start])
+		action := text[start:end]
+		if value, ok := s.evaluate(action); ok {
+			sb.WriteString(value)
+		} else {
+			sb.WriteString(action)
+			for _, ref := range templateRefs(action) {
+				if !shared.ContainsString(symbolic, ref) {
+					symbolic = append(symbolic, ref)
+				}
+			}
+		}
+		text = text[end:]
+	}
+	sb.WriteString(text)
+	return sb.String(), symbolic
+}
+
+// evaluate executes a single template action. It fails for control actions
+// such as {{if}}, for unknown or run-time functions such as OS and for
+// variables that are symbolic. Undeclared variables come from the
+// environment: an action that gives them a default, such as
+// {{.GOOS | default "linux"}}, renders as if they were unset.
+func (s *varScope) evaluate(action string) (string, bool) {
+	tmpl, err := template.New("").Funcs(templateFuncs).Option("missingkey=error").Parse(action)
+	if err != nil {
+		return "", false
+	}
+	if value, ok := executeTemplate(tmpl, s.values); ok {
+		return value, true
+	}
+
+	data := make(map[string]interface{}, len(s.values))
+	for name, value := range s.values {
+		data[name] = value
+	}
+	undeclared := false
+	for _, ref := range templateRefs(action) {
+		if _, declared := s.vars[ref]; !declared {
+			data[ref] = ""
+			undeclared = true
+		}
+	}
+	if !undeclared {
+		return "", false
+	}
+	value, ok := executeTemplate(tmpl, data)
+	return value, ok && value != ""
+}
+
+// executeTemplate renders a parsed template, reporting whether it succeeded
+func executeTemplate(tmpl *template.Template, data map[string]interface{}) (string, bool) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+// readsPendingVar reports whether a var's template reads another var that
+// is not evaluated yet
+func readsPendingVar(value interface{}, name string, pending []string) bool {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case map[string]interface{}:
+		if ref, ok := v["ref"].(string); ok {
+			text = "{{" + ref + "}}"
+		}
+	}
+	for _, ref := range templateRefs(text) {
+		if ref != name && shared.ContainsString(pending, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateRefs returns the variables the template actions of text read
+func templateRefs(text string) []string {
+	var refs []string
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			return refs
+		}
+		end := strings.Index(text[start:], "}}")
+		if end < 0 {
+			return refs
+		}
+		for _, match := range templateRefPattern.FindAllStringSubmatch(text[start+2:start+end], -1) {
+			if !shared.ContainsString(refs, match[1]) {
+				refs = append(refs, match[1])
+			}
+		}
+		text = text[start+end+2:]
+	}
+}
+
+// sortedVarNames returns the keys of a map in sorted order
+func sortedVarNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateFuncs are the deterministic template functions of go-task and
+// slim-sprig. Functions whose result depends on the machine or the time,
+// such as OS, ARCH, now or env, are left out so their actions stay symbolic.
+var templateFuncs = template.FuncMap{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      titleCase,
+	"trim":       strings.TrimSpace,
+	"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"quote":      func(v interface{}) string { return strconv.Quote(fmt.Sprint(v)) },
+	"squote":     func(v interface{}) string { return "'" + fmt.Sprint(v) + "'" },
+	"toString":   func(v interface{}) string { return fmt.Sprint(v) },
+	"default":    defaultValue,
+	"empty":      isEmptyValue,
+	"coalesce":   coalesce,
+	"ternary": func(whenTrue, whenFalse interface{}, condition bool) interface{} {
+		if condition {
+			return whenTrue
+		}
+		return whenFalse
+	},
+	"list":       func(items ...interface{}) []interface{} { return items },
+	"join":       joinList,
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+	"splitLines": func(s string) []string { return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") },
+	"catLines":   func(s string) string { return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", " "), "\n", " ") },
+	"cat":        catValues,
+	"toSlash":    func(s string) string { return strings.ReplaceAll(s, "\\", "/") },
+	"joinPath":   func(elems ...string) string { return path.Join(elems...) },
+	"base":       path.Base,
+	"dir":        path.Dir,
+	"ext":        path.Ext,
+	"clean":      path.Clean,
+	"shellQuote": shellQuote,
+	"q":          shellQuote,
+}
+
+// defaultValue returns given unless it is empty, as in {{.VAR | default "x"}}
+func defaultValue(fallback interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmptyValue(given[0]) {
+		return fallback
+	}
+	return given[0]
+}
+
+// coalesce returns the first value that is not empty
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !isEmptyValue(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// isEmptyValue reports whether a value is nil, zero or has no elements
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// joinList joins the items of a list with a separator
+func joinList(sep string, list interface{}) string {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Sprint(list)
+	}
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(items, sep)
+}
+
+// catValues joins the non-nil values with spaces
+func catValues(values ...interface{}) string {
+	var items []string
+	for _, value := range values {
+		if value != nil {
+			items = append(items, fmt.Sprint(value))
+		}
+	}
+	return strings.Join(items, " ")
+}
+
+// titleCase upper-cases the first letter of each word
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(v interface{}) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", `'\''`) + "'"
+}
@@ -34,26 +34,29 @@ type Include struct {
 
 // Task represents a single task definition
 type Task struct {
-	Cmds          []interface{}             `yaml:"cmds"`
-	Cmd           string                    `yaml:"cmd"`
-	Deps          []interface{}             `yaml:"deps"`
-	Desc          string                    `yaml:"desc"`
-	Summary       string                    `yaml:"summary"`
-	Prompt        string                    `yaml:"prompt"`
-	Aliases       []string                  `yaml:"aliases"`
-	Sources       []string                  `yaml:"sources"`
-	Generates     []string                  `yaml:"generates"`
-	Status        []string                  `yaml:"status"`
-	Preconditions []interface{}             `yaml:"preconditions"`
-	Requires      map[string]string         `yaml:"requires"`
-	Watch         bool                      `yaml:"watch"`
-	Platforms     []string                  `yaml:"platforms"`
-	Silent        bool                      `yaml:"silent"`
-	Internal      bool                      `yaml:"internal"`
-	Vars          map[string]interface{}    `yaml:"vars"`
-	Env           map[string]interface{}    `yaml:"env"`
-	Run           string                    `yaml:"run"`
-	IgnoreError   bool                      `yaml:"ignore_error"`
+	Cmds             []interface{}             `yaml:"cmds"`
+	Cmd              string                    `yaml:"cmd"`
+	Deps             []interface{}             `yaml:"deps"`
+	Desc             string                    `yaml:"desc"`
+	Dir              string                    `yaml:"dir"`
+	Summary          string                    `yaml:"summary"`
+	Prompt           string                    `yaml:"prompt"`
+	Aliases          []string                  `yaml:"aliases"`
+	Sources          []string                  `yaml:"sources"`
+	Generates        []string                  `yaml:"generates"`
+	SourceExcludes   []string                  `yaml:"-"` // exclude: entries of sources
+	GenerateExcludes []string                  `yaml:"-"` // exclude: entries of generates
+	Status           []string                  `yaml:"status"`
+	Preconditions    []interface{}             `yaml:"preconditions"`
+	Requires         map[string]string         `yaml:"requires"`
+	Watch            bool                      `yaml:"watch"`
+	Platforms        []string                  `yaml:"platforms"`
+	Silent           bool                      `yaml:"silent"`
+	Internal         bool                      `yaml:"internal"`
+	Vars             map[string]interface{}    `yaml:"vars"`
+	Env              map[string]interface{}    `yaml:"env"`
+	Run              string                    `yaml:"run"`
+	IgnoreError      bool                      `yaml:"ignore_error"`
 }
 
 // Command represents a command that can be either string or object
@@ -86,6 +89,7 @@ type Analysis struct {
 	Taskfile           *Taskfile
 	TaskUsage          map[string]int
 	TaskDependencies   map[string][]string
+	TaskCalls          map[string][]TaskCall // Typed edges behind TaskDependencies
 	CommandPatterns    map[string]PatternCount
 	VariableUsage      map[string][]string
 	EnvironmentUsage   map[string][]string
@@ -95,7 +99,10 @@ type Analysis struct {
 	CircularDeps       [][]string
 	CriticalPath       []string
 	OptimizationTips   []OptimizationTip
+	FileChecks         []OptimizationTip // sources and generates findings, once the tree is known
+	Diagnostics        []Diagnostic      // Taskfile v3 schema findings of the project's Taskfiles
 	GeneratedAt        time.Time
+	Project            *Project // Set once includes are loaded
 }
 
 // PatternCount tracks pattern usage across tasks
@@ -109,36 +116,64 @@ type IncludeAnalysis struct {
 	Path         string
 	Namespace    string
 	TaskCount    int
-	Dependencies []string
+	Dependencies []string                 // Tasks of other Taskfiles its tasks depend on
 	Tasks        map[string]*TaskAnalysis // Individual tasks from the included file
+	Parent       string                   // Including include; empty for the root Taskfile
+	Dir          string
+	Optional     bool
+	Flatten      bool
+	Internal     bool
+	Aliases      []string
+	Excludes     []string
+	Error        string // Why the included Taskfile could not be loaded
 }
 
 // TaskAnalysis represents detailed analysis of a single task
 type TaskAnalysis struct {
-	Name            string
-	Description     string
-	Summary         string
-	Commands        []string
-	Dependencies    []string
-	Sources         []string
-	Generates       []string
-	Variables       map[string]interface{}
-	Environment     map[string]interface{}
-	Patterns        map[string]int
-	UsageCount      int
-	IsInternal      bool
-	Platforms       []string
-	Aliases         []string
-	Preconditions   []string
-	HasWatch        bool
-	OptimizationOps []string
-	Type            string // Task type (build, test, deploy, etc.)
+	Name             string
+	Description      string
+	Summary          string
+	Commands         []string
+	Dependencies     []string
+	Sources          []string
+	Generates        []string
+	Variables        map[string]interface{}
+	Environment      map[string]interface{}
+	Patterns         map[string]int
+	UsageCount       int
+	IsInternal       bool
+	Platforms        []string
+	Aliases          []string
+	Preconditions    []string
+	HasWatch         bool
+	OptimizationOps  []string
+	Type             string // Task type (build, test, deploy, etc.)
+	ResolvedCommands []string      // Commands with static variables substituted
+	ResolvedVars     []ResolvedVar // Variables the commands reference
+	CallSites        []CallSite    // Calls with vars that change the commands
+	Calls            []TaskCall    // Typed edges behind Dependencies
+}
+
+// Task call kinds, matching the go-task dependency kinds of the pipeline IR
+const (
+	TaskCallDep        = "dep"      // deps:, run in parallel before the commands
+	TaskCallSequential = "call"     // task: in cmds, run in order with the commands
+	TaskCallDeferred   = "deferred" // defer: task: in cmds, run when the task exits
+)
+
+// TaskCall is an edge of the task graph: one task running another
+type TaskCall struct {
+	Task string
+	Kind string
+	Vars map[string]interface{}
+	Loop bool // Run once per item of a for: loop
 }
 
 // DependencyGraph represents the task dependency structure
 type DependencyGraph struct {
 	Tasks    []string
 	Edges    map[string][]string
+	Calls    map[string][]TaskCall
 	Levels   map[string]int
 	Cycles   [][]string
 }
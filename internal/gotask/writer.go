@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/nichecode/pipeline-analyzer/internal/shared"
 )
@@ -112,6 +113,18 @@ func (w *Writer) writeTaskFiles(analysis *Analysis) error {
 		}
 	}
 
+	// Included tasks are written under their full names, such as docker:build
+	for _, includeAnalysis := range analysis.IncludeAnalysis {
+		for _, taskAnalysis := range includeAnalysis.Tasks {
+			content := GenerateTaskMarkdown(taskAnalysis)
+			filename := fmt.Sprintf("tasks/%s.md", NormalizeTaskName(taskAnalysis.Name))
+
+			if err := w.writeFile(filename, content); err != nil {
+				return fmt.Errorf("failed to write task file %s: %w", filename, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -186,9 +199,42 @@ func (w *Writer) writeFile(filename, content string) error {
 func generateIncludeMarkdown(includeName string, includeAnalysis *IncludeAnalysis) string {
 	content := fmt.Sprintf("# Include: %s\n\n", includeName)
 	content += fmt.Sprintf("**Path:** %s\n", includeAnalysis.Path)
-	content += fmt.Sprintf("**Namespace:** %s\n", includeAnalysis.Namespace)
+	if includeAnalysis.Namespace != "" {
+		content += fmt.Sprintf("**Namespace:** %s\n", includeAnalysis.Namespace)
+	} else {
+		content += "**Namespace:** *(root)*\n"
+	}
+	if includeAnalysis.Parent != "" {
+		content += fmt.Sprintf("**Included By:** [%s](%s.md)\n", includeAnalysis.Parent, NormalizeTaskName(includeAnalysis.Parent))
+	}
+	if includeAnalysis.Dir != "" {
+		content += fmt.Sprintf("**Directory:** %s\n", includeAnalysis.Dir)
+	}
+	var options []string
+	if includeAnalysis.Optional {
+		options = append(options, "optional")
+	}
+	if includeAnalysis.Flatten {
+		options = append(options, "flatten")
+	}
+	if includeAnalysis.Internal {
+		options = append(options, "internal")
+	}
+	if len(options) > 0 {
+		content += fmt.Sprintf("**Options:** %s\n", strings.Join(options, ", "))
+	}
+	if len(includeAnalysis.Aliases) > 0 {
+		content += fmt.Sprintf("**Aliases:** %s\n", strings.Join(includeAnalysis.Aliases, ", "))
+	}
+	if len(includeAnalysis.Excludes) > 0 {
+		content += fmt.Sprintf("**Excludes:** %s\n", strings.Join(includeAnalysis.Excludes, ", "))
+	}
 	content += fmt.Sprintf("**Task Count:** %d\n\n", includeAnalysis.TaskCount)
 
+	if includeAnalysis.Error != "" {
+		content += fmt.Sprintf("⚠️ **Not loaded:** %s\n\n", includeAnalysis.Error)
+	}
+
 	if len(includeAnalysis.Dependencies) > 0 {
 		content += "## Dependencies\n\n"
 		for _, dep := range includeAnalysis.Dependencies {
@@ -229,8 +275,8 @@ func generateIncludeMarkdown(includeName string, includeAnalysis *IncludeAnalysi
 				}
 			}
 
-			content += fmt.Sprintf("| **%s** | %s | %s | %s |\n",
-				taskName, description, commandsText, taskAnalysis.Type)
+			content += fmt.Sprintf("| [**%s**](../tasks/%s.md) | %s | %s | %s |\n",
+				taskName, NormalizeTaskName(taskAnalysis.Name), description, commandsText, taskAnalysis.Type)
 		}
 		content += "\n"
 
@@ -252,6 +298,9 @@ func generateIncludeMarkdown(includeName string, includeAnalysis *IncludeAnalysi
 				for i, command := range taskAnalysis.Commands {
 					content += fmt.Sprintf("Command %d. \n\n", i+1)
 					content += fmt.Sprintf("```bash\n%s\n```\n\n", command)
+					if i < len(taskAnalysis.ResolvedCommands) && taskAnalysis.ResolvedCommands[i] != command {
+						content += fmt.Sprintf("Resolved:\n\n```bash\n%s\n```\n\n", taskAnalysis.ResolvedCommands[i])
+					}
 				}
 			} else if len(taskAnalysis.Dependencies) > 0 {
 				content += "**Type:** Dependency-only task\n\n"
@@ -305,10 +354,27 @@ func generateIncludesSummary(analysis *Analysis) string {
 			normalizedName := NormalizeTaskName(name)
 			includeLink := fmt.Sprintf("[%s](../includes/%s.md)", name, normalizedName)
 
+			namespace := include.Namespace
+			if namespace == "" {
+				namespace = "*(root)*"
+			}
+
 			content += fmt.Sprintf("| %s | %s | %d | %s |\n",
-				includeLink, include.Path, include.TaskCount, include.Namespace)
+				includeLink, include.Path, include.TaskCount, namespace)
 		}
 		content += "\n"
+
+		var failed []string
+		for _, name := range includeNames {
+			if include := analysis.IncludeAnalysis[name]; include.Error != "" {
+				failed = append(failed, fmt.Sprintf("- **%s**: %s\n", name, include.Error))
+			}
+		}
+		if len(failed) > 0 {
+			content += "## ⚠️ Includes Not Loaded\n\n"
+			content += strings.Join(failed, "")
+			content += "\n"
+		}
 	}
 
 	content += "## Navigation\n\n"
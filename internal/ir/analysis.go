@@ -0,0 +1,131 @@
+package ir
+
+import (
+	"sort"
+)
+
+// Occurrence locates a command or image inside a lowered pipeline
+type Occurrence struct {
+	Tool     string `json:"tool"`
+	Pipeline string `json:"pipeline"`
+	Job      string `json:"job"`
+}
+
+// SharedItem is a command or image that appears in more than one tool
+type SharedItem struct {
+	Value       string       `json:"value"`
+	Tools       []string     `json:"tools"`
+	Occurrences []Occurrence `json:"occurrences"`
+}
+
+// PipelineStats summarizes the size of one lowered pipeline
+type PipelineStats struct {
+	Tool     string `json:"tool"`
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Jobs     int    `json:"jobs"`
+	Steps    int    `json:"steps"`
+	Commands int    `json:"commands"`
+}
+
+// CrossToolSummary holds comparisons computed across all lowered pipelines
+type CrossToolSummary struct {
+	Pipelines      []PipelineStats `json:"pipelines"`
+	SharedCommands []SharedItem    `json:"shared_commands"`
+	SharedImages   []SharedItem    `json:"shared_images"`
+}
+
+// Compare computes cross-tool comparisons over a set of pipelines
+func Compare(pipelines []*Pipeline) *CrossToolSummary {
+	summary := &CrossToolSummary{
+		Pipelines:      []PipelineStats{},
+		SharedCommands: []SharedItem{},
+		SharedImages:   []SharedItem{},
+	}
+
+	commands := make(map[string][]Occurrence)
+	images := make(map[string][]Occurrence)
+
+	for _, pipeline := range pipelines {
+		stats := PipelineStats{
+			Tool:   pipeline.Tool,
+			Name:   pipeline.Name,
+			Source: pipeline.Source,
+			Jobs:   len(pipeline.Jobs),
+		}
+
+		for _, job := range pipeline.Jobs {
+			occurrence := Occurrence{Tool: pipeline.Tool, Pipeline: pipeline.Name, Job: job.ID}
+			stats.Steps += len(job.Steps)
+
+			for _, command := range job.Commands() {
+				stats.Commands++
+				commands[command.Raw] = appendOccurrence(commands[command.Raw], occurrence)
+			}
+			for _, image := range job.Images {
+				images[image.Name] = appendOccurrence(images[image.Name], occurrence)
+			}
+		}
+
+		summary.Pipelines = append(summary.Pipelines, stats)
+	}
+
+	summary.SharedCommands = sharedAcrossTools(commands)
+	summary.SharedImages = sharedAcrossTools(images)
+
+	return summary
+}
+
+// appendOccurrence adds an occurrence unless the same job is already recorded
+func appendOccurrence(occurrences []Occurrence, occurrence Occurrence) []Occurrence {
+	for _, existing := range occurrences {
+		if existing == occurrence {
+			return occurrences
+		}
+	}
+	return append(occurrences, occurrence)
+}
+
+// sharedAcrossTools keeps only the values that occur in at least two tools
+func sharedAcrossTools(values map[string][]Occurrence) []SharedItem {
+	var shared []SharedItem
+
+	for value, occurrences := range values {
+		tools := make(map[string]bool)
+		for _, occurrence := range occurrences {
+			tools[occurrence.Tool] = true
+		}
+		if len(tools) < 2 {
+			continue
+		}
+
+		item := SharedItem{Value: value, Occurrences: occurrences}
+		for tool := range tools {
+			item.Tools = append(item.Tools, tool)
+		}
+		sort.Strings(item.Tools)
+		sort.Slice(item.Occurrences, func(i, j int) bool {
+			a, b := item.Occurrences[i], item.Occurrences[j]
+			if a.Tool != b.Tool {
+				return a.Tool < b.Tool
+			}
+			if a.Pipeline != b.Pipeline {
+				return a.Pipeline < b.Pipeline
+			}
+			return a.Job < b.Job
+		})
+		shared = append(shared, item)
+	}
+
+	sort.Slice(shared, func(i, j int) bool {
+		if len(shared[i].Tools) != len(shared[j].Tools) {
+			return len(shared[i].Tools) > len(shared[j].Tools)
+		}
+		return shared[i].Value < shared[j].Value
+	})
+
+	if shared == nil {
+		return []SharedItem{}
+	}
+	return shared
+}
@@ -0,0 +1,258 @@
+package ir
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// GoTaskInstallScript is the go-task install script. Run steps that download
+// it only set up go-task, so they are not counted as shell commands.
+const GoTaskInstallScript = "taskfile.dev/install.sh"
+
+// Flags of the task CLI that take a separate value
+var taskValueFlags = []string{"-t", "--taskfile", "-d", "--dir", "-o", "--output", "-C", "--concurrency", "-I", "--interval", "--output-group-begin", "--output-group-end", "--sort"}
+
+// TaskNamesFunc returns the tasks a Taskfile defines, keyed by the names the
+// task CLI accepts. dir is the directory the call runs in, relative to the
+// repository root, and taskfile the --taskfile or --dir value of the call, ""
+// for the default Taskfile. ok is false when the Taskfile cannot be found.
+type TaskNamesFunc func(dir, taskfile string) (names map[string]bool, ok bool)
+
+// TaskCall is a task invoked from a CI command line
+type TaskCall struct {
+	Task     string `json:"task"`
+	Taskfile string `json:"taskfile,omitempty"` // --taskfile or --dir value
+	Dir      string `json:"dir,omitempty"`      // Directory the call runs in, relative to the repository root
+	Defined  bool   `json:"defined"`
+}
+
+// JobCoverage is the migration coverage of one CI job
+type JobCoverage struct {
+	Tool          string     `json:"tool"`
+	Source        string     `json:"source"`
+	Job           string     `json:"job"`
+	Commands      int        `json:"commands"`
+	TaskCommands  int        `json:"task_commands"`
+	ShellCommands int        `json:"shell_commands"`
+	Calls         []TaskCall `json:"calls"`
+}
+
+// UndefinedTask is a task called from CI that no Taskfile defines
+type UndefinedTask struct {
+	Task        string       `json:"task"`
+	Taskfile    string       `json:"taskfile,omitempty"`
+	Dir         string       `json:"dir,omitempty"`
+	Occurrences []Occurrence `json:"occurrences"`
+}
+
+// MigrationCoverage measures how many CI command lines already go through
+// go-task instead of running raw shell
+type MigrationCoverage struct {
+	Commands       int             `json:"commands"`
+	TaskCommands   int             `json:"task_commands"`
+	ShellCommands  int             `json:"shell_commands"`
+	Jobs           []JobCoverage   `json:"jobs"`
+	UndefinedTasks []UndefinedTask `json:"undefined_tasks"`
+}
+
+// Percent returns the share of command lines that run through go-task
+func (c *MigrationCoverage) Percent() float64 {
+	return coveragePercent(c.TaskCommands, c.Commands)
+}
+
+// Percent returns the share of the job's command lines that run through go-task
+func (j *JobCoverage) Percent() float64 {
+	return coveragePercent(j.TaskCommands, j.Commands)
+}
+
+// MeasureMigrationCoverage classifies every command line of the CircleCI and
+// GitHub Actions run steps as a task call or raw shell, and checks that each
+// called task exists in the Taskfile it resolves to from the step's directory
+func MeasureMigrationCoverage(pipelines []*Pipeline, taskNames TaskNamesFunc) *MigrationCoverage {
+	coverage := &MigrationCoverage{Jobs: []JobCoverage{}, UndefinedTasks: []UndefinedTask{}}
+	undefined := make(map[TaskCall][]Occurrence)
+
+	for _, pipeline := range pipelines {
+		if pipeline.Tool != ToolCircleCI && pipeline.Tool != ToolGitHubActions {
+			continue
+		}
+
+		for _, job := range pipeline.Jobs {
+			jobCoverage := JobCoverage{Tool: pipeline.Tool, Source: pipeline.Source, Job: job.ID, Calls: []TaskCall{}}
+			for _, step := range job.Steps {
+				if step.Kind != StepRun || installsGoTask(step) {
+					continue
+				}
+				for _, command := range step.Commands {
+					jobCoverage.Commands++
+					calls, ok := ParseTaskCalls(command.Raw)
+					if !ok {
+						jobCoverage.ShellCommands++
+						continue
+					}
+					jobCoverage.TaskCommands++
+
+					for _, call := range calls {
+						call.Dir = job.StepDir(step)
+						names, found := taskNames(call.Dir, call.Taskfile)
+						call.Defined = found && names[call.Task]
+						jobCoverage.Calls = append(jobCoverage.Calls, call)
+						if !call.Defined {
+							key := TaskCall{Task: call.Task, Taskfile: call.Taskfile, Dir: call.Dir}
+							undefined[key] = appendOccurrence(undefined[key], Occurrence{Tool: pipeline.Tool, Pipeline: pipeline.Name, Job: job.ID})
+						}
+					}
+				}
+			}
+			if jobCoverage.Commands == 0 {
+				continue
+			}
+
+			coverage.Commands += jobCoverage.Commands
+			coverage.TaskCommands += jobCoverage.TaskCommands
+			coverage.ShellCommands += jobCoverage.ShellCommands
+			coverage.Jobs = append(coverage.Jobs, jobCoverage)
+		}
+	}
+
+	for call, occurrences := range undefined {
+		coverage.UndefinedTasks = append(coverage.UndefinedTasks, UndefinedTask{Task: call.Task, Taskfile: call.Taskfile, Dir: call.Dir, Occurrences: occurrences})
+	}
+	sort.Slice(coverage.UndefinedTasks, func(i, j int) bool {
+		a, b := coverage.UndefinedTasks[i], coverage.UndefinedTasks[j]
+		if a.Task != b.Task {
+			return a.Task < b.Task
+		}
+		if a.Taskfile != b.Taskfile {
+			return a.Taskfile < b.Taskfile
+		}
+		return a.Dir < b.Dir
+	})
+	sort.SliceStable(coverage.Jobs, func(i, j int) bool {
+		a, b := coverage.Jobs[i], coverage.Jobs[j]
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Job < b.Job
+	})
+
+	return coverage
+}
+
+// ParseTaskCalls returns the tasks a command line runs with the task CLI.
+// The line is split on &&, ||, | and ; so every chained task invocation
+// counts. ok is false when no segment is a task invocation. A call without
+// task names runs the default task; informational calls such as task --list
+// run no task but still count as going through go-task.
+func ParseTaskCalls(raw string) (calls []TaskCall, ok bool) {
+	for _, segment := range splitShellSegments(raw) {
+		segmentCalls, isTask := parseTaskSegment(segment)
+		if isTask {
+			calls = append(calls, segmentCalls...)
+			ok = true
+		}
+	}
+	return calls, ok
+}
+
+// splitShellSegments splits a command line on the &&, ||, | and ; operators
+// outside quotes
+func splitShellSegments(raw string) []string {
+	var segments []string
+	var current strings.Builder
+	var quoteChar byte
+
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quoteChar != 0:
+			if c == quoteChar {
+				quoteChar = 0
+			}
+		case c == '"' || c == '\'':
+			quoteChar = c
+		case c == ';' || c == '|' || (c == '&' && i+1 < len(raw) && raw[i+1] == '&'):
+			if (c == '|' || c == '&') && i+1 < len(raw) && raw[i+1] == c {
+				i++
+			}
+			segments = append(segments, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+
+	return append(segments, current.String())
+}
+
+// parseTaskSegment parses a single command of a command line as a task call
+func parseTaskSegment(raw string) (calls []TaskCall, ok bool) {
+	if shared.ExtractCommandName(raw) != "task" {
+		return nil, false
+	}
+
+	// Skip a wrapper such as sudo; the binary may be given as a path
+	words := shared.SplitCommand(strings.TrimSpace(raw))
+	start := 0
+	for start < len(words) && filepath.Base(words[start]) != "task" {
+		start++
+	}
+
+	var taskfile string
+	var names []string
+	informational := false
+	for i := start + 1; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case word == "--":
+			i = len(words)
+		case shared.ContainsString(taskValueFlags, word):
+			if i+1 < len(words) && (word == "-t" || word == "--taskfile" || word == "-d" || word == "--dir") {
+				taskfile = words[i+1]
+			}
+			i++
+		case strings.HasPrefix(word, "--taskfile=") || strings.HasPrefix(word, "--dir="):
+			taskfile = word[strings.Index(word, "=")+1:]
+		case word == "-l" || word == "--list" || word == "-a" || word == "--list-all" || word == "--version" || word == "-h" || word == "--help" || word == "--init" || word == "-i":
+			informational = true
+		case strings.HasPrefix(word, "-"):
+			// Boolean flags such as --force or --parallel
+		case strings.Contains(word, "="):
+			// Variables passed as NAME=value
+		default:
+			names = append(names, word)
+		}
+	}
+
+	if len(names) == 0 && !informational {
+		names = []string{"default"}
+	}
+	for _, name := range names {
+		calls = append(calls, TaskCall{Task: name, Taskfile: taskfile})
+	}
+	return calls, true
+}
+
+// installsGoTask reports whether a run step only installs go-task
+func installsGoTask(step Step) bool {
+	for _, command := range step.Commands {
+		if strings.Contains(command.Raw, GoTaskInstallScript) {
+			return true
+		}
+	}
+	return false
+}
+
+// coveragePercent returns part as a percentage of total
+func coveragePercent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
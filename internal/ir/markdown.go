@@ -0,0 +1,119 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// CrossToolFileName is the name of the cross-tool report in the discovery directory
+const CrossToolFileName = "cross-tool.md"
+
+// GenerateCrossToolReport generates the cross-tool comparison page
+func GenerateCrossToolReport(summary *CrossToolSummary) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Cross-Tool Pipeline Comparison\n\n")
+	sb.WriteString("Every CI configuration and Taskfile is lowered into a shared pipeline model so that ")
+	sb.WriteString("commands and images can be compared across tools.\n\n")
+
+	// Pipelines section
+	sb.WriteString("## 📊 Pipelines\n\n")
+	if len(summary.Pipelines) == 0 {
+		sb.WriteString("No pipelines were lowered.\n\n")
+	} else {
+		sb.WriteString("| Tool | Pipeline | Source | Jobs | Steps | Commands |\n")
+		sb.WriteString("|------|----------|--------|------|-------|----------|\n")
+		for _, stats := range summary.Pipelines {
+			sb.WriteString(fmt.Sprintf("| %s | %s | `%s` | %d | %d | %d |\n",
+				stats.Tool, stats.Name, stats.Source, stats.Jobs, stats.Steps, stats.Commands))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Shared commands section
+	sb.WriteString("## ⚡ Commands Shared Across Tools\n\n")
+	if len(summary.SharedCommands) == 0 {
+		sb.WriteString("No command runs in more than one tool.\n\n")
+	} else {
+		sb.WriteString("These commands are duplicated between tools. They are the best candidates for a single ")
+		sb.WriteString("go-task task that every CI system calls.\n\n")
+		sb.WriteString("| Command | Tools | Used In |\n")
+		sb.WriteString("|---------|-------|---------|\n")
+		for _, item := range summary.SharedCommands {
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n",
+				strings.ReplaceAll(shared.TruncateString(item.Value, 80), "|", "\\|"),
+				strings.Join(item.Tools, ", "),
+				formatOccurrences(item.Occurrences)))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Shared images section
+	sb.WriteString("## 🐳 Images Shared Across Tools\n\n")
+	if len(summary.SharedImages) == 0 {
+		sb.WriteString("No container image is used by more than one tool.\n\n")
+	} else {
+		sb.WriteString("| Image | Tools | Used In |\n")
+		sb.WriteString("|-------|-------|---------|\n")
+		for _, item := range summary.SharedImages {
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n",
+				item.Value, strings.Join(item.Tools, ", "), formatOccurrences(item.Occurrences)))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Navigation\n\n")
+	sb.WriteString("- [← Back to Overview](README.md)\n")
+
+	return sb.String()
+}
+
+// formatOccurrences renders occurrences as a compact comma-separated list
+func formatOccurrences(occurrences []Occurrence) string {
+	parts := make([]string, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		parts = append(parts, fmt.Sprintf("%s: %s", occurrence.Tool, occurrence.Job))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GenerateMigrationCoverageSection renders the migration coverage headline
+// and per-job breakdown for the discovery overview
+func GenerateMigrationCoverageSection(coverage *MigrationCoverage) string {
+	var sb strings.Builder
+
+	sb.WriteString("## 🎯 Migration Coverage\n\n")
+	sb.WriteString(fmt.Sprintf("**%.0f%%** of CI command lines run through go-task (%d of %d); **%d** still run raw shell.\n\n",
+		coverage.Percent(), coverage.TaskCommands, coverage.Commands, coverage.ShellCommands))
+
+	sb.WriteString("| Tool | Source | Job | Commands | Via task | Raw shell | Coverage |\n")
+	sb.WriteString("|------|--------|-----|----------|----------|-----------|----------|\n")
+	for _, job := range coverage.Jobs {
+		sb.WriteString(fmt.Sprintf("| %s | `%s` | %s | %d | %d | %d | %.0f%% |\n",
+			job.Tool, job.Source, job.Job, job.Commands, job.TaskCommands, job.ShellCommands, job.Percent()))
+	}
+	sb.WriteString("\n")
+
+	if len(coverage.UndefinedTasks) > 0 {
+		sb.WriteString("### ⚠️ Undefined Tasks\n\n")
+		sb.WriteString("These tasks are called from CI but are not defined in the Taskfile or its includes, ")
+		sb.WriteString("so the job fails when it reaches the call.\n\n")
+		sb.WriteString("| Task | Taskfile | Called From |\n")
+		sb.WriteString("|------|----------|-------------|\n")
+		for _, task := range coverage.UndefinedTasks {
+			taskfile := task.Taskfile
+			if taskfile == "" {
+				taskfile = "default"
+			}
+			if task.Dir != "" {
+				taskfile += fmt.Sprintf(" (from `%s`)", task.Dir)
+			}
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", task.Task, taskfile, formatOccurrences(task.Occurrences)))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
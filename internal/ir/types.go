@@ -0,0 +1,254 @@
+package ir
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// Tool identifiers used in Pipeline.Tool
+const (
+	ToolCircleCI      = "circleci"
+	ToolGitHubActions = "github-actions"
+	ToolGoTask        = "gotask"
+)
+
+// StepKind classifies what a step does independent of the tool that defines it
+type StepKind string
+
+const (
+	StepRun       StepKind = "run"
+	StepCheckout  StepKind = "checkout"
+	StepAction    StepKind = "action"
+	StepCache     StepKind = "cache"
+	StepWorkspace StepKind = "workspace"
+	StepArtifact  StepKind = "artifact"
+	StepTask      StepKind = "task"
+	StepOther     StepKind = "other"
+)
+
+// Dependency kinds used in Dependency.Kind
+const (
+	DependencyRequires = "requires" // CircleCI workflow requires
+	DependencyNeeds    = "needs"    // GitHub Actions needs
+	DependencyDep      = "dep"      // go-task deps
+	DependencyCall     = "call"     // go-task task call from cmds
+	DependencyDeferred = "deferred" // go-task defer: task call from cmds
+)
+
+// Image roles used in Image.Role
+const (
+	ImagePrimary   = "primary"
+	ImageService   = "service"
+	ImageContainer = "container"
+)
+
+// Pipeline is one lowered configuration: a CircleCI config, a GitHub Actions
+// workflow file or a Taskfile
+type Pipeline struct {
+	Tool     string    `json:"tool"`
+	Name     string    `json:"name"`
+	Source   string    `json:"source"`
+	Triggers []Trigger `json:"triggers,omitempty"`
+	Env      []Env     `json:"env,omitempty"`
+	Jobs     []*Job    `json:"jobs"`
+}
+
+// Job is a unit of execution: a CI job or a task
+type Job struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name,omitempty"`
+	Runner     string       `json:"runner,omitempty"`
+	WorkingDir string       `json:"working_dir,omitempty"`
+	Images     []Image      `json:"images,omitempty"`
+	Env        []Env        `json:"env,omitempty"`
+	Needs      []Dependency `json:"needs,omitempty"`
+	Steps      []Step       `json:"steps"`
+}
+
+// Step is a single step of a job
+type Step struct {
+	Name       string    `json:"name,omitempty"`
+	Kind       StepKind  `json:"kind"`
+	Uses       string    `json:"uses,omitempty"`
+	Commands   []Command `json:"commands,omitempty"`
+	WorkingDir string    `json:"working_dir,omitempty"`
+	Env        []Env     `json:"env,omitempty"`
+	Origin     string    `json:"origin,omitempty"`    // Where the step came from (e.g. a reusable command)
+	Condition  string    `json:"condition,omitempty"` // Run-time condition the step runs under; empty when it always runs
+}
+
+// Command is a single shell command line
+type Command struct {
+	Raw     string `json:"raw"`
+	Program string `json:"program"`
+}
+
+// Dependency is an ordering edge to another job in the same pipeline
+type Dependency struct {
+	Target string `json:"target"`
+	Kind   string `json:"kind"`
+}
+
+// Image is a container image a job runs in or alongside
+type Image struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// Env is a single environment variable
+type Env struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Trigger describes an event that starts a pipeline
+type Trigger struct {
+	Event    string   `json:"event"`
+	Branches []string `json:"branches,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Paths    []string `json:"paths,omitempty"`
+	Schedule string   `json:"schedule,omitempty"`
+}
+
+// NewCommand creates a Command from a raw command line
+func NewCommand(raw string) Command {
+	raw = NormalizeCommand(raw)
+	return Command{
+		Raw:     raw,
+		Program: shared.ExtractCommandName(raw),
+	}
+}
+
+// SplitCommands splits a shell script into individual command lines,
+// joining backslash continuations and dropping blanks and comments
+func SplitCommands(script string) []Command {
+	var commands []Command
+	var pending string
+
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		line = pending + line
+		pending = ""
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commands = append(commands, NewCommand(line))
+	}
+
+	if strings.TrimSpace(pending) != "" {
+		commands = append(commands, NewCommand(pending))
+	}
+
+	return commands
+}
+
+// NormalizeCommand collapses whitespace so equivalent commands compare equal
+func NormalizeCommand(raw string) string {
+	return strings.Join(strings.Fields(raw), " ")
+}
+
+// EnvFromMap converts a map of environment values to a sorted Env list
+func EnvFromMap[V any](values map[string]V) []Env {
+	if len(values) == 0 {
+		return nil
+	}
+
+	env := make([]Env, 0, len(values))
+	for name, value := range values {
+		env = append(env, Env{Name: name, Value: fmt.Sprintf("%v", value)})
+	}
+	sort.Slice(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+
+	return env
+}
+
+// CheckoutDirs are the directories CircleCI images check the repository out to
+var CheckoutDirs = []string{"~/project", "/home/circleci/project", "/root/project"}
+
+// StepDir returns the directory a step of the job runs in, relative to the
+// repository root, or "" for the root. Relative step directories are below
+// the job's; absolute ones are mapped through the checkout directories.
+func (j *Job) StepDir(step Step) string {
+	dir := checkoutRelative(j.WorkingDir)
+	if step.WorkingDir != "" {
+		if path.IsAbs(step.WorkingDir) || strings.HasPrefix(step.WorkingDir, "~") {
+			dir = checkoutRelative(step.WorkingDir)
+		} else {
+			dir = path.Join(dir, step.WorkingDir)
+		}
+	}
+
+	if dir = path.Clean(dir); dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// checkoutRelative makes a working directory relative to the checkout.
+// Absolute directories outside the known checkout directories are taken to
+// be the checkout itself.
+func checkoutRelative(dir string) string {
+	for _, root := range CheckoutDirs {
+		if dir == root {
+			return ""
+		}
+		if strings.HasPrefix(dir, root+"/") {
+			return strings.TrimPrefix(dir, root+"/")
+		}
+	}
+	if path.IsAbs(dir) || strings.HasPrefix(dir, "~") {
+		return ""
+	}
+	return dir
+}
+
+// Job returns the job with the given ID, or nil
+func (p *Pipeline) Job(id string) *Job {
+	for _, job := range p.Jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// SortJobs orders jobs by ID for stable output
+func (p *Pipeline) SortJobs() {
+	sort.Slice(p.Jobs, func(i, j int) bool { return p.Jobs[i].ID < p.Jobs[j].ID })
+}
+
+// Commands returns every command the job runs, in step order
+func (j *Job) Commands() []Command {
+	var commands []Command
+	for _, step := range j.Steps {
+		commands = append(commands, step.Commands...)
+	}
+	return commands
+}
+
+// DependencyTargets returns the IDs of the jobs this job depends on
+func (j *Job) DependencyTargets() []string {
+	targets := make([]string, 0, len(j.Needs))
+	for _, dep := range j.Needs {
+		targets = append(targets, dep.Target)
+	}
+	return targets
+}
+
+// ImageNames returns the names of all images the job uses
+func (j *Job) ImageNames() []string {
+	names := make([]string, 0, len(j.Images))
+	for _, image := range j.Images {
+		names = append(names, image.Name)
+	}
+	return names
+}
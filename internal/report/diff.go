@@ -0,0 +1,519 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+	"github.com/nichecode/pipeline-analyzer/internal/shared"
+)
+
+// Diff is the change between two reports over every analyzer: jobs,
+// dependencies, images, actions and commands, plus the headline metrics
+type Diff struct {
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	AddedJobs      []JobRef          `json:"added_jobs"`
+	RemovedJobs    []JobRef          `json:"removed_jobs"`
+	ChangedJobs    []JobChange       `json:"changed_jobs"`
+	RiskyCommands  []RiskyCommand    `json:"risky_commands"`
+	AddedImages    []ImageUse        `json:"added_images"`
+	RemovedImages  []ImageUse        `json:"removed_images"`
+	AddedActions   []ActionReference `json:"added_actions"`
+	RemovedActions []ActionReference `json:"removed_actions"`
+	Metrics        []MetricChange    `json:"metrics"`
+}
+
+// JobRef identifies a job or task of a lowered pipeline
+type JobRef struct {
+	Tool   string `json:"tool"`
+	Source string `json:"source"`
+	Job    string `json:"job"`
+}
+
+// JobChange lists what changed inside a job present in both reports
+type JobChange struct {
+	JobRef
+	AddedNeeds      []string `json:"added_needs"`
+	RemovedNeeds    []string `json:"removed_needs"`
+	AddedImages     []string `json:"added_images"`
+	RemovedImages   []string `json:"removed_images"`
+	AddedUses       []string `json:"added_uses"` // Actions, orbs and other non-run steps
+	RemovedUses     []string `json:"removed_uses"`
+	AddedCommands   []string `json:"added_commands"`
+	RemovedCommands []string `json:"removed_commands"`
+}
+
+// RiskyCommand is a new command line that assessCommandRisk rates medium or high
+type RiskyCommand struct {
+	JobRef
+	Command string `json:"command"`
+	Risk    string `json:"risk"`
+}
+
+// ImageUse is a container image and where it is used
+type ImageUse struct {
+	Image  string   `json:"image"`
+	UsedBy []string `json:"used_by"`
+}
+
+// MetricChange compares a headline metric; nil means it was not measured
+type MetricChange struct {
+	Name   string   `json:"name"`
+	Before *float64 `json:"before"`
+	After  *float64 `json:"after"`
+}
+
+// Compare computes the changes from one report to another
+func Compare(from, to *Report) *Diff {
+	diff := &Diff{
+		AddedJobs:      []JobRef{},
+		RemovedJobs:    []JobRef{},
+		ChangedJobs:    []JobChange{},
+		RiskyCommands:  []RiskyCommand{},
+		AddedActions:   []ActionReference{},
+		RemovedActions: []ActionReference{},
+	}
+
+	before, after := indexJobs(from), indexJobs(to)
+	for _, ref := range sortedJobRefs(after) {
+		job := after[ref]
+		previous, ok := before[ref]
+		if !ok {
+			diff.AddedJobs = append(diff.AddedJobs, ref)
+			diff.RiskyCommands = append(diff.RiskyCommands, riskyCommands(ref, jobCommands(job))...)
+			continue
+		}
+		change := compareJob(ref, previous, job)
+		diff.RiskyCommands = append(diff.RiskyCommands, riskyCommands(ref, change.AddedCommands)...)
+		if !change.empty() {
+			diff.ChangedJobs = append(diff.ChangedJobs, change)
+		}
+	}
+	for _, ref := range sortedJobRefs(before) {
+		if _, ok := after[ref]; !ok {
+			diff.RemovedJobs = append(diff.RemovedJobs, ref)
+		}
+	}
+
+	diff.AddedImages, diff.RemovedImages = compareImages(reportImages(from), reportImages(to))
+
+	fromActions, toActions := indexActions(from), indexActions(to)
+	for _, uses := range sortedKeys(toActions) {
+		if _, ok := fromActions[uses]; !ok {
+			diff.AddedActions = append(diff.AddedActions, toActions[uses])
+		}
+	}
+	for _, uses := range sortedKeys(fromActions) {
+		if _, ok := toActions[uses]; !ok {
+			diff.RemovedActions = append(diff.RemovedActions, fromActions[uses])
+		}
+	}
+
+	fromMetrics, toMetrics := reportMetrics(from), reportMetrics(to)
+	diff.Metrics = []MetricChange{}
+	for _, name := range metricNames {
+		change := MetricChange{Name: name, Before: fromMetrics[name], After: toMetrics[name]}
+		if change.Before != nil || change.After != nil {
+			diff.Metrics = append(diff.Metrics, change)
+		}
+	}
+
+	return diff
+}
+
+// Empty reports whether the pipelines are structurally unchanged. Metric
+// changes alone do not count.
+func (d *Diff) Empty() bool {
+	return len(d.AddedJobs) == 0 && len(d.RemovedJobs) == 0 && len(d.ChangedJobs) == 0 &&
+		len(d.AddedImages) == 0 && len(d.RemovedImages) == 0 &&
+		len(d.AddedActions) == 0 && len(d.RemovedActions) == 0
+}
+
+// empty reports whether nothing changed inside the job
+func (c JobChange) empty() bool {
+	return len(c.AddedNeeds) == 0 && len(c.RemovedNeeds) == 0 &&
+		len(c.AddedImages) == 0 && len(c.RemovedImages) == 0 &&
+		len(c.AddedUses) == 0 && len(c.RemovedUses) == 0 &&
+		len(c.AddedCommands) == 0 && len(c.RemovedCommands) == 0
+}
+
+// indexJobs keys the jobs of every lowered pipeline by tool, source and ID
+func indexJobs(r *Report) map[JobRef]*ir.Job {
+	jobs := make(map[JobRef]*ir.Job)
+	for _, pipeline := range r.Pipelines {
+		for _, job := range pipeline.Jobs {
+			jobs[JobRef{Tool: pipeline.Tool, Source: filepath.ToSlash(pipeline.Source), Job: job.ID}] = job
+		}
+	}
+	return jobs
+}
+
+// sortedJobRefs returns the keys of a job index in display order
+func sortedJobRefs(jobs map[JobRef]*ir.Job) []JobRef {
+	refs := make([]JobRef, 0, len(jobs))
+	for ref := range jobs {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Job < b.Job
+	})
+	return refs
+}
+
+// compareJob lists the dependencies, images, steps and commands that changed
+func compareJob(ref JobRef, before, after *ir.Job) JobChange {
+	change := JobChange{JobRef: ref}
+	change.AddedNeeds, change.RemovedNeeds = compareLists(uniqueItems(jobNeeds(before)), uniqueItems(jobNeeds(after)))
+	change.AddedImages, change.RemovedImages = compareLists(uniqueItems(jobImages(before)), uniqueItems(jobImages(after)))
+	change.AddedUses, change.RemovedUses = compareLists(uniqueItems(jobUses(before)), uniqueItems(jobUses(after)))
+	change.AddedCommands, change.RemovedCommands = compareLists(jobCommands(before), jobCommands(after))
+	return change
+}
+
+// compareLists returns the items only in after and only in before, counting
+// repeated items so a duplicated command shows up as added
+func compareLists(before, after []string) (added, removed []string) {
+	added, removed = []string{}, []string{}
+	remaining := make(map[string]int)
+	for _, item := range before {
+		remaining[item]++
+	}
+	for _, item := range after {
+		if remaining[item] > 0 {
+			remaining[item]--
+			continue
+		}
+		added = append(added, item)
+	}
+	for _, item := range before {
+		if remaining[item] > 0 {
+			remaining[item]--
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
+
+// uniqueItems drops repeated items, keeping the first occurrence
+func uniqueItems(items []string) []string {
+	var unique []string
+	for _, item := range items {
+		if !shared.ContainsString(unique, item) {
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
+
+// jobNeeds returns the jobs or tasks a job depends on
+func jobNeeds(job *ir.Job) []string {
+	var needs []string
+	for _, need := range job.Needs {
+		needs = append(needs, need.Target)
+	}
+	return needs
+}
+
+// jobImages returns the container images a job runs in or alongside
+func jobImages(job *ir.Job) []string {
+	var images []string
+	for _, image := range job.Images {
+		images = append(images, image.Name)
+	}
+	return images
+}
+
+// jobUses returns the actions, orbs and commands a job's non-run steps use
+func jobUses(job *ir.Job) []string {
+	var uses []string
+	for _, step := range job.Steps {
+		if step.Kind != ir.StepRun && step.Uses != "" {
+			uses = append(uses, step.Uses)
+		}
+	}
+	return uses
+}
+
+// jobCommands returns the command lines of a job's run steps
+func jobCommands(job *ir.Job) []string {
+	var commands []string
+	for _, step := range job.Steps {
+		for _, command := range step.Commands {
+			if raw := strings.TrimSpace(command.Raw); raw != "" {
+				commands = append(commands, raw)
+			}
+		}
+	}
+	return commands
+}
+
+// riskyCommands returns the commands rated medium or high risk
+func riskyCommands(ref JobRef, commands []string) []RiskyCommand {
+	var risky []RiskyCommand
+	for _, command := range commands {
+		if risk := shared.ClassifyCommand(command).Risk; risk == "high" || risk == "medium" {
+			risky = append(risky, RiskyCommand{JobRef: ref, Command: command, Risk: risk})
+		}
+	}
+	return risky
+}
+
+// reportImages collects the images of CI jobs, Dockerfiles and compose
+// services with the places that use them
+func reportImages(r *Report) map[string][]string {
+	images := make(map[string][]string)
+	add := func(image, usedBy string) {
+		if image != "" && !shared.ContainsString(images[image], usedBy) {
+			images[image] = append(images[image], usedBy)
+		}
+	}
+
+	for _, pipeline := range r.Pipelines {
+		for _, job := range pipeline.Jobs {
+			for _, image := range job.Images {
+				add(image.Name, fmt.Sprintf("%s: %s", filepath.ToSlash(pipeline.Source), job.ID))
+			}
+		}
+	}
+	if r.Docker != nil {
+		for _, dockerfile := range r.Docker.Dockerfiles {
+			for _, image := range dockerfile.BaseImages {
+				add(image, r.relativePath(dockerfile.FilePath))
+			}
+		}
+		for _, compose := range r.Docker.DockerCompose {
+			for _, name := range sortedKeys(compose.Services) {
+				if service := compose.Services[name]; service != nil {
+					add(service.Image, fmt.Sprintf("%s: %s", r.relativePath(compose.FilePath), name))
+				}
+			}
+		}
+	}
+	return images
+}
+
+// compareImages returns the images only used after and only used before
+func compareImages(before, after map[string][]string) (added, removed []ImageUse) {
+	added, removed = []ImageUse{}, []ImageUse{}
+	for _, image := range sortedKeys(after) {
+		if _, ok := before[image]; !ok {
+			added = append(added, ImageUse{Image: image, UsedBy: after[image]})
+		}
+	}
+	for _, image := range sortedKeys(before) {
+		if _, ok := after[image]; !ok {
+			removed = append(removed, ImageUse{Image: image, UsedBy: before[image]})
+		}
+	}
+	return added, removed
+}
+
+// indexActions keys the GitHub Actions uses: references by their full reference
+func indexActions(r *Report) map[string]ActionReference {
+	actions := make(map[string]ActionReference)
+	if r.GitHubActions != nil {
+		for _, ref := range r.GitHubActions.SupplyChain {
+			actions[ref.Uses] = ref
+		}
+	}
+	return actions
+}
+
+// metricNames lists the compared metrics in display order
+var metricNames = []string{
+	"Pipelines",
+	"Jobs and tasks",
+	"Command lines",
+	"Task coverage (%)",
+	"Docker score",
+	"Docker security issues",
+	"GitHub Actions security issues",
+	"High-risk action references",
+}
+
+// reportMetrics computes the headline metrics a report measures
+func reportMetrics(r *Report) map[string]*float64 {
+	metrics := make(map[string]*float64)
+	set := func(name string, value float64) {
+		metrics[name] = &value
+	}
+
+	if len(r.Pipelines) > 0 {
+		jobs, commands := 0, 0
+		for _, pipeline := range r.Pipelines {
+			jobs += len(pipeline.Jobs)
+			for _, job := range pipeline.Jobs {
+				commands += len(jobCommands(job))
+			}
+		}
+		set("Pipelines", float64(len(r.Pipelines)))
+		set("Jobs and tasks", float64(jobs))
+		set("Command lines", float64(commands))
+	}
+	if r.MigrationCoverage != nil && r.MigrationCoverage.Commands > 0 {
+		set("Task coverage (%)", r.MigrationCoverage.Percent())
+	}
+	if r.Docker != nil && r.Docker.Summary != nil {
+		set("Docker score", float64(r.Docker.Summary.OverallScore))
+		set("Docker security issues", float64(r.Docker.Summary.SecurityIssues))
+	}
+	if r.GitHubActions != nil {
+		issues, highRisk := 0, 0
+		for _, workflow := range r.GitHubActions.Workflows {
+			for _, job := range workflow.Jobs {
+				issues += len(job.SecurityIssues)
+			}
+		}
+		for _, ref := range r.GitHubActions.SupplyChain {
+			if ref.Risk == githubactions.RiskHigh {
+				highRisk++
+			}
+		}
+		set("GitHub Actions security issues", float64(issues))
+		set("High-risk action references", float64(highRisk))
+	}
+	return metrics
+}
+
+// relativePath returns path relative to the analyzed repository root
+func (r *Report) relativePath(path string) string {
+	if rel, err := filepath.Rel(r.Repository.RootPath, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// FormatDiff renders a diff as a markdown change report
+func FormatDiff(d *Diff) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Pipeline Diff\n\n")
+	fmt.Fprintf(&sb, "Changes from **%s** to **%s**.\n\n", d.From, d.To)
+	if d.Empty() {
+		sb.WriteString("No jobs, dependencies, images, actions or commands changed.\n\n")
+	}
+
+	if len(d.RiskyCommands) > 0 {
+		sb.WriteString("## ⚠️ New Risky Commands\n\n")
+		sb.WriteString("| Risk | Job | Source | Command |\n")
+		sb.WriteString("|------|-----|--------|---------|\n")
+		for _, command := range d.RiskyCommands {
+			fmt.Fprintf(&sb, "| %s | %s | `%s` | `%s` |\n", command.Risk, command.Job, command.Source, markdownCell(command.Command))
+		}
+		sb.WriteString("\n")
+	}
+
+	writeJobTable(&sb, "## ➕ Added Jobs", d.AddedJobs)
+	writeJobTable(&sb, "## ➖ Removed Jobs", d.RemovedJobs)
+
+	if len(d.ChangedJobs) > 0 {
+		sb.WriteString("## ✏️ Changed Jobs\n\n")
+		for _, change := range d.ChangedJobs {
+			fmt.Fprintf(&sb, "### %s (`%s`)\n\n", change.Job, change.Source)
+			writeChangeLines(&sb, "needs", change.AddedNeeds, change.RemovedNeeds)
+			writeChangeLines(&sb, "image", change.AddedImages, change.RemovedImages)
+			writeChangeLines(&sb, "uses", change.AddedUses, change.RemovedUses)
+			writeChangeLines(&sb, "run", change.AddedCommands, change.RemovedCommands)
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(d.AddedImages) > 0 || len(d.RemovedImages) > 0 {
+		sb.WriteString("## 🐳 Images\n\n")
+		sb.WriteString("| Change | Image | Used By |\n")
+		sb.WriteString("|--------|-------|---------|\n")
+		for _, image := range d.AddedImages {
+			fmt.Fprintf(&sb, "| ➕ | `%s` | %s |\n", image.Image, strings.Join(image.UsedBy, ", "))
+		}
+		for _, image := range d.RemovedImages {
+			fmt.Fprintf(&sb, "| ➖ | `%s` | %s |\n", image.Image, strings.Join(image.UsedBy, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(d.AddedActions) > 0 || len(d.RemovedActions) > 0 {
+		sb.WriteString("## 🔗 Actions\n\n")
+		sb.WriteString("| Change | Uses | Risk | Finding |\n")
+		sb.WriteString("|--------|------|------|---------|\n")
+		for _, action := range d.AddedActions {
+			fmt.Fprintf(&sb, "| ➕ | `%s` | %s | %s |\n", action.Uses, action.Risk, action.Finding)
+		}
+		for _, action := range d.RemovedActions {
+			fmt.Fprintf(&sb, "| ➖ | `%s` | %s | %s |\n", action.Uses, action.Risk, action.Finding)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(d.Metrics) > 0 {
+		sb.WriteString("## 📊 Metrics\n\n")
+		sb.WriteString("| Metric | Before | After | Change |\n")
+		sb.WriteString("|--------|--------|-------|--------|\n")
+		for _, metric := range d.Metrics {
+			change := "-"
+			if metric.Before != nil && metric.After != nil {
+				change = "0"
+				if delta := roundDiffMetric(*metric.After - *metric.Before); delta != 0 {
+					change = fmt.Sprintf("%+g", delta)
+				}
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", metric.Name, formatDiffMetric(metric.Before), formatDiffMetric(metric.After), change)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// writeJobTable renders a list of added or removed jobs
+func writeJobTable(sb *strings.Builder, heading string, jobs []JobRef) {
+	if len(jobs) == 0 {
+		return
+	}
+	sb.WriteString(heading + "\n\n")
+	sb.WriteString("| Tool | Source | Job |\n")
+	sb.WriteString("|------|--------|-----|\n")
+	for _, job := range jobs {
+		fmt.Fprintf(sb, "| %s | `%s` | %s |\n", job.Tool, job.Source, job.Job)
+	}
+	sb.WriteString("\n")
+}
+
+// writeChangeLines renders added and removed items of one kind as a list
+func writeChangeLines(sb *strings.Builder, kind string, added, removed []string) {
+	for _, item := range added {
+		fmt.Fprintf(sb, "- ➕ %s `%s`\n", kind, markdownCell(item))
+	}
+	for _, item := range removed {
+		fmt.Fprintf(sb, "- ➖ %s `%s`\n", kind, markdownCell(item))
+	}
+}
+
+// markdownCell escapes text for use inside a table cell
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// formatDiffMetric renders a metric value, or "-" when it was not measured
+func formatDiffMetric(value *float64) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%g", roundDiffMetric(*value))
+}
+
+// roundDiffMetric rounds a metric to one decimal place
+func roundDiffMetric(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,803 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nichecode/pipeline-analyzer/internal/circleci"
+	"github.com/nichecode/pipeline-analyzer/internal/docker"
+	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
+	"github.com/nichecode/pipeline-analyzer/internal/gotask"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+)
+
+// SchemaVersion is the version of the JSON report schema. Bump the minor
+// version for additive changes and the major version for breaking ones.
+const SchemaVersion = "1.15"
+
+// FileName is the name of the JSON report written to the discovery directory
+const FileName = "report.json"
+
+// Report is the machine-readable form of a full discovery run
+type Report struct {
+	SchemaVersion     string                 `json:"schema_version"`
+	GeneratedAt       time.Time              `json:"generated_at"`
+	ToolVersion       string                 `json:"tool_version,omitempty"`
+	Repository        Repository             `json:"repository"`
+	Tools             []ToolResult           `json:"tools"`
+	CircleCI          *CircleCIReport        `json:"circleci,omitempty"`
+	GoTask            *GoTaskReport          `json:"gotask,omitempty"`
+	GitHubActions     *GitHubActionsReport   `json:"github_actions,omitempty"`
+	Docker            *docker.DockerAnalysis `json:"docker,omitempty"`
+	Pipelines         []*ir.Pipeline         `json:"pipelines,omitempty"`
+	CrossTool         *ir.CrossToolSummary   `json:"cross_tool,omitempty"`
+	MigrationCoverage *ir.MigrationCoverage  `json:"migration_coverage,omitempty"`
+}
+
+// Repository describes the analyzed repository
+type Repository struct {
+	RootPath string `json:"root_path"`
+	GitRepo  bool   `json:"git_repo"`
+}
+
+// ToolResult records the outcome of analyzing one discovered build tool
+type ToolResult struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	ConfigPath string `json:"config_path"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	OutputDir  string `json:"output_dir,omitempty"`
+}
+
+// CircleCIReport is the report section for a CircleCI configuration
+type CircleCIReport struct {
+	ConfigPath       string                  `json:"config_path"`
+	Version          string                  `json:"version"`
+	TotalJobs        int                     `json:"total_jobs"`
+	TotalWorkflows   int                     `json:"total_workflows"`
+	TotalCommands    int                     `json:"total_commands"`
+	Jobs             []CircleCIJob           `json:"jobs"`
+	Workflows        []CircleCIWorkflow      `json:"workflows"`
+	ReusableCommands []CircleCICommand       `json:"reusable_commands"`
+	CommandPatterns  map[string]PatternUsage `json:"command_patterns"`
+	ExecutorUsage    map[string][]string     `json:"executor_usage"`
+	Orbs             []CircleCIOrb           `json:"orbs"`
+}
+
+// CircleCIJob is a single CircleCI job
+type CircleCIJob struct {
+	Name         string   `json:"name"`          // Invocation name, such as deploy-prod
+	Job          string   `json:"job,omitempty"` // Job definition, when invoked under an alias
+	Description  string   `json:"description,omitempty"`
+	Executor     string   `json:"executor,omitempty"`
+	DockerImages []string `json:"docker_images"`
+	Commands     []string `json:"commands"`
+	Dependencies []string `json:"dependencies"`
+	UsageCount   int      `json:"usage_count"`
+}
+
+// CircleCIWorkflow is a single CircleCI workflow
+type CircleCIWorkflow struct {
+	Name     string                `json:"name"`
+	Jobs     []CircleCIWorkflowJob `json:"jobs"`
+	DataFlow CircleCIDataFlow      `json:"data_flow"`
+}
+
+// CircleCIDataFlow is workspace, cache and artifact passing inside a workflow
+type CircleCIDataFlow struct {
+	Edges  []CircleCIDataFlowEdge  `json:"edges"`
+	Issues []CircleCIDataFlowIssue `json:"issues"`
+}
+
+// CircleCIDataFlowEdge links a producing job to a consuming job
+type CircleCIDataFlowEdge struct {
+	Producer string `json:"producer"`
+	Consumer string `json:"consumer"`
+	Kind     string `json:"kind"`
+	Key      string `json:"key"`
+	Ordered  bool   `json:"ordered"`
+}
+
+// CircleCIDataFlowIssue is a consumer not ordered after its producers
+type CircleCIDataFlowIssue struct {
+	Job       string   `json:"job"`
+	Kind      string   `json:"kind"`
+	Key       string   `json:"key"`
+	Producers []string `json:"producers"`
+	Message   string   `json:"message"`
+}
+
+// CircleCIWorkflowJob is a job reference inside a workflow
+type CircleCIWorkflowJob struct {
+	Name     string   `json:"name"`
+	Alias    string   `json:"alias,omitempty"`
+	Requires []string `json:"requires,omitempty"`
+	Context  []string `json:"context,omitempty"`
+}
+
+// CircleCICommand is a reusable CircleCI command
+type CircleCICommand struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Commands    []string `json:"commands"`
+	UsageCount  int      `json:"usage_count"`
+}
+
+// CircleCIOrb is a declared orb and the jobs that use its elements
+type CircleCIOrb struct {
+	Alias     string   `json:"alias"`
+	Ref       string   `json:"ref,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	Resolved  bool     `json:"resolved"`
+	Jobs      []string `json:"jobs"`
+	Commands  []string `json:"commands"`
+	Executors []string `json:"executors"`
+	UsedBy    []string `json:"used_by"`
+}
+
+// PatternUsage counts how often a command pattern appears and where
+type PatternUsage struct {
+	Count int      `json:"count"`
+	Users []string `json:"users"`
+}
+
+// GoTaskReport is the report section for a Taskfile
+type GoTaskReport struct {
+	ConfigPath       string                  `json:"config_path"`
+	Version          string                  `json:"version"`
+	TotalTasks       int                     `json:"total_tasks"`
+	TotalIncludes    int                     `json:"total_includes"`
+	Tasks            []GoTaskTask            `json:"tasks"`
+	Includes         []GoTaskInclude         `json:"includes"`
+	CircularDeps     [][]string              `json:"circular_dependencies"`
+	CriticalPath     []string                `json:"critical_path"`
+	OptimizationTips []OptimizationTip       `json:"optimization_tips"`
+	CommandPatterns  map[string]PatternUsage `json:"command_patterns"`
+	Diagnostics      []GoTaskDiagnostic      `json:"diagnostics"`
+}
+
+// GoTaskDiagnostic is a Taskfile v3 schema finding. File is relative to the
+// repository root.
+type GoTaskDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// GoTaskTask is a single go-task task
+type GoTaskTask struct {
+	Name             string           `json:"name"`
+	Description      string           `json:"description,omitempty"`
+	Type             string           `json:"type"`
+	Commands         []string         `json:"commands"`
+	ResolvedCommands []string         `json:"resolved_commands"`
+	Variables        []GoTaskVariable `json:"variables"`
+	Dependencies     []string         `json:"dependencies"`
+	Calls            []GoTaskCall     `json:"calls"`
+	Sources          []string         `json:"sources,omitempty"`
+	Generates        []string         `json:"generates,omitempty"`
+	Aliases          []string         `json:"aliases,omitempty"`
+	Internal         bool             `json:"internal"`
+	UsageCount       int              `json:"usage_count"`
+}
+
+// GoTaskCall is a typed edge of the task graph: a dep, a sequential task:
+// call in cmds or a deferred call
+type GoTaskCall struct {
+	Task string `json:"task"`
+	Kind string `json:"kind"`
+	Loop bool   `json:"loop"`
+}
+
+// GoTaskVariable is a template variable a task's commands read, with the
+// value go-task resolves it to
+type GoTaskVariable struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Source   string `json:"source"`
+	Symbolic bool   `json:"symbolic"`
+}
+
+// GoTaskInclude is an included Taskfile
+type GoTaskInclude struct {
+	Namespace    string   `json:"namespace"`
+	Path         string   `json:"path"`
+	TaskCount    int      `json:"task_count"`
+	Tasks        []string `json:"tasks"`
+	Parent       string   `json:"parent,omitempty"` // Including include, for nested includes
+	Dir          string   `json:"dir,omitempty"`
+	Optional     bool     `json:"optional"`
+	Flatten      bool     `json:"flatten"`
+	Internal     bool     `json:"internal"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Excludes     []string `json:"excludes,omitempty"`
+	Dependencies []string `json:"dependencies"` // Tasks of other Taskfiles its tasks depend on
+	Error        string   `json:"error,omitempty"`
+}
+
+// OptimizationTip is a go-task optimization suggestion
+type OptimizationTip struct {
+	Type       string `json:"type"`
+	Task       string `json:"task"`
+	Message    string `json:"message"`
+	Severity   string `json:"severity"`
+	Suggestion string `json:"suggestion"`
+}
+
+// GitHubActionsReport is the report section for all GitHub Actions workflows
+type GitHubActionsReport struct {
+	ConfigPath   string                  `json:"config_path"`
+	Workflows    []GitHubActionsWorkflow `json:"workflows"`
+	LocalActions []LocalAction           `json:"local_actions"`
+	SupplyChain  []ActionReference       `json:"supply_chain"`
+}
+
+// ActionReference is a uses: reference classified by publisher and pinning
+type ActionReference struct {
+	Uses      string   `json:"uses"`
+	Name      string   `json:"name"`
+	Ref       string   `json:"ref,omitempty"`
+	Publisher string   `json:"publisher"`
+	RefType   string   `json:"ref_type"`
+	Reusable  bool     `json:"reusable_workflow,omitempty"`
+	Risk      string   `json:"risk"`
+	Finding   string   `json:"finding,omitempty"`
+	UsedBy    []string `json:"used_by"`
+}
+
+// LocalAction is an action defined inside the repository
+type LocalAction struct {
+	Path    string   `json:"path"`
+	Name    string   `json:"name"`
+	Using   string   `json:"using"`
+	Inputs  []string `json:"inputs"`
+	Outputs []string `json:"outputs"`
+	Steps   int      `json:"steps"`
+	UsedBy  []string `json:"used_by"`
+}
+
+// LocalCall is a job's use of a local action or reusable workflow
+type LocalCall struct {
+	Uses       string            `json:"uses"`
+	Kind       string            `json:"kind"`
+	Resolved   bool              `json:"resolved"`
+	Inputs     map[string]string `json:"inputs"`
+	Missing    []string          `json:"missing_inputs"`
+	Outputs    []string          `json:"outputs"`
+	Provenance []string          `json:"provenance,omitempty"`
+}
+
+// GitHubActionsWorkflow is a single workflow file
+type GitHubActionsWorkflow struct {
+	Name            string             `json:"name"`
+	FilePath        string             `json:"file_path"`
+	TotalSteps      int                `json:"total_steps"`
+	Jobs            []GitHubActionsJob `json:"jobs"`
+	ActionUsage     map[string]int     `json:"action_usage"`
+	RunnerUsage     map[string]int     `json:"runner_usage"`
+	ServiceUsage    map[string]int     `json:"service_usage"`
+	Recommendations []string           `json:"recommendations"`
+	Issues          []string           `json:"issues"`
+}
+
+// GitHubActionsJob is a single workflow job
+type GitHubActionsJob struct {
+	Name             string              `json:"name"`
+	Runner           string              `json:"runner"`
+	StepCount        int                 `json:"step_count"`
+	RunCommands      []string            `json:"run_commands"`
+	ActionsUsed      []string            `json:"actions_used"`
+	Dependencies     []string            `json:"dependencies"`
+	EstimatedTime    string              `json:"estimated_time"`
+	CachingEnabled   bool                `json:"caching_enabled"`
+	SecurityIssues   []string            `json:"security_issues"`
+	Recommendations  []string            `json:"recommendations"`
+	Matrix           Matrix              `json:"matrix"`
+	Contexts         map[string][]string `json:"contexts"`
+	ExpressionIssues []string            `json:"expression_issues"`
+	ReusableWorkflow string              `json:"reusable_workflow,omitempty"`
+	LocalCalls       []LocalCall         `json:"local_calls"`
+	Permissions      Permissions         `json:"permissions"`
+}
+
+// Permissions compares the GITHUB_TOKEN scopes a job uses with the ones it is granted
+type Permissions struct {
+	DeclaredAt  string            `json:"declared_at"`
+	Declared    map[string]string `json:"declared,omitempty"`
+	Required    map[string]string `json:"required"`
+	OverGranted []string          `json:"over_granted"`
+	Missing     []string          `json:"missing"`
+	Unknown     []string          `json:"unknown_actions"`
+	Suggested   string            `json:"suggested"`
+}
+
+// Matrix is the expansion of a job's strategy matrix
+type Matrix struct {
+	Size        int                      `json:"size"`
+	MaxParallel int                      `json:"max_parallel,omitempty"`
+	Dynamic     bool                     `json:"dynamic,omitempty"`
+	Instances   []map[string]interface{} `json:"instances"`
+}
+
+// New creates an empty report for a repository
+func New(rootPath string, gitRepo bool, toolVersion string) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now(),
+		ToolVersion:   toolVersion,
+		Repository: Repository{
+			RootPath: rootPath,
+			GitRepo:  gitRepo,
+		},
+		Tools: []ToolResult{},
+	}
+}
+
+// FromCircleCI builds the CircleCI report section from an analysis
+func FromCircleCI(analysis *circleci.Analysis, configPath string) *CircleCIReport {
+	config := analysis.Config
+	section := &CircleCIReport{
+		ConfigPath:       configPath,
+		Version:          config.Version,
+		TotalJobs:        analysis.TotalJobs,
+		TotalWorkflows:   analysis.TotalWorkflows,
+		TotalCommands:    analysis.TotalCommands,
+		Jobs:             []CircleCIJob{},
+		Workflows:        []CircleCIWorkflow{},
+		ReusableCommands: []CircleCICommand{},
+		CommandPatterns:  make(map[string]PatternUsage),
+		ExecutorUsage:    analysis.ExecutorUsage,
+	}
+
+	for _, jobName := range sortedKeys(config.Jobs) {
+		jobAnalysis := circleci.AnalyzeJob(config, jobName, analysis)
+		if jobAnalysis == nil {
+			continue
+		}
+		definition := ""
+		if jobAnalysis.Job != jobAnalysis.Name {
+			definition = jobAnalysis.Job
+		}
+		section.Jobs = append(section.Jobs, CircleCIJob{
+			Name:         jobAnalysis.Name,
+			Job:          definition,
+			Description:  jobAnalysis.Description,
+			Executor:     jobAnalysis.Executor,
+			DockerImages: nonNil(jobAnalysis.DockerImages),
+			Commands:     nonNil(jobAnalysis.Commands),
+			Dependencies: nonNil(jobAnalysis.Dependencies),
+			UsageCount:   jobAnalysis.UsageCount,
+		})
+	}
+
+	for _, workflowName := range sortedKeys(config.Workflows) {
+		workflowAnalysis := circleci.AnalyzeWorkflow(config, workflowName)
+		if workflowAnalysis == nil {
+			continue
+		}
+		workflow := CircleCIWorkflow{
+			Name:     workflowName,
+			Jobs:     []CircleCIWorkflowJob{},
+			DataFlow: CircleCIDataFlow{Edges: []CircleCIDataFlowEdge{}, Issues: []CircleCIDataFlowIssue{}},
+		}
+		for _, job := range workflowAnalysis.Jobs {
+			workflow.Jobs = append(workflow.Jobs, CircleCIWorkflowJob{
+				Name:     job.Name,
+				Alias:    job.Alias,
+				Requires: job.Requires,
+				Context:  job.Context,
+			})
+		}
+		if flow := workflowAnalysis.DataFlow; flow != nil {
+			for _, edge := range flow.Edges {
+				workflow.DataFlow.Edges = append(workflow.DataFlow.Edges, CircleCIDataFlowEdge{
+					Producer: edge.Producer,
+					Consumer: edge.Consumer,
+					Kind:     string(edge.Kind),
+					Key:      edge.Key,
+					Ordered:  edge.Ordered,
+				})
+			}
+			for _, issue := range flow.Issues {
+				workflow.DataFlow.Issues = append(workflow.DataFlow.Issues, CircleCIDataFlowIssue{
+					Job:       issue.Job,
+					Kind:      string(issue.Kind),
+					Key:       issue.Key,
+					Producers: nonNil(issue.Producers),
+					Message:   issue.Message,
+				})
+			}
+		}
+		section.Workflows = append(section.Workflows, workflow)
+	}
+
+	for _, commandName := range sortedKeys(analysis.ReusableCommands) {
+		command := analysis.ReusableCommands[commandName]
+		section.ReusableCommands = append(section.ReusableCommands, CircleCICommand{
+			Name:        command.Name,
+			Description: command.Description,
+			Commands:    nonNil(command.Commands),
+			UsageCount:  command.UsageCount,
+		})
+	}
+
+	for pattern, count := range analysis.CommandPatterns {
+		section.CommandPatterns[pattern] = PatternUsage{Count: count.Count, Users: nonNil(count.Jobs)}
+	}
+
+	section.Orbs = fromCircleCIOrbs(analysis)
+
+	return section
+}
+
+// fromMatrix summarizes a job's matrix expansion
+func fromMatrix(job githubactions.JobAnalysis) Matrix {
+	matrix := Matrix{
+		Size:        job.MatrixSize,
+		MaxParallel: job.MaxParallel,
+		Dynamic:     job.MatrixDynamic,
+		Instances:   []map[string]interface{}{},
+	}
+	for _, instance := range job.MatrixInstances {
+		if len(instance.Values) > 0 {
+			matrix.Instances = append(matrix.Instances, instance.Values)
+		}
+	}
+	return matrix
+}
+
+// fromCircleCIOrbs lists the declared orbs with their resolved elements and users
+func fromCircleCIOrbs(analysis *circleci.Analysis) []CircleCIOrb {
+	config := analysis.Config
+	usages := circleci.AnalyzeOrbUsage(analysis)
+	orbs := []CircleCIOrb{}
+
+	for _, alias := range sortedKeys(config.Orbs) {
+		orb := CircleCIOrb{Alias: alias, UsedBy: []string{}}
+		if resolved, ok := config.ResolvedOrbs[alias]; ok {
+			orb.Ref = resolved.Ref
+			orb.Source = resolved.Source
+			orb.Resolved = resolved.Resolved()
+			orb.Jobs = sortedKeys(resolved.Jobs)
+			orb.Commands = sortedKeys(resolved.Commands)
+			orb.Executors = sortedKeys(resolved.Executors)
+		} else if ref, ok := config.Orbs[alias].(string); ok {
+			orb.Ref = ref
+		}
+		orb.Jobs = nonNil(orb.Jobs)
+		orb.Commands = nonNil(orb.Commands)
+		orb.Executors = nonNil(orb.Executors)
+
+		prefix := alias + "/"
+		for _, usage := range usages {
+			elements := append([]string{usage.Executor}, usage.Commands...)
+			elements = append(elements, usage.Unresolved...)
+			if usage.OrbJob {
+				elements = append(elements, usage.Job)
+			}
+			for _, element := range elements {
+				if strings.HasPrefix(element, prefix) {
+					orb.UsedBy = append(orb.UsedBy, usage.Job)
+					break
+				}
+			}
+		}
+
+		orbs = append(orbs, orb)
+	}
+
+	return orbs
+}
+
+// FromGoTask builds the go-task report section from an analysis
+func FromGoTask(analysis *gotask.Analysis, configPath string) *GoTaskReport {
+	taskfile := analysis.Taskfile
+	section := &GoTaskReport{
+		ConfigPath:       configPath,
+		Version:          taskfile.Version,
+		TotalTasks:       analysis.TotalTasks,
+		TotalIncludes:    analysis.TotalIncludes,
+		Tasks:            []GoTaskTask{},
+		Includes:         []GoTaskInclude{},
+		CircularDeps:     analysis.CircularDeps,
+		CriticalPath:     nonNil(analysis.CriticalPath),
+		OptimizationTips: []OptimizationTip{},
+		CommandPatterns:  make(map[string]PatternUsage),
+		Diagnostics:      []GoTaskDiagnostic{},
+	}
+	if section.CircularDeps == nil {
+		section.CircularDeps = [][]string{}
+	}
+
+	// Root tasks first, then the namespaced tasks of each include
+	var tasks []*gotask.TaskAnalysis
+	for _, taskName := range sortedKeys(taskfile.Tasks) {
+		if taskAnalysis := gotask.AnalyzeTask(taskfile, taskName, analysis); taskAnalysis != nil {
+			tasks = append(tasks, taskAnalysis)
+		}
+	}
+	for _, key := range sortedKeys(analysis.IncludeAnalysis) {
+		include := analysis.IncludeAnalysis[key]
+		for _, localName := range sortedKeys(include.Tasks) {
+			tasks = append(tasks, include.Tasks[localName])
+		}
+	}
+	for _, taskAnalysis := range tasks {
+		calls := []GoTaskCall{}
+		for _, call := range taskAnalysis.Calls {
+			calls = append(calls, GoTaskCall{Task: call.Task, Kind: call.Kind, Loop: call.Loop})
+		}
+		variables := []GoTaskVariable{}
+		for _, variable := range taskAnalysis.ResolvedVars {
+			variables = append(variables, GoTaskVariable{
+				Name:     variable.Name,
+				Value:    variable.Value,
+				Source:   variable.Source,
+				Symbolic: variable.Symbolic,
+			})
+		}
+		section.Tasks = append(section.Tasks, GoTaskTask{
+			Name:             taskAnalysis.Name,
+			Description:      taskAnalysis.Description,
+			Type:             taskAnalysis.Type,
+			Commands:         nonNil(taskAnalysis.Commands),
+			ResolvedCommands: nonNil(taskAnalysis.ResolvedCommands),
+			Variables:        variables,
+			Dependencies:     nonNil(taskAnalysis.Dependencies),
+			Calls:            calls,
+			Sources:          taskAnalysis.Sources,
+			Generates:        taskAnalysis.Generates,
+			Aliases:          taskAnalysis.Aliases,
+			Internal:         taskAnalysis.IsInternal,
+			UsageCount:       taskAnalysis.UsageCount,
+		})
+	}
+
+	for _, namespace := range sortedKeys(analysis.IncludeAnalysis) {
+		include := analysis.IncludeAnalysis[namespace]
+		section.Includes = append(section.Includes, GoTaskInclude{
+			Namespace:    namespace,
+			Path:         include.Path,
+			TaskCount:    include.TaskCount,
+			Tasks:        sortedKeys(include.Tasks),
+			Parent:       include.Parent,
+			Dir:          include.Dir,
+			Optional:     include.Optional,
+			Flatten:      include.Flatten,
+			Internal:     include.Internal,
+			Aliases:      include.Aliases,
+			Excludes:     include.Excludes,
+			Dependencies: nonNil(include.Dependencies),
+			Error:        include.Error,
+		})
+	}
+
+	for _, tip := range analysis.OptimizationTips {
+		section.OptimizationTips = append(section.OptimizationTips, OptimizationTip{
+			Type:       tip.Type,
+			Task:       tip.Task,
+			Message:    tip.Message,
+			Severity:   tip.Severity,
+			Suggestion: tip.Suggestion,
+		})
+	}
+
+	for pattern, count := range analysis.CommandPatterns {
+		section.CommandPatterns[pattern] = PatternUsage{Count: count.Count, Users: nonNil(count.Tasks)}
+	}
+
+	// Diagnostic files are relative to the root Taskfile; make them relative to the repository
+	root := ""
+	if analysis.Project != nil {
+		root = filepath.Dir(analysis.Project.Root)
+	}
+	for _, diagnostic := range analysis.Diagnostics {
+		if root != "" {
+			diagnostic = diagnostic.RelativeTo(root)
+			diagnostic.File = filepath.ToSlash(filepath.Join(filepath.Dir(configPath), diagnostic.File))
+		}
+		section.Diagnostics = append(section.Diagnostics, GoTaskDiagnostic{
+			File:     diagnostic.File,
+			Line:     diagnostic.Line,
+			Column:   diagnostic.Column,
+			Severity: diagnostic.Severity,
+			Rule:     diagnostic.Rule,
+			Message:  diagnostic.Message,
+		})
+	}
+
+	return section
+}
+
+// FromGitHubActions builds the GitHub Actions report section from workflow results
+func FromGitHubActions(results []*githubactions.AnalysisResult, configPath string) *GitHubActionsReport {
+	section := &GitHubActionsReport{
+		ConfigPath: configPath,
+		Workflows:  []GitHubActionsWorkflow{},
+	}
+
+	for _, result := range results {
+		name := filepath.Base(result.FilePath)
+		if result.Config != nil && result.Config.Name != "" {
+			name = result.Config.Name
+		}
+
+		workflow := GitHubActionsWorkflow{
+			Name:            name,
+			FilePath:        result.FilePath,
+			TotalSteps:      result.TotalSteps,
+			Jobs:            []GitHubActionsJob{},
+			ActionUsage:     result.ActionUsage,
+			RunnerUsage:     result.RunnerUsage,
+			ServiceUsage:    result.ServiceUsage,
+			Recommendations: nonNil(result.Recommendations),
+			Issues:          nonNil(result.Issues),
+		}
+
+		jobs := append([]githubactions.JobAnalysis(nil), result.Jobs...)
+		sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+		for _, job := range jobs {
+			workflow.Jobs = append(workflow.Jobs, GitHubActionsJob{
+				Name:             job.Name,
+				Runner:           job.Runner,
+				StepCount:        job.StepCount,
+				RunCommands:      nonNil(job.RunCommands),
+				ActionsUsed:      nonNil(job.ActionsUsed),
+				Dependencies:     nonNil(job.Dependencies),
+				EstimatedTime:    job.EstimatedTime,
+				CachingEnabled:   job.CachingEnabled,
+				SecurityIssues:   nonNil(job.SecurityIssues),
+				Recommendations:  nonNil(job.Recommendations),
+				Matrix:           fromMatrix(job),
+				Contexts:         job.Contexts,
+				ExpressionIssues: nonNil(job.ExpressionIssues),
+				ReusableWorkflow: job.ReusableWorkflow,
+				LocalCalls:       fromLocalCalls(job.LocalCalls),
+				Permissions: Permissions{
+					DeclaredAt:  job.Permissions.DeclaredAt,
+					Declared:    job.Permissions.Declared,
+					Required:    job.Permissions.Required,
+					OverGranted: nonNil(job.Permissions.OverGranted),
+					Missing:     nonNil(job.Permissions.Missing),
+					Unknown:     nonNil(job.Permissions.Unknown),
+					Suggested:   job.Permissions.Suggested,
+				},
+			})
+		}
+
+		section.Workflows = append(section.Workflows, workflow)
+	}
+
+	sort.Slice(section.Workflows, func(i, j int) bool {
+		return section.Workflows[i].FilePath < section.Workflows[j].FilePath
+	})
+
+	section.LocalActions = []LocalAction{}
+	for _, action := range githubactions.CollectLocalActions(results) {
+		section.LocalActions = append(section.LocalActions, LocalAction{
+			Path:    action.Path,
+			Name:    action.Name,
+			Using:   action.Using,
+			Inputs:  sortedKeys(action.Inputs),
+			Outputs: sortedKeys(action.Outputs),
+			Steps:   len(action.Steps),
+			UsedBy:  nonNil(action.UsedBy),
+		})
+	}
+
+	section.SupplyChain = []ActionReference{}
+	for _, ref := range githubactions.AuditSupplyChain(results) {
+		section.SupplyChain = append(section.SupplyChain, ActionReference{
+			Uses:      ref.Uses,
+			Name:      ref.Name,
+			Ref:       ref.Ref,
+			Publisher: ref.Publisher,
+			RefType:   ref.RefType,
+			Reusable:  ref.Reusable,
+			Risk:      ref.Risk,
+			Finding:   ref.Finding,
+			UsedBy:    nonNil(ref.UsedBy),
+		})
+	}
+
+	return section
+}
+
+// fromLocalCalls converts a job's local calls
+func fromLocalCalls(calls []githubactions.LocalCall) []LocalCall {
+	converted := []LocalCall{}
+	for _, call := range calls {
+		inputs := call.Inputs
+		if inputs == nil {
+			inputs = map[string]string{}
+		}
+		converted = append(converted, LocalCall{
+			Uses:       call.Uses,
+			Kind:       call.Kind,
+			Resolved:   call.Resolved,
+			Inputs:     inputs,
+			Missing:    nonNil(call.Missing),
+			Outputs:    nonNil(call.Outputs),
+			Provenance: call.Provenance,
+		})
+	}
+	return converted
+}
+
+// Encode writes the report as indented JSON
+func Encode(w io.Writer, r *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes the report as indented JSON to the given path
+func WriteFile(path string, r *Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer file.Close()
+
+	return Encode(file, r)
+}
+
+// Decode reads a report encoded as JSON, rejecting other major schema versions
+func Decode(r io.Reader) (*Report, error) {
+	var decoded Report
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode report: %w", err)
+	}
+	if majorVersion(decoded.SchemaVersion) != majorVersion(SchemaVersion) {
+		return nil, fmt.Errorf("unsupported report schema version %q (expected %s.x)", decoded.SchemaVersion, majorVersion(SchemaVersion))
+	}
+	return &decoded, nil
+}
+
+// ReadFile reads a report.json written by WriteFile
+func ReadFile(path string) (*Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open report file: %w", err)
+	}
+	defer file.Close()
+
+	return Decode(file)
+}
+
+// majorVersion returns the major part of a schema version
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
+
+// nonNil returns an empty slice instead of nil so arrays encode as []
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+// sortedKeys returns the keys of a string-keyed map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -21,8 +21,10 @@ type MermaidNode struct {
 }
 
 type MermaidEdge struct {
-	From string
-	To   string
+	From   string
+	To     string
+	Label  string
+	Dotted bool
 }
 
 // Generate creates the Mermaid diagram syntax
@@ -63,7 +65,7 @@ func (d *MermaidDiagram) Generate() string {
 	
 	// Add edges
 	for _, edge := range d.Edges {
-		sb.WriteString(fmt.Sprintf("    %s --> %s\n", edge.From, edge.To))
+		sb.WriteString(fmt.Sprintf("    %s %s %s\n", edge.From, edge.Arrow(), edge.To))
 	}
 	
 	// Add styling
@@ -87,6 +89,18 @@ func (d *MermaidDiagram) Generate() string {
 	return sb.String()
 }
 
+// Arrow returns the Mermaid link syntax for the edge, such as -.->|label|
+func (e MermaidEdge) Arrow() string {
+	arrow := "-->"
+	if e.Dotted {
+		arrow = "-.->"
+	}
+	if e.Label != "" {
+		arrow += "|" + e.Label + "|"
+	}
+	return arrow
+}
+
 // CleanNodeID creates a valid Mermaid node ID
 func CleanNodeID(name string) string {
 	// Replace invalid characters with underscores
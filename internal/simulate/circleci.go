@@ -0,0 +1,256 @@
+package simulate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/circleci"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+)
+
+// CircleCI simulates which workflows and jobs of a CircleCI configuration run
+// for a push. params override pipeline parameter defaults.
+func CircleCI(config *circleci.Config, source string, ref Ref, params map[string]interface{}) []WorkflowRun {
+	scope := pipelineScope(config, ref, params)
+
+	workflowNames := circleci.GetAllWorkflowNames(config)
+	sort.Strings(workflowNames)
+
+	var runs []WorkflowRun
+	for _, workflowName := range workflowNames {
+		workflow := config.Workflows[workflowName]
+		run := WorkflowRun{
+			Tool:   string(ir.ToolCircleCI),
+			Source: source,
+			Name:   workflowName,
+			Runs:   true,
+		}
+
+		switch {
+		case isScheduled(workflow):
+			run.Runs = false
+			run.Reason = "scheduled workflow, not triggered by pushes"
+		case workflow.When != nil && !truthy(evaluateCondition(workflow.When, scope)):
+			run.Runs = false
+			run.Reason = fmt.Sprintf("when: %s is false", describeCondition(workflow.When))
+		case workflow.Unless != nil && truthy(evaluateCondition(workflow.Unless, scope)):
+			run.Runs = false
+			run.Reason = fmt.Sprintf("unless: %s is true", describeCondition(workflow.Unless))
+		}
+
+		var jobs []JobRun
+		for _, wfJob := range circleci.ExtractWorkflowJobs(workflow) {
+			// Jobs invoked twice under name: aliases are separate runs
+			job := JobRun{Name: wfJob.InvocationName(), Requires: wfJob.Requires, Runs: run.Runs}
+			if wfJob.Alias != "" {
+				job.Job = wfJob.Name
+			}
+			if run.Runs {
+				job.Runs, job.Reason = evaluateJobFilters(wfJob.Filters, ref)
+			}
+			jobs = append(jobs, job)
+		}
+		run.Jobs = orderJobs(jobs, skipIfDependencySkipped)
+
+		if run.Runs && len(run.RunningJobs()) == 0 {
+			run.Runs = false
+			run.Reason = fmt.Sprintf("no job matches %s", ref)
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs
+}
+
+// pipelineScope builds the << pipeline.* >> values visible to when/unless
+func pipelineScope(config *circleci.Config, ref Ref, params map[string]interface{}) circleci.ParameterScope {
+	values := circleci.PipelineParameterValues(config)
+	for name, value := range params {
+		values[name] = value
+	}
+
+	scope := circleci.NewParameterScope(nil, values)
+	scope["pipeline.git.branch"] = ref.Branch
+	scope["pipeline.git.tag"] = ref.Tag
+	scope["pipeline.trigger_source"] = "webhook"
+	return scope
+}
+
+// isScheduled reports whether a workflow only runs from scheduled triggers
+func isScheduled(workflow circleci.Workflow) bool {
+	for _, triggerInterface := range workflow.Triggers {
+		if trigger, ok := triggerInterface.(map[string]interface{}); ok {
+			if _, scheduled := trigger["schedule"]; scheduled {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluateJobFilters applies a workflow job's branch and tag filters. CircleCI
+// runs jobs for every branch by default but only for tags a filter accepts.
+func evaluateJobFilters(filters map[string]interface{}, ref Ref) (bool, string) {
+	if ref.IsTag() {
+		tags, ok := filters["tags"].(map[string]interface{})
+		if !ok {
+			return false, "no filters.tags, so the job does not run for tags"
+		}
+		return evaluateFilter(tags, ref.Tag, "tag", "filters.tags")
+	}
+
+	branches, ok := filters["branches"].(map[string]interface{})
+	if !ok {
+		return true, ""
+	}
+	return evaluateFilter(branches, ref.Branch, "branch", "filters.branches")
+}
+
+// evaluateFilter applies an only/ignore filter. ignore takes precedence over only.
+func evaluateFilter(filter map[string]interface{}, value, kind, field string) (bool, string) {
+	if ignore := filterPatterns(filter["ignore"]); len(ignore) > 0 {
+		if pattern, matched := matchAny(ignore, value); matched {
+			return false, fmt.Sprintf("%s %s matches %s.ignore %s", kind, value, field, pattern)
+		}
+	}
+
+	if only := filterPatterns(filter["only"]); len(only) > 0 {
+		if _, matched := matchAny(only, value); !matched {
+			return false, fmt.Sprintf("%s %s does not match %s.only [%s]", kind, value, field, strings.Join(only, ", "))
+		}
+	}
+
+	return true, ""
+}
+
+// filterPatterns reads a filter value given as a string or list
+func filterPatterns(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var patterns []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				patterns = append(patterns, str)
+			}
+		}
+		return patterns
+	}
+	return nil
+}
+
+// matchAny returns the first pattern matching the value. Patterns wrapped in
+// slashes are regular expressions that must match the whole value.
+func matchAny(patterns []string, value string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchFilter(pattern, value) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// matchFilter matches a single CircleCI filter pattern
+func matchFilter(pattern, value string) bool {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("^(?:" + pattern[1:len(pattern)-1] + ")$")
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value)
+	}
+	return pattern == value
+}
+
+// evaluateCondition evaluates a CircleCI logic statement
+func evaluateCondition(condition interface{}, scope circleci.ParameterScope) interface{} {
+	switch c := circleci.Interpolate(condition, scope).(type) {
+	case map[string]interface{}:
+		if nested, ok := c["condition"]; ok && len(c) == 1 {
+			return evaluateCondition(nested, scope)
+		}
+		if operands, ok := c["and"].([]interface{}); ok {
+			for _, operand := range operands {
+				if !truthy(evaluateCondition(operand, scope)) {
+					return false
+				}
+			}
+			return len(operands) > 0
+		}
+		if operands, ok := c["or"].([]interface{}); ok {
+			for _, operand := range operands {
+				if truthy(evaluateCondition(operand, scope)) {
+					return true
+				}
+			}
+			return false
+		}
+		if operand, ok := c["not"]; ok {
+			return !truthy(evaluateCondition(operand, scope))
+		}
+		if operands, ok := c["equal"].([]interface{}); ok {
+			if len(operands) == 0 {
+				return false
+			}
+			first := fmt.Sprintf("%v", evaluateCondition(operands[0], scope))
+			for _, operand := range operands[1:] {
+				if fmt.Sprintf("%v", evaluateCondition(operand, scope)) != first {
+					return false
+				}
+			}
+			return true
+		}
+		if match, ok := c["matches"].(map[string]interface{}); ok {
+			pattern, _ := match["pattern"].(string)
+			value := fmt.Sprintf("%v", match["value"])
+			pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			return err == nil && re.MatchString(value)
+		}
+		return false
+	default:
+		return c
+	}
+}
+
+// truthy applies CircleCI's truthiness rules: false, null, 0, NaN and empty
+// strings are false
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case float64:
+		return v != 0 && v == v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// describeCondition renders a condition for display
+func describeCondition(condition interface{}) string {
+	switch c := condition.(type) {
+	case string:
+		return c
+	case map[string]interface{}:
+		var keys []string
+		for key := range c {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, ", ") + " condition"
+	}
+	return fmt.Sprintf("%v", condition)
+}
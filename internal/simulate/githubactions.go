@@ -0,0 +1,301 @@
+package simulate
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
+	"github.com/nichecode/pipeline-analyzer/internal/ir"
+)
+
+// GitHubActions simulates whether a workflow runs for a push and which of its jobs run
+func GitHubActions(workflow *githubactions.Workflow, source string, ref Ref) WorkflowRun {
+	run := WorkflowRun{
+		Tool:   string(ir.ToolGitHubActions),
+		Source: source,
+		Name:   workflow.Name,
+	}
+	if run.Name == "" {
+		run.Name = filepath.Base(source)
+	}
+
+	run.Runs, run.Reason = evaluatePushTrigger(workflow.On, ref)
+
+	parser := githubactions.NewParser()
+	jobNames := make([]string, 0, len(workflow.Jobs))
+	for jobName := range workflow.Jobs {
+		jobNames = append(jobNames, jobName)
+	}
+	sort.Strings(jobNames)
+
+	var jobs []JobRun
+	for _, jobName := range jobNames {
+		job := workflow.Jobs[jobName]
+		jobs = append(jobs, JobRun{Name: jobName, Requires: parser.GetJobDependencies(job), Runs: run.Runs})
+	}
+	run.Jobs = orderJobs(jobs, func(job JobRun, index map[string]int, ordered []JobRun) JobRun {
+		return evaluateJobCondition(job, workflow.Jobs[job.Name].If, workflow, ref, index, ordered)
+	})
+
+	return run
+}
+
+// evaluateJobCondition applies a job's if: condition under the push event.
+// Conditions without a status check function only apply once every needed
+// job runs; conditions using always(), failure() and friends decide alone.
+func evaluateJobCondition(job JobRun, condition string, workflow *githubactions.Workflow, ref Ref, index map[string]int, ordered []JobRun) JobRun {
+	if !job.Runs || condition == "" {
+		return skipIfDependencySkipped(job, index, ordered)
+	}
+
+	node, err := githubactions.ParseExpression(githubactions.ConditionExpression(condition))
+	if err != nil {
+		job = skipIfDependencySkipped(job, index, ordered)
+		if job.Runs {
+			job.Reason = fmt.Sprintf("if: %s not evaluated (%v)", condition, err)
+		}
+		return job
+	}
+
+	status := "success"
+	if !githubactions.UsesStatusFunction(node) {
+		if job = skipIfDependencySkipped(job, index, ordered); !job.Runs {
+			return job
+		}
+	} else if skipped := skipIfDependencySkipped(job, index, ordered); !skipped.Runs {
+		status = "skipped"
+	}
+
+	ctx := pushContext(workflow, ref, job.Requires, ordered)
+	ctx.Status = status
+	value, err := githubactions.EvaluateCondition(condition, ctx)
+	switch {
+	case err != nil:
+		job.Reason = fmt.Sprintf("if: %s not evaluated (%v)", condition, err)
+	case githubactions.IsUnknown(value):
+		job.Reason = fmt.Sprintf("if: %s depends on values only known at run time", condition)
+	case value == false:
+		job.Runs = false
+		job.Reason = fmt.Sprintf("if: %s is false", condition)
+	default:
+		job.Reason = fmt.Sprintf("if: %s is true", condition)
+	}
+	return job
+}
+
+// pushContext builds the expression contexts known for a push of ref.
+// Secrets, variables and job outputs stay unknown.
+func pushContext(workflow *githubactions.Workflow, ref Ref, needs []string, ordered []JobRun) *githubactions.EvaluationContext {
+	refName, refType, fullRef := ref.Branch, "branch", "refs/heads/"+ref.Branch
+	if ref.IsTag() {
+		refName, refType, fullRef = ref.Tag, "tag", "refs/tags/"+ref.Tag
+	}
+
+	outcomes := make(map[string]bool, len(ordered))
+	for _, done := range ordered {
+		outcomes[done.Name] = done.Runs
+	}
+	needsContext := make(map[string]interface{}, len(needs))
+	for _, dep := range needs {
+		result := "skipped"
+		if outcomes[dep] {
+			result = "success"
+		}
+		needsContext[dep] = map[string]interface{}{"result": result}
+	}
+
+	env := make(map[string]interface{}, len(workflow.Env))
+	for name, value := range workflow.Env {
+		env[name] = value
+	}
+
+	return &githubactions.EvaluationContext{
+		Contexts: map[string]interface{}{
+			"github": map[string]interface{}{
+				"event_name": "push",
+				"ref":        fullRef,
+				"ref_name":   refName,
+				"ref_type":   refType,
+				"head_ref":   "",
+				"base_ref":   "",
+				"event":      map[string]interface{}{"ref": fullRef},
+			},
+			"needs": needsContext,
+			"env":   env,
+		},
+	}
+}
+
+// evaluatePushTrigger decides whether the workflow's on: configuration
+// triggers for a push of the ref
+func evaluatePushTrigger(on interface{}, ref Ref) (bool, string) {
+	var push interface{}
+	triggered := false
+
+	switch events := on.(type) {
+	case string:
+		triggered = events == "push"
+	case []interface{}:
+		for _, event := range events {
+			if event == "push" {
+				triggered = true
+			}
+		}
+	case map[string]interface{}:
+		push, triggered = events["push"]
+	}
+
+	if !triggered {
+		return false, fmt.Sprintf("not triggered by push (on: %s)", describeEvents(on))
+	}
+
+	filters, ok := push.(map[string]interface{})
+	if !ok || len(filters) == 0 {
+		return true, ""
+	}
+
+	_, hasBranches := filters["branches"]
+	_, hasBranchesIgnore := filters["branches-ignore"]
+	_, hasTags := filters["tags"]
+	_, hasTagsIgnore := filters["tags-ignore"]
+	refFiltered := hasBranches || hasBranchesIgnore || hasTags || hasTagsIgnore
+
+	// Defining only branch or only tag filters excludes the other kind of ref
+	if ref.IsTag() {
+		switch {
+		case hasTags:
+			if !matchGlobList(filterPatterns(filters["tags"]), ref.Tag) {
+				return false, fmt.Sprintf("tag %s does not match on.push.tags", ref.Tag)
+			}
+		case hasTagsIgnore:
+			if matchGlobList(filterPatterns(filters["tags-ignore"]), ref.Tag) {
+				return false, fmt.Sprintf("tag %s matches on.push.tags-ignore", ref.Tag)
+			}
+		case refFiltered:
+			return false, "on.push only filters branches, so tag pushes do not trigger"
+		}
+		// Path filters are not evaluated for tag pushes
+		return true, ""
+	}
+
+	switch {
+	case hasBranches:
+		if !matchGlobList(filterPatterns(filters["branches"]), ref.Branch) {
+			return false, fmt.Sprintf("branch %s does not match on.push.branches", ref.Branch)
+		}
+	case hasBranchesIgnore:
+		if matchGlobList(filterPatterns(filters["branches-ignore"]), ref.Branch) {
+			return false, fmt.Sprintf("branch %s matches on.push.branches-ignore", ref.Branch)
+		}
+	case refFiltered:
+		return false, "on.push only filters tags, so branch pushes do not trigger"
+	}
+
+	return evaluatePathFilters(filters, ref)
+}
+
+// evaluatePathFilters applies on.push.paths and paths-ignore to the changed files
+func evaluatePathFilters(filters map[string]interface{}, ref Ref) (bool, string) {
+	paths, hasPaths := filters["paths"]
+	pathsIgnore, hasPathsIgnore := filters["paths-ignore"]
+	if !hasPaths && !hasPathsIgnore {
+		return true, ""
+	}
+	if len(ref.ChangedFiles) == 0 {
+		return true, "paths filter assumed to match (pass --changed to evaluate it)"
+	}
+
+	for _, file := range ref.ChangedFiles {
+		switch {
+		case hasPaths && matchGlobList(filterPatterns(paths), file):
+			return true, fmt.Sprintf("%s matches on.push.paths", file)
+		case hasPathsIgnore && !hasPaths && !matchGlobList(filterPatterns(pathsIgnore), file):
+			return true, fmt.Sprintf("%s is not in on.push.paths-ignore", file)
+		}
+	}
+
+	if hasPaths {
+		return false, "no changed file matches on.push.paths"
+	}
+	return false, "every changed file matches on.push.paths-ignore"
+}
+
+// matchGlobList evaluates GitHub filter patterns in order; a later pattern
+// prefixed with ! excludes values an earlier pattern included
+func matchGlobList(patterns []string, value string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			if matched && globRegex(pattern[1:]).MatchString(value) {
+				matched = false
+			}
+			continue
+		}
+		if globRegex(pattern).MatchString(value) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// globRegex converts a GitHub filter pattern into a regular expression.
+// * matches within a path segment, ** across segments, and ?, + and [...]
+// keep their regular expression meaning.
+func globRegex(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?' || c == '+':
+			sb.WriteByte(c)
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			sb.WriteString(pattern[i : i+end+1])
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+	return re
+}
+
+// describeEvents lists the events of an on: value
+func describeEvents(on interface{}) string {
+	switch events := on.(type) {
+	case string:
+		return events
+	case []interface{}:
+		var names []string
+		for _, event := range events {
+			names = append(names, fmt.Sprintf("%v", event))
+		}
+		return strings.Join(names, ", ")
+	case map[string]interface{}:
+		var names []string
+		for name := range events {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ", ")
+	}
+	return "none"
+}
@@ -0,0 +1,108 @@
+package simulate
+
+import (
+	"fmt"
+	"sort"
+)
+
+// jobGate decides whether a job runs once every job it requires is ordered
+type jobGate func(job JobRun, index map[string]int, ordered []JobRun) JobRun
+
+// orderJobs sorts jobs into dependency order, keeping the declared order among
+// jobs that are ready at the same time, and lets gate propagate skipped
+// dependencies. Jobs are keyed by Name, their invocation name.
+func orderJobs(jobs []JobRun, gate jobGate) []JobRun {
+	index := make(map[string]int, len(jobs))
+	for i, job := range jobs {
+		index[job.Name] = i
+	}
+
+	placed := make(map[string]bool, len(jobs))
+	stages := make(map[string]int, len(jobs))
+	var ordered []JobRun
+
+	for len(ordered) < len(jobs) {
+		progress := false
+		for _, job := range jobs {
+			if placed[job.Name] || !dependenciesPlaced(job, index, placed) {
+				continue
+			}
+
+			job.Stage = 1
+			for _, dep := range job.Requires {
+				if _, known := index[dep]; !known {
+					continue
+				}
+				if stages[dep]+1 > job.Stage {
+					job.Stage = stages[dep] + 1
+				}
+			}
+			job = gate(job, index, ordered)
+
+			placed[job.Name] = true
+			stages[job.Name] = job.Stage
+			ordered = append(ordered, job)
+			progress = true
+		}
+
+		if !progress {
+			// Circular requirements: append the rest as they are declared
+			sortByStage(ordered)
+			for _, job := range jobs {
+				if !placed[job.Name] {
+					job.Runs = false
+					job.Reason = "part of a dependency cycle"
+					placed[job.Name] = true
+					ordered = append(ordered, job)
+				}
+			}
+			return ordered
+		}
+	}
+
+	sortByStage(ordered)
+	return ordered
+}
+
+// sortByStage groups jobs by stage, keeping dependency order within a stage
+func sortByStage(jobs []JobRun) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].Stage < jobs[j].Stage
+	})
+}
+
+// dependenciesPlaced reports whether every known dependency is already ordered
+func dependenciesPlaced(job JobRun, index map[string]int, placed map[string]bool) bool {
+	for _, dep := range job.Requires {
+		if _, known := index[dep]; known && !placed[dep] {
+			return false
+		}
+	}
+	return true
+}
+
+// skipIfDependencySkipped marks a job skipped when a job it requires does not run
+func skipIfDependencySkipped(job JobRun, index map[string]int, ordered []JobRun) JobRun {
+	if !job.Runs {
+		return job
+	}
+
+	outcome := make(map[string]bool, len(ordered))
+	for _, done := range ordered {
+		outcome[done.Name] = done.Runs
+	}
+
+	for _, dep := range job.Requires {
+		if _, known := index[dep]; !known {
+			job.Runs = false
+			job.Reason = fmt.Sprintf("requires %s, which is not in this workflow", dep)
+			return job
+		}
+		if !outcome[dep] {
+			job.Runs = false
+			job.Reason = fmt.Sprintf("requires %s, which does not run", dep)
+			return job
+		}
+	}
+	return job
+}
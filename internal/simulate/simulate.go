@@ -0,0 +1,116 @@
+package simulate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nichecode/pipeline-analyzer/internal/circleci"
+	"github.com/nichecode/pipeline-analyzer/internal/discovery"
+	"github.com/nichecode/pipeline-analyzer/internal/githubactions"
+)
+
+// Repository simulates every CircleCI and GitHub Actions workflow discovered in a repository
+func Repository(repo *discovery.Repository, ref Ref, params map[string]interface{}) (*Result, error) {
+	result := &Result{Ref: ref, Workflows: []WorkflowRun{}}
+
+	for _, tool := range repo.BuildTools {
+		configPath := filepath.Join(repo.RootPath, tool.ConfigPath)
+
+		switch tool.Type {
+		case "circleci":
+			config, err := circleci.ParseConfig(configPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse CircleCI config: %w", err)
+			}
+			source := relativePath(repo.RootPath, configPath)
+			result.Workflows = append(result.Workflows, CircleCI(config, source, ref, params)...)
+
+		case "github-actions":
+			parser := githubactions.NewParser()
+			workflows, err := parser.ParseWorkflowsDirectory(configPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse GitHub Actions workflows: %w", err)
+			}
+
+			fileNames := make([]string, 0, len(workflows))
+			for fileName := range workflows {
+				fileNames = append(fileNames, fileName)
+			}
+			sort.Strings(fileNames)
+
+			for _, fileName := range fileNames {
+				source := relativePath(repo.RootPath, filepath.Join(configPath, fileName))
+				result.Workflows = append(result.Workflows, GitHubActions(workflows[fileName], source, ref))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// relativePath returns path relative to the repository root with forward slashes
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// FormatText renders a simulation result for the terminal
+func FormatText(result *Result) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("🔀 Push to %s\n\n", result.Ref))
+
+	if len(result.Workflows) == 0 {
+		sb.WriteString("   No CircleCI or GitHub Actions workflows found\n\n")
+		return sb.String()
+	}
+
+	running := 0
+	for _, workflow := range result.Workflows {
+		if !workflow.Runs {
+			continue
+		}
+		running++
+		sb.WriteString(fmt.Sprintf("✅ %s: %s (%s)\n", workflow.Tool, workflow.Name, workflow.Source))
+		if workflow.Reason != "" {
+			sb.WriteString(fmt.Sprintf("   ℹ️  %s\n", workflow.Reason))
+		}
+		for _, job := range workflow.Jobs {
+			icon := "✅"
+			if !job.Runs {
+				icon = "⏭️ "
+			}
+			line := fmt.Sprintf("   [%d] %s %s", job.Stage, icon, job.Name)
+			if job.Job != "" {
+				line += fmt.Sprintf(" [job %s]", job.Job)
+			}
+			if len(job.Requires) > 0 {
+				line += fmt.Sprintf(" (requires: %s)", strings.Join(job.Requires, ", "))
+			}
+			if job.Reason != "" {
+				line += fmt.Sprintf(" — %s", job.Reason)
+			}
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if running < len(result.Workflows) {
+		sb.WriteString("⏭️  Not triggered:\n")
+		for _, workflow := range result.Workflows {
+			if !workflow.Runs {
+				sb.WriteString(fmt.Sprintf("   - %s: %s (%s) — %s\n", workflow.Tool, workflow.Name, workflow.Source, workflow.Reason))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("📊 %d of %d workflows run\n\n", running, len(result.Workflows)))
+
+	return sb.String()
+}
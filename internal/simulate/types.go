@@ -0,0 +1,60 @@
+package simulate
+
+import "fmt"
+
+// Ref is the git push being simulated. A tag push has no branch.
+type Ref struct {
+	Branch       string   `json:"branch,omitempty"`
+	Tag          string   `json:"tag,omitempty"`
+	ChangedFiles []string `json:"changed_files,omitempty"` // Files changed by the push, for paths filters
+}
+
+// IsTag reports whether the ref is a tag push
+func (r Ref) IsTag() bool {
+	return r.Tag != ""
+}
+
+// String describes the ref
+func (r Ref) String() string {
+	if r.IsTag() {
+		return fmt.Sprintf("tag %s", r.Tag)
+	}
+	return fmt.Sprintf("branch %s", r.Branch)
+}
+
+// Result lists the workflows and jobs that run for a ref
+type Result struct {
+	Ref       Ref           `json:"ref"`
+	Workflows []WorkflowRun `json:"workflows"`
+}
+
+// WorkflowRun is the simulated outcome of a single workflow
+type WorkflowRun struct {
+	Tool   string   `json:"tool"`
+	Source string   `json:"source"`
+	Name   string   `json:"name"`
+	Runs   bool     `json:"runs"`
+	Reason string   `json:"reason,omitempty"`
+	Jobs   []JobRun `json:"jobs"` // Dependency order
+}
+
+// JobRun is the simulated outcome of a single job
+type JobRun struct {
+	Name     string   `json:"name"`          // Invocation name, which requires: refers to
+	Job      string   `json:"job,omitempty"` // Job definition, when invoked under an alias
+	Runs     bool     `json:"runs"`
+	Stage    int      `json:"stage"` // 1 for jobs without dependencies
+	Requires []string `json:"requires,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// RunningJobs returns the names of the jobs that run, in dependency order
+func (w WorkflowRun) RunningJobs() []string {
+	var names []string
+	for _, job := range w.Jobs {
+		if job.Runs {
+			names = append(names, job.Name)
+		}
+	}
+	return names
+}